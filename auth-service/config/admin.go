@@ -0,0 +1,28 @@
+package config
+
+import "os"
+
+// AdminSeed is the admin account ensured on startup. Admins can not sign up
+// through /register, so this is how the first one is provisioned.
+type AdminSeed struct {
+	Email    string
+	Password string
+	FullName string
+}
+
+// LoadAdminSeed reads ADMIN_EMAIL, ADMIN_PASSWORD and ADMIN_FULLNAME. It
+// reports false when no admin is configured.
+func LoadAdminSeed() (AdminSeed, bool) {
+	seed := AdminSeed{
+		Email:    os.Getenv("ADMIN_EMAIL"),
+		Password: os.Getenv("ADMIN_PASSWORD"),
+		FullName: os.Getenv("ADMIN_FULLNAME"),
+	}
+	if seed.Email == "" || seed.Password == "" {
+		return AdminSeed{}, false
+	}
+	if seed.FullName == "" {
+		seed.FullName = "Administrator"
+	}
+	return seed, true
+}
@@ -12,16 +12,21 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
+// UpdateUserRequest is what users may change about their own account.
+// Role and balance are not among it.
 type UpdateUserRequest struct {
 	FullName string `json:"full_name" validate:"required"`
 	Address  string `json:"address" validate:"required"`
-	Role     string `json:"role" validate:"required,oneof=buyer seller"`
 }
 
 type UpdateBalanceRequest struct {
 	Amount float64 `json:"Amount" validate:"required"`
 }
 
+type DebitBalanceRequest struct {
+	Amount float64 `json:"amount" validate:"required"`
+}
+
 type ResendVerificationEmailRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
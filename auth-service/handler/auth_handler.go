@@ -3,14 +3,16 @@ package handler
 import (
 	"auth-service/dto"
 	"auth-service/helpers"
-	"auth-service/models"
+	"auth-service/repository"
 	"auth-service/service"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type AuthHandler struct {
@@ -195,6 +197,8 @@ func (h *AuthHandler) GetUserByID(c echo.Context) error {
 	})
 }
 
+// UpdateUser changes the name and address of the signed-in user's own
+// account.
 func (h *AuthHandler) UpdateUser(c echo.Context) error {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -205,16 +209,29 @@ func (h *AuthHandler) UpdateUser(c echo.Context) error {
 		})
 	}
 
-	var user models.User
-	if err := c.Bind(&user); err != nil {
+	userID, _ := c.Get("user_id").(uint)
+	if userID != uint(id) {
+		return c.JSON(http.StatusForbidden, dto.ErrorResponse{
+			Message: "You can only update your own account",
+			Code:    http.StatusForbidden,
+		})
+	}
+
+	var req dto.UpdateUserRequest
+	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, dto.ErrorResponse{
 			Message: "Invalid request",
 			Code:    http.StatusBadRequest,
 		})
 	}
+	if err := c.Validate(req); err != nil {
+		return c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Validation failed: " + err.Error(),
+			Code:    http.StatusBadRequest,
+		})
+	}
 
-	user.ID = uint(id)
-	updatedUser, err := h.Service.UpdateUser(user)
+	updatedUser, err := h.Service.UpdateUser(uint(id), req)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
 			Message: err.Error(),
@@ -224,6 +241,10 @@ func (h *AuthHandler) UpdateUser(c echo.Context) error {
 	return c.JSON(http.StatusOK, updatedUser)
 }
 
+// UpdateBalance credits a user's balance. Only other services may, with a
+// service token. Callers that may retry, such as transaction-service
+// paying out a sale, send an Idempotency-Key header so the credit is
+// applied only once.
 func (h *AuthHandler) UpdateBalance(c echo.Context) error {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
@@ -243,35 +264,78 @@ func (h *AuthHandler) UpdateBalance(c echo.Context) error {
 		})
 	}
 
-	user, err := h.Service.GetUserByID(uint(id))
+	updatedUser, err := h.Service.CreditBalance(uint(id), balanceRequest.Amount, c.Request().Header.Get("Idempotency-Key"))
 	if err != nil {
-		return c.JSON(http.StatusNotFound, dto.ErrorResponse{
-			Message: "User not found",
-			Code:    http.StatusNotFound,
+		return balanceError(c, err, "Failed to update user balance: ")
+	}
+	return c.JSON(http.StatusOK, dto.UpdateBalanceResponse{
+		Message: "User balance updated successfully",
+		ID:      updatedUser.ID,
+		Balance: updatedUser.Balance,
+	})
+
+}
+
+// DebitBalance takes an amount off a user's balance for another service,
+// such as transaction-service reversing a seller's credit. It never takes
+// the balance below zero, and a request repeated with the same
+// Idempotency-Key header is applied only once.
+func (h *AuthHandler) DebitBalance(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Invalid user ID",
+			Code:    http.StatusBadRequest,
 		})
 	}
 
-	if balanceRequest.Amount <= 0 {
+	var req dto.DebitBalanceRequest
+	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Message: "Amount must be greater than zero",
+			Message: "Invalid request",
 			Code:    http.StatusBadRequest,
 		})
 	}
 
-	user.Balance += balanceRequest.Amount
-	updatedUser, err := h.Service.UpdateUser(user)
+	user, err := h.Service.DebitBalance(uint(id), req.Amount, c.Request().Header.Get("Idempotency-Key"))
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Message: "Failed to update user balance: " + err.Error(),
-			Code:    http.StatusInternalServerError,
-		})
+		return balanceError(c, err, "Failed to debit user balance: ")
 	}
+
 	return c.JSON(http.StatusOK, dto.UpdateBalanceResponse{
-		Message: "User balance updated successfully",
-		ID:      updatedUser.ID,
-		Balance: updatedUser.Balance,
+		Message: "User balance debited successfully",
+		ID:      user.ID,
+		Balance: user.Balance,
 	})
+}
 
+func balanceError(c echo.Context, err error, prefix string) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, dto.ErrorResponse{
+			Message: "User not found",
+			Code:    http.StatusNotFound,
+		})
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return c.JSON(http.StatusConflict, dto.ErrorResponse{
+			Message: "Insufficient balance",
+			Code:    http.StatusConflict,
+		})
+	case errors.Is(err, repository.ErrIdempotencyKeyReused):
+		return c.JSON(http.StatusUnprocessableEntity, dto.ErrorResponse{
+			Message: "Idempotency key already used for a different request",
+			Code:    http.StatusUnprocessableEntity,
+		})
+	case err.Error() == "amount must be greater than zero":
+		return c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Message: "Amount must be greater than zero",
+			Code:    http.StatusBadRequest,
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
+		Message: prefix + err.Error(),
+		Code:    http.StatusInternalServerError,
+	})
 }
 
 func (h *AuthHandler) VerifyUser(c echo.Context) error {
@@ -4,16 +4,19 @@ package handler
 import (
 	"auth-service/dto"
 	"auth-service/models"
+	"auth-service/repository"
+	"auth-service/validator"
 	"bytes"
-	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 // Mock service
@@ -31,12 +34,11 @@ func (m *MockAuthService) GetUserByID(id uint) (models.User, error) {
 func (m *MockAuthService) CreateUser(dto.RegisterRequest) (models.User, error) {
 	return models.User{}, nil
 }
-func (m *MockAuthService) UpdateUser(user models.User) (models.User, error) {
-	if user.ID == 99 {
+func (m *MockAuthService) UpdateUser(id uint, req dto.UpdateUserRequest) (models.User, error) {
+	if id == 99 {
 		return models.User{}, errors.New("user not found")
 	}
-	user.Fullname = "Updated Name"
-	return user, nil
+	return models.User{ID: id, Fullname: req.FullName, Address: req.Address, Role: "buyer"}, nil
 }
 func (m *MockAuthService) GetUserByEmail(email string) (models.User, error) {
 	if email == "notfound@mail.com" {
@@ -63,6 +65,30 @@ func (m *MockAuthService) VerifyUser(email string) (models.User, error) {
 func (m *MockAuthService) DeleteInactiveUsersOver30Days() error {
 	panic("not implemented")
 }
+func (m *MockAuthService) CreditBalance(id uint, amount float64, idempotencyKey string) (models.User, error) {
+	if id == 99 {
+		return models.User{}, gorm.ErrRecordNotFound
+	}
+	if amount <= 0 {
+		return models.User{}, errors.New("amount must be greater than zero")
+	}
+	if idempotencyKey == "reused" {
+		return models.User{}, repository.ErrIdempotencyKeyReused
+	}
+	return models.User{ID: id, Balance: 100 + amount}, nil
+}
+func (m *MockAuthService) DebitBalance(id uint, amount float64, idempotencyKey string) (models.User, error) {
+	if id == 99 {
+		return models.User{}, gorm.ErrRecordNotFound
+	}
+	if amount > 100 {
+		return models.User{}, repository.ErrInsufficientBalance
+	}
+	return models.User{ID: id, Balance: 100 - amount}, nil
+}
+func (m *MockAuthService) EnsureAdmin(email, password, fullName string) (models.User, error) {
+	panic("not implemented")
+}
 
 func TestGetUserByID(t *testing.T) {
 	e := echo.New()
@@ -87,34 +113,61 @@ func TestGetUserByID(t *testing.T) {
 	}
 }
 
+func updateUserContext(e *echo.Echo, userID uint, id int, body string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPut, "/users/"+strconv.Itoa(id), strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.SetPath("/users/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(strconv.Itoa(id))
+	c.Set("user_id", userID)
+	return c, rec
+}
+
 func TestUpdateUser(t *testing.T) {
 	e := echo.New()
+	e.Validator = validator.New()
 
 	mockService := &MockAuthService{}
 	handler := &AuthHandler{Service: mockService}
 
-	// Sample user payload
-	userPayload := models.User{
-		Fullname: "Old Name",
-		Email:    "test@example.com",
-		Address:  "123 Test St",
+	c, rec := updateUserContext(e, 1, 1, `{"full_name":"Updated Name","address":"123 Test St"}`)
+
+	if assert.NoError(t, handler.UpdateUser(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Updated Name")
 	}
+}
 
-	jsonBody, _ := json.Marshal(userPayload)
-	req := httptest.NewRequest(http.MethodPut, "/users/1", bytes.NewReader(jsonBody))
-	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
-	rec := httptest.NewRecorder()
+func TestUpdateUser_IgnoresRoleAndBalance(t *testing.T) {
+	e := echo.New()
+	e.Validator = validator.New()
 
-	// Set context
-	c := e.NewContext(req, rec)
-	c.SetPath("/users/:id")
-	c.SetParamNames("id")
-	c.SetParamValues(strconv.Itoa(1))
+	mockService := &MockAuthService{}
+	handler := &AuthHandler{Service: mockService}
+
+	c, rec := updateUserContext(e, 1, 1, `{"full_name":"Updated Name","address":"123 Test St","Role":"admin","Balance":1000000}`)
 
-	// Act
 	if assert.NoError(t, handler.UpdateUser(c)) {
 		assert.Equal(t, http.StatusOK, rec.Code)
-		assert.Contains(t, rec.Body.String(), "Updated Name")
+		assert.NotContains(t, rec.Body.String(), "admin")
+		assert.NotContains(t, rec.Body.String(), "1000000")
+	}
+}
+
+func TestUpdateUser_OtherAccount(t *testing.T) {
+	e := echo.New()
+	e.Validator = validator.New()
+
+	mockService := &MockAuthService{}
+	handler := &AuthHandler{Service: mockService}
+
+	c, rec := updateUserContext(e, 2, 1, `{"full_name":"Updated Name","address":"123 Test St"}`)
+
+	if assert.NoError(t, handler.UpdateUser(c)) {
+		assert.Equal(t, http.StatusForbidden, rec.Code)
 	}
 }
 
@@ -154,3 +207,69 @@ func TestDeleteUser_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.EqualError(t, err, "user not found")
 }
+
+func TestDebitBalance(t *testing.T) {
+	e := echo.New()
+	h := &AuthHandler{Service: &MockAuthService{}}
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		status int
+	}{
+		{"debited", "1", `{"amount":40}`, http.StatusOK},
+		{"insufficient balance", "1", `{"amount":150}`, http.StatusConflict},
+		{"unknown user", "99", `{"amount":40}`, http.StatusNotFound},
+		{"invalid id", "abc", `{"amount":40}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/users/"+tt.id+"/balance/debit", bytes.NewBufferString(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			if assert.NoError(t, h.DebitBalance(c)) {
+				assert.Equal(t, tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+func TestUpdateBalance(t *testing.T) {
+	e := echo.New()
+	h := &AuthHandler{Service: &MockAuthService{}}
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		key    string
+		status int
+	}{
+		{"credited", "1", `{"Amount":40}`, "transaction-1-credit", http.StatusOK},
+		{"non-positive amount", "1", `{"Amount":0}`, "", http.StatusBadRequest},
+		{"reused key", "1", `{"Amount":40}`, "reused", http.StatusUnprocessableEntity},
+		{"unknown user", "99", `{"Amount":40}`, "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/users/"+tt.id, bytes.NewBufferString(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set("Idempotency-Key", tt.key)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			if assert.NoError(t, h.UpdateBalance(c)) {
+				assert.Equal(t, tt.status, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+)
+
+// ServiceAudience is the audience other services sign their tokens for
+// when calling auth-service.
+const ServiceAudience = "auth-service"
+
+// ServiceClaims are the claims of a token another service signs with
+// INTERNAL_SERVICE_SECRET to call auth-service's internal endpoints.
+type ServiceClaims struct {
+	Service string `json:"service"`
+	jwt.RegisteredClaims
+}
+
+// ParseServiceToken verifies a service token. Without INTERNAL_SERVICE_SECRET
+// no token is accepted.
+func ParseServiceToken(tokenString string) (*ServiceClaims, error) {
+	godotenv.Load()
+	secret := os.Getenv("INTERNAL_SERVICE_SECRET")
+	if secret == "" {
+		return nil, errors.New("INTERNAL_SERVICE_SECRET is not set")
+	}
+
+	claims := &ServiceClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(ServiceAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Service == "" {
+		return nil, errors.New("service token has no service")
+	}
+	return claims, nil
+}
+
+// ExtractBearerToken reads the bearer token from an Authorization header.
+func ExtractBearerToken(authorization string) string {
+	token := strings.TrimPrefix(authorization, "Bearer ")
+	if token == authorization {
+		return ""
+	}
+	return token
+}
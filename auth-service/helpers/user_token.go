@@ -0,0 +1,42 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+)
+
+// UserClaims are the claims of the token GenerateJWT gives a user at
+// login.
+type UserClaims struct {
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+	Email  string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// ParseUserToken verifies a user's login token.
+func ParseUserToken(tokenString string) (*UserClaims, error) {
+	godotenv.Load()
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, errors.New("JWT_SECRET is not set")
+	}
+
+	claims := &UserClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID == 0 {
+		return nil, errors.New("token has no user")
+	}
+	return claims, nil
+}
@@ -11,6 +11,7 @@ import (
 	"auth-service/validator"
 
 	"fmt"
+	"log"
 
 	"net/http"
 
@@ -25,7 +26,7 @@ func main() {
 	}
 
 	// Migrate the User model
-	db.AutoMigrate(&models.User{})
+	db.AutoMigrate(&models.User{}, &models.BalanceOperation{})
 
 	e := echo.New()
 	e.Validator = validator.New()
@@ -37,6 +38,12 @@ func main() {
 	authService := service.NewAuthService(authRepo)
 	authHandler := handler.NewAuthHandler(authService)
 
+	if seed, ok := config.LoadAdminSeed(); ok {
+		if _, err := authService.EnsureAdmin(seed.Email, seed.Password, seed.FullName); err != nil {
+			log.Println("Failed to provision admin account:", err)
+		}
+	}
+
 	routes.SetupRoutes(e, authHandler)
 
 	jobs.StartCleanupJob(authRepo)
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"auth-service/dto"
+	"auth-service/helpers"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ServiceAuth only lets other services through, authenticated with a token
+// signed with INTERNAL_SERVICE_SECRET. The calling service's name is set
+// as "service".
+func ServiceAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := helpers.ExtractBearerToken(c.Request().Header.Get("Authorization"))
+		claims, err := helpers.ParseServiceToken(token)
+		if token == "" || err != nil {
+			return c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Message: "Service token required",
+				Code:    http.StatusUnauthorized,
+			})
+		}
+
+		c.Set("service", claims.Service)
+		return next(c)
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"auth-service/dto"
+	"auth-service/helpers"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// UserAuth only lets signed-in users through, authenticated with the token
+// they got at login. Their ID is set as "user_id" and their role as
+// "role".
+func UserAuth(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		token := helpers.ExtractBearerToken(c.Request().Header.Get("Authorization"))
+		claims, err := helpers.ParseUserToken(token)
+		if token == "" || err != nil {
+			return c.JSON(http.StatusUnauthorized, dto.ErrorResponse{
+				Message: "Invalid or missing token",
+				Code:    http.StatusUnauthorized,
+			})
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("role", claims.Role)
+		return next(c)
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Kinds of balance operation.
+const (
+	BalanceCredit = "credit"
+	BalanceDebit  = "debit"
+)
+
+// BalanceOperation is a balance change made under the caller's idempotency
+// key, so a retried request is applied only once.
+type BalanceOperation struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	IdempotencyKey string    `gorm:"type:varchar(100);uniqueIndex;not null"`
+	UserID         uint      `gorm:"not null;index"`
+	Kind           string    `gorm:"type:varchar(10);not null"`
+	Amount         float64   `gorm:"type:decimal(12,2);not null"`
+	CreatedAt      time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
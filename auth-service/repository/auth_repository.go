@@ -2,18 +2,27 @@ package repository
 
 import (
 	"auth-service/models"
+	"errors"
 	"fmt"
 
 	"gorm.io/gorm"
 )
 
+var (
+	ErrInsufficientBalance  = errors.New("insufficient balance")
+	ErrIdempotencyKeyReused = errors.New("idempotency key already used for a different request")
+)
+
 type AuthRepository interface {
 	GetUserByID(id uint) (models.User, error)
 	GetUserByEmail(email string) (models.User, error)
 	CreateUser(user models.User) (models.User, error)
 	UpdateUser(user models.User) (models.User, error)
+	UpdateProfile(id uint, fullName string, address string) (models.User, error)
 	DeleteInactiveUsersOver30Days() error
 	VerifyUser(email string) (models.User, error)
+	CreditBalance(id uint, amount float64, idempotencyKey string) (models.User, error)
+	DebitBalance(id uint, amount float64, idempotencyKey string) (models.User, error)
 }
 
 type authRepository struct {
@@ -64,6 +73,19 @@ func (r *authRepository) UpdateUser(user models.User) (models.User, error) {
 	return user, nil
 }
 
+// UpdateProfile sets only the user's name and address.
+func (r *authRepository) UpdateProfile(id uint, fullName string, address string) (models.User, error) {
+	result := r.db.Model(&models.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"fullname": fullName, "address": address})
+	if result.Error != nil {
+		return models.User{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return models.User{}, gorm.ErrRecordNotFound
+	}
+	return r.GetUserByID(id)
+}
+
 func (r *authRepository) VerifyUser(email string) (models.User, error) {
 	var user models.User
 	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
@@ -80,3 +102,92 @@ func (r *authRepository) VerifyUser(email string) (models.User, error) {
 
 	return user, nil
 }
+
+// CreditBalance adds amount to the user's balance in a single update, so
+// concurrent credits are never lost.
+func (r *authRepository) CreditBalance(id uint, amount float64, idempotencyKey string) (models.User, error) {
+	return r.changeBalance(id, models.BalanceCredit, amount, idempotencyKey, func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ?", id).
+			Update("balance", gorm.Expr("balance + ?", amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// DebitBalance takes amount off the user's balance in a single update that
+// refuses to take the balance below zero, so concurrent debits can not
+// overdraw it.
+func (r *authRepository) DebitBalance(id uint, amount float64, idempotencyKey string) (models.User, error) {
+	return r.changeBalance(id, models.BalanceDebit, amount, idempotencyKey, func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).
+			Where("id = ? AND balance >= ?", id, amount).
+			Update("balance", gorm.Expr("balance - ?", amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if err := tx.First(&models.User{}, id).Error; err != nil {
+				return err
+			}
+			return ErrInsufficientBalance
+		}
+		return nil
+	})
+}
+
+// changeBalance applies update and, when an idempotency key is given,
+// records it in the same database transaction. A request repeated with
+// the same key, even one racing the first, is not applied again and gets
+// the user's current balance; reusing a key for a different request is an
+// error.
+func (r *authRepository) changeBalance(id uint, kind string, amount float64, idempotencyKey string, update func(tx *gorm.DB) error) (models.User, error) {
+	op := models.BalanceOperation{
+		IdempotencyKey: idempotencyKey,
+		UserID:         id,
+		Kind:           kind,
+		Amount:         amount,
+	}
+
+	if idempotencyKey != "" {
+		if existing, err := r.getBalanceOperation(idempotencyKey); err == nil {
+			return r.replayBalance(existing, op)
+		}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if idempotencyKey != "" {
+			if err := tx.Create(&op).Error; err != nil {
+				return err
+			}
+		}
+		return update(tx)
+	})
+	if err != nil {
+		if idempotencyKey != "" {
+			if existing, getErr := r.getBalanceOperation(idempotencyKey); getErr == nil {
+				return r.replayBalance(existing, op)
+			}
+		}
+		return models.User{}, err
+	}
+	return r.GetUserByID(id)
+}
+
+func (r *authRepository) getBalanceOperation(idempotencyKey string) (models.BalanceOperation, error) {
+	var op models.BalanceOperation
+	err := r.db.Where("idempotency_key = ?", idempotencyKey).First(&op).Error
+	return op, err
+}
+
+func (r *authRepository) replayBalance(existing models.BalanceOperation, op models.BalanceOperation) (models.User, error) {
+	if existing.UserID != op.UserID || existing.Kind != op.Kind || existing.Amount != op.Amount {
+		return models.User{}, ErrIdempotencyKeyReused
+	}
+	return r.GetUserByID(op.UserID)
+}
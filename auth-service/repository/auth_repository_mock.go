@@ -26,6 +26,10 @@ func (m *MockAuthRepository) UpdateUser(user models.User) (models.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(models.User), args.Error(1)
 }
+func (m *MockAuthRepository) UpdateProfile(id uint, fullName string, address string) (models.User, error) {
+	args := m.Called(id, fullName, address)
+	return args.Get(0).(models.User), args.Error(1)
+}
 func (m *MockAuthRepository) DeleteInactiveUsersOver30Days() error {
 	args := m.Called()
 	return args.Error(0)
@@ -34,3 +38,11 @@ func (m *MockAuthRepository) VerifyUser(email string) (models.User, error) {
 	args := m.Called(email)
 	return args.Get(0).(models.User), args.Error(1)
 }
+func (m *MockAuthRepository) CreditBalance(id uint, amount float64, idempotencyKey string) (models.User, error) {
+	args := m.Called(id, amount, idempotencyKey)
+	return args.Get(0).(models.User), args.Error(1)
+}
+func (m *MockAuthRepository) DebitBalance(id uint, amount float64, idempotencyKey string) (models.User, error) {
+	args := m.Called(id, amount, idempotencyKey)
+	return args.Get(0).(models.User), args.Error(1)
+}
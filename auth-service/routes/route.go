@@ -2,6 +2,7 @@ package routes
 
 import (
 	"auth-service/handler"
+	"auth-service/middleware"
 
 	"github.com/labstack/echo/v4"
 )
@@ -10,8 +11,9 @@ func SetupRoutes(e *echo.Echo, h *handler.AuthHandler) {
 	e.POST("/register", h.Register)
 	e.POST("/login", h.Login)
 	e.GET("/users/:id", h.GetUserByID)
-	e.PUT("/users/:id", h.UpdateUser)
-	e.PATCH("/users/:id", h.UpdateBalance)
+	e.PUT("/users/:id", h.UpdateUser, middleware.UserAuth)
+	e.PATCH("/users/:id", h.UpdateBalance, middleware.ServiceAuth)
+	e.POST("/users/:id/balance/debit", h.DebitBalance, middleware.ServiceAuth)
 	e.POST("/users/verify", h.VerifyUser)
 	e.POST("/users/resend-verification-email", h.ResendVerificationEmail)
 }
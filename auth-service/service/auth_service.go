@@ -4,6 +4,7 @@ import (
 	"auth-service/dto"
 	"auth-service/models"
 	"auth-service/repository"
+	"errors"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -11,13 +12,20 @@ import (
 type AuthService interface {
 	GetUserByID(id uint) (models.User, error)
 	CreateUser(user dto.RegisterRequest) (models.User, error)
-	UpdateUser(user models.User) (models.User, error)
+	UpdateUser(id uint, req dto.UpdateUserRequest) (models.User, error)
 	GetUserByEmail(email string) (models.User, error)
 	DeleteInactiveUsersOver30Days() error
 
 	VerifyUser(email string) (models.User, error)
+	CreditBalance(id uint, amount float64, idempotencyKey string) (models.User, error)
+	DebitBalance(id uint, amount float64, idempotencyKey string) (models.User, error)
+	EnsureAdmin(email, password, fullName string) (models.User, error)
 }
 
+// ErrAdminEmailTaken is returned by EnsureAdmin when the admin email already
+// belongs to a buyer or seller account.
+var ErrAdminEmailTaken = errors.New("admin email is already registered to a non-admin account")
+
 type authService struct {
 	repo repository.AuthRepository
 }
@@ -65,8 +73,11 @@ func (s *authService) CreateUser(user dto.RegisterRequest) (models.User, error)
 	}
 	return createdUser, nil
 }
-func (s *authService) UpdateUser(user models.User) (models.User, error) {
-	updatedUser, err := s.repo.UpdateUser(user)
+
+// UpdateUser changes a user's name and address; nothing else about the
+// account can be changed this way.
+func (s *authService) UpdateUser(id uint, req dto.UpdateUserRequest) (models.User, error) {
+	updatedUser, err := s.repo.UpdateProfile(id, req.FullName, req.Address)
 	if err != nil {
 		return models.User{}, err
 	}
@@ -88,3 +99,50 @@ func (s *authService) VerifyUser(email string) (models.User, error) {
 	}
 	return user, nil
 }
+
+// CreditBalance adds amount to the user's balance. A request repeated with
+// the same idempotency key is only applied once.
+func (s *authService) CreditBalance(id uint, amount float64, idempotencyKey string) (models.User, error) {
+	if amount <= 0 {
+		return models.User{}, errors.New("amount must be greater than zero")
+	}
+	return s.repo.CreditBalance(id, amount, idempotencyKey)
+}
+
+// DebitBalance takes amount off the user's balance, failing with
+// repository.ErrInsufficientBalance when it does not cover it. A request
+// repeated with the same idempotency key is only applied once.
+func (s *authService) DebitBalance(id uint, amount float64, idempotencyKey string) (models.User, error) {
+	if amount <= 0 {
+		return models.User{}, errors.New("amount must be greater than zero")
+	}
+	return s.repo.DebitBalance(id, amount, idempotencyKey)
+}
+
+// EnsureAdmin creates a verified admin account with the given email unless
+// one already exists. An existing admin is left untouched, password
+// included, so restarting the service never resets it.
+func (s *authService) EnsureAdmin(email, password, fullName string) (models.User, error) {
+	existing, err := s.repo.GetUserByEmail(email)
+	if err == nil {
+		if existing.Role != "admin" {
+			return models.User{}, ErrAdminEmailTaken
+		}
+		return existing, nil
+	}
+	if err.Error() != "email not found" {
+		return models.User{}, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+	return s.repo.CreateUser(models.User{
+		Fullname:   fullName,
+		Email:      email,
+		Password:   string(hashedPassword),
+		Role:       "admin",
+		IsVerified: true,
+	})
+}
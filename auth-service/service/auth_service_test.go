@@ -4,6 +4,7 @@ import (
 	"auth-service/dto"
 	"auth-service/models"
 	"auth-service/repository"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -84,20 +85,17 @@ func TestUpdateUser(t *testing.T) {
 	mockRepo := new(repository.MockAuthRepository)
 	svc := NewAuthService(mockRepo)
 
-	inputUser := models.User{
+	expectedUser := models.User{
 		ID:       1,
 		Fullname: "Jane Doe",
 		Email:    "jane@example.com",
 		Role:     "buyer",
-		Address:  "Old Address",
+		Address:  "New Address",
 	}
 
-	expectedUser := inputUser
-	expectedUser.Address = "New Address"
-
-	mockRepo.On("UpdateUser", inputUser).Return(expectedUser, nil)
+	mockRepo.On("UpdateProfile", uint(1), "Jane Doe", "New Address").Return(expectedUser, nil)
 
-	result, err := svc.UpdateUser(inputUser)
+	result, err := svc.UpdateUser(1, dto.UpdateUserRequest{FullName: "Jane Doe", Address: "New Address"})
 	assert.NoError(t, err)
 	assert.Equal(t, expectedUser, result)
 	mockRepo.AssertExpectations(t)
@@ -119,3 +117,96 @@ func TestVerifyUser_AlreadyVerified(t *testing.T) {
 	assert.Equal(t, true, user.IsVerified)
 	mockRepo.AssertNotCalled(t, "UpdateUser")
 }
+
+func TestDebitBalance(t *testing.T) {
+	mockRepo := new(repository.MockAuthRepository)
+	svc := NewAuthService(mockRepo)
+
+	mockRepo.On("DebitBalance", uint(1), 40.0, "transaction-1-credit-reversal").Return(models.User{ID: 1, Balance: 60}, nil)
+
+	user, err := svc.DebitBalance(1, 40, "transaction-1-credit-reversal")
+	assert.NoError(t, err)
+	assert.Equal(t, 60.0, user.Balance)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDebitBalance_InsufficientBalance(t *testing.T) {
+	mockRepo := new(repository.MockAuthRepository)
+	svc := NewAuthService(mockRepo)
+
+	mockRepo.On("DebitBalance", uint(1), 150.0, "").Return(models.User{}, repository.ErrInsufficientBalance)
+
+	_, err := svc.DebitBalance(1, 150, "")
+	assert.ErrorIs(t, err, repository.ErrInsufficientBalance)
+}
+
+func TestDebitBalance_NonPositiveAmount(t *testing.T) {
+	mockRepo := new(repository.MockAuthRepository)
+	svc := NewAuthService(mockRepo)
+
+	_, err := svc.DebitBalance(1, 0, "")
+	assert.EqualError(t, err, "amount must be greater than zero")
+	mockRepo.AssertNotCalled(t, "DebitBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreditBalance(t *testing.T) {
+	mockRepo := new(repository.MockAuthRepository)
+	svc := NewAuthService(mockRepo)
+
+	mockRepo.On("CreditBalance", uint(1), 40.0, "transaction-1-credit").Return(models.User{ID: 1, Balance: 140}, nil)
+
+	user, err := svc.CreditBalance(1, 40, "transaction-1-credit")
+	assert.NoError(t, err)
+	assert.Equal(t, 140.0, user.Balance)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreditBalance_NonPositiveAmount(t *testing.T) {
+	mockRepo := new(repository.MockAuthRepository)
+	svc := NewAuthService(mockRepo)
+
+	_, err := svc.CreditBalance(1, -5, "")
+	assert.EqualError(t, err, "amount must be greater than zero")
+	mockRepo.AssertNotCalled(t, "CreditBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEnsureAdmin_CreatesVerifiedAdmin(t *testing.T) {
+	mockRepo := new(repository.MockAuthRepository)
+	svc := NewAuthService(mockRepo)
+
+	mockRepo.On("GetUserByEmail", "admin@example.com").Return(models.User{}, errors.New("email not found"))
+	mockRepo.On("CreateUser", mock.MatchedBy(func(user models.User) bool {
+		err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("adminpass"))
+		return user.Email == "admin@example.com" && user.Role == "admin" && user.IsVerified && err == nil
+	})).Return(models.User{ID: 1, Email: "admin@example.com", Role: "admin", IsVerified: true}, nil)
+
+	admin, err := svc.EnsureAdmin("admin@example.com", "adminpass", "Admin")
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", admin.Role)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestEnsureAdmin_ExistingAdminUntouched(t *testing.T) {
+	mockRepo := new(repository.MockAuthRepository)
+	svc := NewAuthService(mockRepo)
+
+	existing := models.User{ID: 1, Email: "admin@example.com", Role: "admin", IsVerified: true}
+	mockRepo.On("GetUserByEmail", "admin@example.com").Return(existing, nil)
+
+	admin, err := svc.EnsureAdmin("admin@example.com", "newpass", "Admin")
+	assert.NoError(t, err)
+	assert.Equal(t, existing, admin)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateUser", mock.Anything)
+}
+
+func TestEnsureAdmin_EmailTakenByBuyer(t *testing.T) {
+	mockRepo := new(repository.MockAuthRepository)
+	svc := NewAuthService(mockRepo)
+
+	mockRepo.On("GetUserByEmail", "admin@example.com").Return(models.User{ID: 2, Email: "admin@example.com", Role: "buyer"}, nil)
+
+	_, err := svc.EnsureAdmin("admin@example.com", "adminpass", "Admin")
+	assert.ErrorIs(t, err, ErrAdminEmailTaken)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
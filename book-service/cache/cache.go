@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrCacheMiss = errors.New("cache miss")
+
+// Cache is the minimal key/value store the service needs. Implementations
+// must be safe for concurrent use.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+type noopCache struct{}
+
+// NewNoopCache returns a cache that stores nothing, so every read falls
+// through to the database.
+func NewNoopCache() Cache {
+	return noopCache{}
+}
+
+func (noopCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, ErrCacheMiss
+}
+
+func (noopCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (noopCache) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
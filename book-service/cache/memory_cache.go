@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// memorySweepInterval is how often writes sweep out expired entries, and
+// memoryMaxEntries how many entries the cache holds at most.
+const (
+	memorySweepInterval = time.Minute
+	memoryMaxEntries    = 10000
+)
+
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+type memoryCache struct {
+	mu        sync.RWMutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryCache returns a process-local cache, used when Redis is not
+// configured. Expired entries are dropped when read, and swept out by
+// writes at most once every memorySweepInterval: list keys are orphaned
+// whenever the list version is bumped and never read again. Past
+// memoryMaxEntries, writes make room by evicting entries.
+func NewMemoryCache() Cache {
+	return &memoryCache{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.RLock()
+	entry, ok := m.entries[key]
+	m.mu.RUnlock()
+
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	if entry.expired(m.now()) {
+		m.mu.Lock()
+		delete(m.entries, key)
+		m.mu.Unlock()
+		return nil, ErrCacheMiss
+	}
+	return entry.value, nil
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	now := m.now()
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = now.Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.lastSweep) >= memorySweepInterval {
+		m.sweep(now)
+	}
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= memoryMaxEntries {
+		m.sweep(now)
+		m.evict(len(m.entries) - memoryMaxEntries + 1)
+	}
+	m.entries[key] = entry
+	return nil
+}
+
+// sweep drops every expired entry. m.mu must be held.
+func (m *memoryCache) sweep(now time.Time) {
+	for key, entry := range m.entries {
+		if entry.expired(now) {
+			delete(m.entries, key)
+		}
+	}
+	m.lastSweep = now
+}
+
+// evict drops n entries, whichever come first. It is a cache, so any of
+// them may go. m.mu must be held.
+func (m *memoryCache) evict(n int) {
+	for key := range m.entries {
+		if n <= 0 {
+			return
+		}
+		delete(m.entries, key)
+		n--
+	}
+}
+
+func (m *memoryCache) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	m.mu.Unlock()
+	return nil
+}
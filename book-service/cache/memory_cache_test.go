@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCache_SweepsOrphanedExpiredEntries(t *testing.T) {
+	now := time.Now()
+	m := NewMemoryCache().(*memoryCache)
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// List pages under an old version are never read again
+	for i := 0; i < 50; i++ {
+		m.Set(ctx, fmt.Sprintf("books:list:1:page:%d", i), []byte("[]"), time.Minute)
+	}
+	assert.Len(t, m.entries, 50)
+
+	now = now.Add(2 * time.Minute)
+	m.Set(ctx, "books:list:2:page:0", []byte("[]"), time.Minute)
+
+	assert.Len(t, m.entries, 1)
+	_, err := m.Get(ctx, "books:list:2:page:0")
+	assert.NoError(t, err)
+}
+
+func TestMemoryCache_BoundsEntries(t *testing.T) {
+	m := NewMemoryCache().(*memoryCache)
+	ctx := context.Background()
+
+	for i := 0; i < memoryMaxEntries+100; i++ {
+		m.Set(ctx, fmt.Sprintf("book:%d", i), []byte("{}"), time.Hour)
+	}
+
+	assert.Len(t, m.entries, memoryMaxEntries)
+	_, err := m.Get(ctx, fmt.Sprintf("book:%d", memoryMaxEntries+99))
+	assert.NoError(t, err)
+}
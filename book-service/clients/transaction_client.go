@@ -0,0 +1,153 @@
+package clients
+
+import (
+	"book-service/helpers"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// Transaction is the part of a transaction-service transaction that
+// book-service relies on.
+type Transaction struct {
+	TransactionID uint              `json:"transaction_id"`
+	UserID        uint              `json:"user_id"`
+	SellerID      uint              `json:"seller_id"`
+	BookID        uint              `json:"book_id"`
+	Amount        float64           `json:"amount"`
+	Status        string            `json:"status"`
+	Items         []TransactionItem `json:"items"`
+}
+
+// TransactionItem is one book of a transaction, with the seller it was
+// bought from.
+type TransactionItem struct {
+	BookID   uint `json:"book_id"`
+	SellerID uint `json:"seller_id"`
+}
+
+// Item returns the transaction's item for bookID. Transactions placed
+// before orders had items are treated as a single item of their book.
+func (t *Transaction) Item(bookID uint) (TransactionItem, bool) {
+	if len(t.Items) == 0 {
+		if bookID == t.BookID {
+			return TransactionItem{BookID: t.BookID, SellerID: t.SellerID}, true
+		}
+		return TransactionItem{}, false
+	}
+	for _, item := range t.Items {
+		if item.BookID == bookID {
+			if item.SellerID == 0 {
+				item.SellerID = t.SellerID
+			}
+			return item, true
+		}
+	}
+	return TransactionItem{}, false
+}
+
+// Paid reports whether the buyer paid for the transaction and was not
+// refunded, wherever it is in shipping.
+func (t *Transaction) Paid() bool {
+	switch t.Status {
+	case "paid", "shipped", "delivered", "completed":
+		return true
+	}
+	return false
+}
+
+// DailySales sums a book's paid transactions on one day (UTC).
+type DailySales struct {
+	BookID  uint      `json:"book_id"`
+	Day     time.Time `json:"day"`
+	Sales   int64     `json:"sales"`
+	Revenue float64   `json:"revenue"`
+}
+
+type TransactionClient interface {
+	// GetTransaction fetches a transaction on behalf of the user whose
+	// Authorization header is passed along. Transactions that do not belong
+	// to that user are reported as not found.
+	GetTransaction(id uint, authorization string) (*Transaction, error)
+	// GetDailySales fetches the sales of every book for the days from
+	// through to, authenticating with a service token.
+	GetDailySales(from time.Time, to time.Time) ([]DailySales, error)
+}
+
+type transactionClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+func NewTransactionClient(baseURL string) TransactionClient {
+	return &transactionClient{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *transactionClient) GetTransaction(id uint, authorization string) (*Transaction, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/transactions/%d", c.baseURL, id), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusForbidden:
+		return nil, ErrTransactionNotFound
+	default:
+		return nil, fmt.Errorf("transaction-service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data Transaction `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return &body.Data, nil
+}
+
+func (c *transactionClient) GetDailySales(from time.Time, to time.Time) ([]DailySales, error) {
+	token, err := helpers.NewServiceToken("transaction-service")
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/internal/sales/daily?from=%s&to=%s", c.baseURL, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transaction-service returned %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data []DailySales `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+type AnalyticsConfig struct {
+	SyncInterval  time.Duration
+	SalesLookback time.Duration
+}
+
+func LoadAnalyticsConfig() AnalyticsConfig {
+	return AnalyticsConfig{
+		SyncInterval:  durationFromEnv("ANALYTICS_SYNC_INTERVAL", 15*time.Minute),
+		SalesLookback: durationFromEnv("ANALYTICS_SALES_LOOKBACK", 72*time.Hour),
+	}
+}
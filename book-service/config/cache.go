@@ -0,0 +1,78 @@
+package config
+
+import (
+	"book-service/cache"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type CacheConfig struct {
+	BookTTL time.Duration
+	ListTTL time.Duration
+}
+
+// InitCache picks the cache backend from CACHE_DRIVER (redis, memory or
+// none). When the driver is not set, Redis is used if REDIS_ADDR is present
+// and the in-memory cache otherwise. An unreachable Redis falls back to the
+// in-memory cache so the service can still start.
+func InitCache() (cache.Cache, CacheConfig) {
+	cfg := CacheConfig{
+		BookTTL: durationFromEnv("CACHE_BOOK_TTL", 10*time.Minute),
+		ListTTL: durationFromEnv("CACHE_LIST_TTL", time.Minute),
+	}
+
+	driver := os.Getenv("CACHE_DRIVER")
+	addr := os.Getenv("REDIS_ADDR")
+	if driver == "" {
+		driver = "memory"
+		if addr != "" {
+			driver = "redis"
+		}
+	}
+
+	switch driver {
+	case "none":
+		log.Println("Cache disabled")
+		return cache.NewNoopCache(), cfg
+	case "redis":
+		db, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+		client := redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: os.Getenv("REDIS_PASSWORD"),
+			DB:       db,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := client.Ping(ctx).Err(); err != nil {
+			log.Printf("Warning: Redis unavailable (%v), using in-memory cache", err)
+			client.Close()
+			return cache.NewMemoryCache(), cfg
+		}
+
+		log.Println("Redis cache connected")
+		return cache.NewRedisCache(client), cfg
+	default:
+		log.Println("Using in-memory cache")
+		return cache.NewMemoryCache(), cfg
+	}
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"book-service/helpers"
+	"book-service/model"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// MigrateCategories files books that only have a free-text category under a
+// managed category. Strings that share a slug ("Fiction", "fiction ") end up
+// in the same category, which is created on first use.
+func MigrateCategories(db *gorm.DB) error {
+	var names []string
+	err := db.Model(&model.Book{}).
+		Where("category_id IS NULL AND category <> ''").
+		Distinct().
+		Pluck("category", &names).Error
+	if err != nil {
+		return err
+	}
+
+	migrated := 0
+	for _, name := range names {
+		slug := helpers.Slugify(name)
+		if slug == "" {
+			continue
+		}
+
+		category := model.Category{Name: strings.TrimSpace(name), Slug: slug}
+		if err := db.Where("slug = ?", slug).FirstOrCreate(&category).Error; err != nil {
+			return err
+		}
+
+		result := db.Model(&model.Book{}).
+			Where("category_id IS NULL AND category = ?", name).
+			Updates(map[string]interface{}{
+				"category_id": category.ID,
+				"category":    category.Name,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		migrated += int(result.RowsAffected)
+	}
+
+	if migrated > 0 {
+		log.Printf("Filed %d books under managed categories", migrated)
+	}
+	return nil
+}
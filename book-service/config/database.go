@@ -21,16 +21,24 @@ func InitDB() *gorm.DB {
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		host, port, user, password, dbname, sslmode)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
-	err = db.AutoMigrate(&model.Book{})
+	err = db.AutoMigrate(&model.Book{}, &model.Reservation{}, &model.ImportJob{}, &model.Category{}, &model.WishlistItem{}, &model.WishlistAlert{}, &model.Review{}, &model.ReviewReport{}, &model.BookChange{}, &model.Offer{}, &model.StockOperation{}, &model.BookView{}, &model.BookDailyStat{}, &model.ProcessedEvent{})
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	if err := MigrateCategories(db); err != nil {
+		log.Fatalf("Failed to migrate book categories: %v", err)
+	}
+
+	if err := MigrateBookStatuses(db); err != nil {
+		log.Fatalf("Failed to migrate book statuses: %v", err)
+	}
+
 	log.Println("Database connected and migrated successfully")
 	return db
 }
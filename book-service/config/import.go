@@ -0,0 +1,33 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+type ImportConfig struct {
+	AsyncThreshold int
+	ChunkSize      int
+}
+
+func LoadImportConfig() ImportConfig {
+	return ImportConfig{
+		AsyncThreshold: intFromEnv("IMPORT_ASYNC_THRESHOLD", 100),
+		ChunkSize:      intFromEnv("IMPORT_CHUNK_SIZE", 50),
+	}
+}
+
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+type OfferConfig struct {
+	ResponseTTL   time.Duration
+	AcceptedTTL   time.Duration
+	SweepInterval time.Duration
+}
+
+func LoadOfferConfig() OfferConfig {
+	return OfferConfig{
+		ResponseTTL:   durationFromEnv("OFFER_RESPONSE_TTL", 48*time.Hour),
+		AcceptedTTL:   durationFromEnv("OFFER_ACCEPTED_TTL", 24*time.Hour),
+		SweepInterval: durationFromEnv("OFFER_SWEEP_INTERVAL", 5*time.Minute),
+	}
+}
@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+type ReservationConfig struct {
+	TTL           time.Duration
+	SweepInterval time.Duration
+}
+
+func LoadReservationConfig() ReservationConfig {
+	return ReservationConfig{
+		TTL:           durationFromEnv("RESERVATION_TTL", 30*time.Minute),
+		SweepInterval: durationFromEnv("RESERVATION_SWEEP_INTERVAL", time.Minute),
+	}
+}
@@ -0,0 +1,13 @@
+package config
+
+type ReviewConfig struct {
+	TransactionServiceURL string
+	ReportHideThreshold   int
+}
+
+func LoadReviewConfig() ReviewConfig {
+	return ReviewConfig{
+		TransactionServiceURL: stringFromEnv("TRANSACTION_SERVICE_URL", "http://transaction-service:8083"),
+		ReportHideThreshold:   intFromEnv("REVIEW_REPORT_HIDE_THRESHOLD", 3),
+	}
+}
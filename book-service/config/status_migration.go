@@ -0,0 +1,24 @@
+package config
+
+import (
+	"book-service/model"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// MigrateBookStatuses marks listings that were already out of stock when
+// the status column was added as sold out.
+func MigrateBookStatuses(db *gorm.DB) error {
+	result := db.Model(&model.Book{}).
+		Where("status = ? AND stock <= 0", model.StatusActive).
+		Update("status", model.StatusSoldOut)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		log.Printf("Marked %d books as sold out", result.RowsAffected)
+	}
+	return nil
+}
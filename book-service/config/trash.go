@@ -0,0 +1,15 @@
+package config
+
+import "time"
+
+type TrashConfig struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+func LoadTrashConfig() TrashConfig {
+	return TrashConfig{
+		Retention:     durationFromEnv("TRASH_RETENTION", 30*24*time.Hour),
+		PurgeInterval: durationFromEnv("TRASH_PURGE_INTERVAL", time.Hour),
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+type WishlistConfig struct {
+	AuthServiceURL  string
+	EmailServiceURL string
+	AlertCooldown   time.Duration
+	AlertDailyLimit int
+}
+
+func LoadWishlistConfig() WishlistConfig {
+	return WishlistConfig{
+		AuthServiceURL:  stringFromEnv("AUTH_SERVICE_URL", "http://auth-service:8080"),
+		EmailServiceURL: stringFromEnv("EMAIL_SERVICE_URL", "http://email-service:8084"),
+		AlertCooldown:   durationFromEnv("WISHLIST_ALERT_COOLDOWN", 24*time.Hour),
+		AlertDailyLimit: intFromEnv("WISHLIST_ALERT_DAILY_LIMIT", 5),
+	}
+}
+
+func stringFromEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,14 @@
+package handler
+
+import (
+	"book-service/policy"
+
+	"github.com/labstack/echo/v4"
+)
+
+// currentActor returns the caller set by the JWT middleware, or the
+// anonymous actor on public endpoints without a token.
+func currentActor(c echo.Context) policy.Actor {
+	actor, _ := c.Get("actor").(policy.Actor)
+	return actor
+}
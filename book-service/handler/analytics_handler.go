@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"book-service/model"
+	"book-service/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// defaultAnalyticsDays is the range shown when no from date is given.
+const defaultAnalyticsDays = 30
+
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+}
+
+func NewAnalyticsHandler(analyticsService service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// GetMyAnalytics returns views, wishlist adds, sales, revenue and
+// conversion for the seller's books. from and to are YYYY-MM-DD dates,
+// both inclusive, defaulting to the last 30 days; period is day (default),
+// week or month.
+func (h *AnalyticsHandler) GetMyAnalytics(c echo.Context) error {
+	sellerIDStr := c.Get("user_id").(string)
+	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid seller ID",
+		})
+	}
+
+	to := time.Now().UTC()
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err = time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid to date, use YYYY-MM-DD",
+			})
+		}
+	}
+
+	from := to.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err = time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid from date, use YYYY-MM-DD",
+			})
+		}
+	}
+
+	period := c.QueryParam("period")
+	if period == "" {
+		period = model.PeriodDay
+	}
+
+	analytics, err := h.analyticsService.GetSellerAnalytics(uint(sellerID), from, to, period)
+	if err != nil {
+		switch err.Error() {
+		case "invalid period":
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Period must be day, week or month",
+			})
+		case "invalid date range":
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "from must not be after to",
+			})
+		case "date range too long":
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Date range must not exceed 366 days",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Analytics retrieved successfully",
+		"data":    analytics,
+	})
+}
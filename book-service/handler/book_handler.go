@@ -2,20 +2,25 @@ package handler
 
 import (
 	"book-service/model"
+	"book-service/policy"
 	"book-service/service"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
 
 type BookHandler struct {
-	bookService service.BookService
+	bookService      service.BookService
+	analyticsService service.AnalyticsService
 }
 
-func NewBookHandler(bookService service.BookService) *BookHandler {
+func NewBookHandler(bookService service.BookService, analyticsService service.AnalyticsService) *BookHandler {
 	return &BookHandler{
-		bookService: bookService,
+		bookService:      bookService,
+		analyticsService: analyticsService,
 	}
 }
 
@@ -33,9 +38,7 @@ func (h *BookHandler) CreateBook(c echo.Context) error {
 		})
 	}
 
-	// Extract role from JWT claims
-	role, ok := c.Get("role").(string)
-	if !ok || role != "seller" {
+	if !policy.CanCreateBooks(currentActor(c)) {
 		return c.JSON(http.StatusForbidden, map[string]string{
 			"error": "Only sellers can create books",
 		})
@@ -51,6 +54,11 @@ func (h *BookHandler) CreateBook(c echo.Context) error {
 
 	book, err := h.bookService.CreateBook(&req, uint(sellerID))
 	if err != nil {
+		if err.Error() == "category not found" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Category not found",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
@@ -63,9 +71,18 @@ func (h *BookHandler) CreateBook(c echo.Context) error {
 }
 
 func (h *BookHandler) GetAllBooks(c echo.Context) error {
+	if c.QueryParam("ids") != "" {
+		return h.GetBooksByIDs(c)
+	}
+
 	category := c.QueryParam("category")
 
-	books, err := h.bookService.GetAllBooks(category)
+	// Sellers also see their own unpublished listings; anonymous visitors
+	// have no user ID and only see active ones.
+	viewerIDStr, _ := c.Get("user_id").(string)
+	viewerID, _ := strconv.ParseUint(viewerIDStr, 10, 32)
+
+	books, err := h.bookService.GetAllBooks(category, uint(viewerID))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
@@ -86,7 +103,12 @@ func (h *BookHandler) GetBookByID(c echo.Context) error {
 		})
 	}
 
-	book, err := h.bookService.GetBookByID(uint(id))
+	// Unpublished listings are only shown to their seller. Anonymous
+	// visitors have no user ID and only see published ones.
+	viewerIDStr, _ := c.Get("user_id").(string)
+	viewerID, _ := strconv.ParseUint(viewerIDStr, 10, 32)
+
+	book, err := h.bookService.GetBookByID(uint(id), uint(viewerID))
 	if err != nil {
 		if err.Error() == "book not found" {
 			return c.JSON(http.StatusNotFound, map[string]string{
@@ -98,6 +120,21 @@ func (h *BookHandler) GetBookByID(c echo.Context) error {
 		})
 	}
 
+	etag := bookETag(book)
+	c.Response().Header().Set("ETag", etag)
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	// Only people reading the book count as views, not internal services
+	// looking it up. Anonymous viewers are told apart by address and user
+	// agent. The view is recorded in the background so it never slows the
+	// page down.
+	if !currentActor(c).IsService() {
+		clientKey := c.RealIP() + "|" + c.Request().UserAgent()
+		go h.analyticsService.RecordView(book.ID, book.SellerID, uint(viewerID), clientKey)
+	}
+
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"message": "Book retrieved successfully",
 		"data":    book,
@@ -113,8 +150,13 @@ func (h *BookHandler) GetMyBooks(c echo.Context) error {
 		})
 	}
 
-	books, err := h.bookService.GetBooksBySellerID(uint(sellerID))
+	books, err := h.bookService.GetBooksBySellerID(uint(sellerID), c.QueryParam("status"))
 	if err != nil {
+		if err.Error() == "invalid status" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid status",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
@@ -147,15 +189,14 @@ func (h *BookHandler) UpdateBook(c echo.Context) error {
 		})
 	}
 
-	sellerIDStr := c.Get("user_id").(string)
-	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid seller ID",
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return c.JSON(http.StatusPreconditionFailed, map[string]string{
+			"error": "Book has been modified",
 		})
 	}
 
-	book, err := h.bookService.UpdateBook(uint(id), &req, uint(sellerID))
+	book, err := h.bookService.UpdateBook(uint(id), &req, currentActor(c), version)
 	if err != nil {
 		if err.Error() == "book not found" {
 			return c.JSON(http.StatusNotFound, map[string]string{
@@ -167,11 +208,27 @@ func (h *BookHandler) UpdateBook(c echo.Context) error {
 				"error": "Forbidden: You can only update your own books",
 			})
 		}
+		if err.Error() == "book has been modified" {
+			return c.JSON(http.StatusPreconditionFailed, map[string]string{
+				"error": "Book has been modified",
+			})
+		}
+		if err.Error() == "category not found" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Category not found",
+			})
+		}
+		if err.Error() == "invalid status transition" {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Invalid status transition",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
 	}
 
+	c.Response().Header().Set("ETag", bookETag(book))
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"message": "Book updated successfully",
 		"data":    book,
@@ -186,15 +243,14 @@ func (h *BookHandler) DeleteBook(c echo.Context) error {
 		})
 	}
 
-	sellerIDStr := c.Get("user_id").(string)
-	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
-	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid seller ID",
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return c.JSON(http.StatusPreconditionFailed, map[string]string{
+			"error": "Book has been modified",
 		})
 	}
 
-	err = h.bookService.DeleteBook(uint(id), uint(sellerID))
+	err = h.bookService.DeleteBook(uint(id), currentActor(c), version)
 	if err != nil {
 		if err.Error() == "book not found" {
 			return c.JSON(http.StatusNotFound, map[string]string{
@@ -206,6 +262,11 @@ func (h *BookHandler) DeleteBook(c echo.Context) error {
 				"error": "Forbidden: You can only delete your own books",
 			})
 		}
+		if err.Error() == "book has been modified" {
+			return c.JSON(http.StatusPreconditionFailed, map[string]string{
+				"error": "Book has been modified",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": err.Error(),
 		})
@@ -217,6 +278,13 @@ func (h *BookHandler) DeleteBook(c echo.Context) error {
 }
 
 func (h *BookHandler) DeductStock(c echo.Context) error {
+	actor := currentActor(c)
+	if !policy.CanDeductStock(actor) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only internal services can deduct stock",
+		})
+	}
+
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
@@ -231,7 +299,7 @@ func (h *BookHandler) DeductStock(c echo.Context) error {
 		})
 	}
 
-	book, err := h.bookService.DeductStock(uint(id), amount)
+	book, err := h.bookService.DeductStock(uint(id), amount, actor.UserID)
 	if err != nil {
 		if err.Error() == "book not found" {
 			return c.JSON(http.StatusNotFound, map[string]string{
@@ -258,3 +326,67 @@ func (h *BookHandler) DeductStock(c echo.Context) error {
 		"data":    book,
 	})
 }
+
+func (h *BookHandler) GetBookHistory(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid book ID",
+		})
+	}
+
+	history, err := h.bookService.GetBookHistory(uint(id), currentActor(c))
+	if err != nil {
+		if err.Error() == "book not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Book not found",
+			})
+		}
+		if err.Error() == "unauthorized: you can only view the history of your own books" {
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": "Forbidden: You can only view the history of your own books",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Book history retrieved successfully",
+		"data":    history,
+	})
+}
+
+// GetBooksByIDs looks up the comma-separated books in the ids query
+// parameter in one call, reporting the IDs it could not find. It serves
+// both GET /books?ids= and the internal GET /internal/books.
+func (h *BookHandler) GetBooksByIDs(c echo.Context) error {
+	var ids []uint
+	for _, part := range strings.Split(c.QueryParam("ids"), ",") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32)
+		if err != nil || id == 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid book ID list",
+			})
+		}
+		ids = append(ids, uint(id))
+	}
+
+	batch, err := h.bookService.GetBooksByIDs(ids, currentActor(c))
+	if err != nil {
+		if err.Error() == "too many book IDs" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("At most %d book IDs can be looked up at once", model.MaxBookBatchSize),
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Books retrieved successfully",
+		"data":    batch,
+	})
+}
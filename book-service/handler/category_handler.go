@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"book-service/model"
+	"book-service/policy"
+	"book-service/service"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CategoryHandler struct {
+	categoryService service.CategoryService
+}
+
+func NewCategoryHandler(categoryService service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		categoryService: categoryService,
+	}
+}
+
+func (h *CategoryHandler) GetCategoryTree(c echo.Context) error {
+	tree, err := h.categoryService.GetTree()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Categories retrieved successfully",
+		"data":    tree,
+	})
+}
+
+func (h *CategoryHandler) CreateCategory(c echo.Context) error {
+	if !policy.CanManageCategories(currentActor(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only admins can manage categories",
+		})
+	}
+
+	var req model.CreateCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	category, err := h.categoryService.CreateCategory(&req)
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Category created successfully",
+		"data":    category,
+	})
+}
+
+func (h *CategoryHandler) UpdateCategory(c echo.Context) error {
+	if !policy.CanManageCategories(currentActor(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only admins can manage categories",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid category ID",
+		})
+	}
+
+	var req model.UpdateCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	category, err := h.categoryService.UpdateCategory(uint(id), &req)
+	if err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Category updated successfully",
+		"data":    category,
+	})
+}
+
+func (h *CategoryHandler) DeleteCategory(c echo.Context) error {
+	if !policy.CanManageCategories(currentActor(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only admins can manage categories",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid category ID",
+		})
+	}
+
+	if err := h.categoryService.DeleteCategory(uint(id)); err != nil {
+		return categoryError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Category deleted successfully",
+	})
+}
+
+func categoryError(c echo.Context, err error) error {
+	switch err.Error() {
+	case "category not found":
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Category not found",
+		})
+	case "category slug already exists", "category is in use":
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	case "parent category not found", "category cannot be moved under itself", "invalid category slug":
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": err.Error(),
+	})
+}
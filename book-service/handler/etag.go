@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"book-service/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// bookETag tags a book as it is served: its version, which If-Match is
+// checked against, followed by a hash of the whole response, so that
+// figures that change without a new version, like its available stock and
+// ratings, change the tag too.
+func bookETag(book *model.BookResponse) string {
+	body, err := json.Marshal(book)
+	if err != nil {
+		return fmt.Sprintf(`"%d"`, book.Version)
+	}
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`"%d-%s"`, book.Version, hex.EncodeToString(sum[:8]))
+}
+
+// ifMatchVersion reads the book version from the If-Match header. It
+// returns nil when the header is absent or "*", and ok is false when the
+// header does not hold a book ETag, which can never match. Only the
+// version is compared, as only a new version means the book was edited.
+func ifMatchVersion(c echo.Context) (version *uint, ok bool) {
+	header := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	tag, _, _ = strings.Cut(tag, "-")
+	parsed, err := strconv.ParseUint(tag, 10, 32)
+	if err != nil {
+		return nil, false
+	}
+
+	v := uint(parsed)
+	return &v, true
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"book-service/model"
+	"book-service/policy"
+	"book-service/service"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// EventHandler receives the events other services deliver from their
+// outbox. It sits behind the service token middleware and is not exposed
+// through the gateway.
+type EventHandler struct {
+	eventService service.EventService
+}
+
+func NewEventHandler(eventService service.EventService) *EventHandler {
+	return &EventHandler{
+		eventService: eventService,
+	}
+}
+
+// HandleEvent acknowledges an event with a 200 once it has been handled,
+// including when it already had been. Any other answer has the sender
+// deliver it again later.
+func (h *EventHandler) HandleEvent(c echo.Context) error {
+	if !policy.CanDeliverEvents(currentActor(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only internal services can deliver events",
+		})
+	}
+
+	var event model.IncomingEvent
+	if err := c.Bind(&event); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if event.ID == "" {
+		event.ID = c.Request().Header.Get("Idempotency-Key")
+	}
+
+	if err := c.Validate(&event); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	caller, _ := c.Get("service").(string)
+	replayed, err := h.eventService.Handle(&event, caller)
+	if err != nil {
+		if err.Error() == "invalid event data" {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid event data",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message":  "Event processed successfully",
+		"replayed": replayed,
+	})
+}
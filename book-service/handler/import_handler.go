@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"book-service/helpers"
+	"book-service/policy"
+	"book-service/service"
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxImportFileSize caps the size of an uploaded import file.
+const maxImportFileSize = 5 << 20
+
+type ImportHandler struct {
+	importService service.ImportService
+}
+
+func NewImportHandler(importService service.ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+func (h *ImportHandler) ImportBooks(c echo.Context) error {
+	if !policy.CanCreateBooks(currentActor(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only sellers can import books",
+		})
+	}
+
+	sellerIDStr := c.Get("user_id").(string)
+	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid seller ID",
+		})
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "File is required",
+		})
+	}
+	if fileHeader.Size > maxImportFileSize {
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": "File is too large",
+		})
+	}
+
+	format, err := helpers.SheetFormat(c.QueryParam("format"), fileHeader.Filename)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Unsupported file format, use csv or xlsx",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Could not read file",
+		})
+	}
+	defer file.Close()
+
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+
+	result, err := h.importService.Import(uint(sellerID), file, format, dryRun)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	switch {
+	case result.DryRun:
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"message": "Import file validated",
+			"data":    result,
+		})
+	case len(result.Errors) > 0:
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"error": "Import file has invalid rows",
+			"data":  result,
+		})
+	case result.Job != nil:
+		return c.JSON(http.StatusAccepted, map[string]interface{}{
+			"message": "Import started",
+			"data":    result,
+		})
+	default:
+		return c.JSON(http.StatusCreated, map[string]interface{}{
+			"message": "Books imported successfully",
+			"data":    result,
+		})
+	}
+}
+
+func (h *ImportHandler) GetImportJob(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("job_id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid import job ID",
+		})
+	}
+
+	sellerIDStr := c.Get("user_id").(string)
+	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid seller ID",
+		})
+	}
+
+	job, err := h.importService.GetJob(uint(id), uint(sellerID))
+	if err != nil {
+		if err.Error() == "import job not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Import job not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Import job retrieved successfully",
+		"data":    job,
+	})
+}
+
+func (h *ImportHandler) ExportMyBooks(c echo.Context) error {
+	sellerIDStr := c.Get("user_id").(string)
+	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid seller ID",
+		})
+	}
+
+	format, err := helpers.SheetFormat(c.QueryParam("format"), "books.csv")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Unsupported file format, use csv or xlsx",
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := h.importService.Export(uint(sellerID), &buf, format); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	contentType := "text/csv"
+	if format == helpers.FormatXLSX {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="my-books.%s"`, format))
+	return c.Blob(http.StatusOK, contentType, buf.Bytes())
+}
@@ -0,0 +1,307 @@
+package handler
+
+import (
+	"book-service/model"
+	"book-service/policy"
+	"book-service/service"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OfferHandler struct {
+	offerService service.OfferService
+}
+
+func NewOfferHandler(offerService service.OfferService) *OfferHandler {
+	return &OfferHandler{
+		offerService: offerService,
+	}
+}
+
+func (h *OfferHandler) MakeOffer(c echo.Context) error {
+	bookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid book ID",
+		})
+	}
+
+	var req model.CreateOfferRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	buyerIDStr := c.Get("user_id").(string)
+	buyerID, err := strconv.ParseUint(buyerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	offer, err := h.offerService.MakeOffer(uint(bookID), uint(buyerID), &req)
+	if err != nil {
+		return offerError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Offer made successfully",
+		"data":    offer,
+	})
+}
+
+func (h *OfferHandler) GetAcceptedOffer(c echo.Context) error {
+	bookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid book ID",
+		})
+	}
+
+	buyerIDStr := c.Get("user_id").(string)
+	buyerID, err := strconv.ParseUint(buyerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	offer, err := h.offerService.GetAcceptedOffer(uint(bookID), uint(buyerID))
+	if err != nil {
+		return offerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Accepted offer retrieved successfully",
+		"data":    offer,
+	})
+}
+
+// GetOffers lists the caller's offers as a buyer, or with ?role=seller
+// the offers made on its books.
+func (h *OfferHandler) GetOffers(c echo.Context) error {
+	var received bool
+	switch c.QueryParam("role") {
+	case "", policy.RoleBuyer:
+	case policy.RoleSeller:
+		received = true
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "invalid role",
+		})
+	}
+
+	offers, err := h.offerService.GetOffers(currentActor(c), received)
+	if err != nil {
+		return offerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Offers retrieved successfully",
+		"data":    offers,
+	})
+}
+
+func (h *OfferHandler) GetOffer(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid offer ID",
+		})
+	}
+
+	userIDStr := c.Get("user_id").(string)
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	offer, err := h.offerService.GetOffer(uint(id), uint(userID))
+	if err != nil {
+		return offerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Offer retrieved successfully",
+		"data":    offer,
+	})
+}
+
+func (h *OfferHandler) CounterOffer(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid offer ID",
+		})
+	}
+
+	var req model.CounterOfferRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	userIDStr := c.Get("user_id").(string)
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	offer, err := h.offerService.CounterOffer(uint(id), uint(userID), &req)
+	if err != nil {
+		return offerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Counter-offer made successfully",
+		"data":    offer,
+	})
+}
+
+func (h *OfferHandler) AcceptOffer(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid offer ID",
+		})
+	}
+
+	userIDStr := c.Get("user_id").(string)
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	offer, err := h.offerService.AcceptOffer(uint(id), uint(userID))
+	if err != nil {
+		return offerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Offer accepted successfully",
+		"data":    offer,
+	})
+}
+
+func (h *OfferHandler) RejectOffer(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid offer ID",
+		})
+	}
+
+	userIDStr := c.Get("user_id").(string)
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	offer, err := h.offerService.RejectOffer(uint(id), uint(userID))
+	if err != nil {
+		return offerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Offer rejected successfully",
+		"data":    offer,
+	})
+}
+
+func (h *OfferHandler) RedeemOffer(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid offer ID",
+		})
+	}
+
+	var req model.RedeemOfferRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	buyerIDStr := c.Get("user_id").(string)
+	buyerID, err := strconv.ParseUint(buyerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	offer, err := h.offerService.RedeemOffer(uint(id), uint(buyerID), &req)
+	if err != nil {
+		return offerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Offer redeemed successfully",
+		"data":    offer,
+	})
+}
+
+func offerError(c echo.Context, err error) error {
+	switch err.Error() {
+	case "book not found":
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Book not found",
+		})
+	case "offer not found":
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Offer not found",
+		})
+	case "offer price must be below the listing price", "cannot make an offer on your own book":
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	case "unauthorized: only sellers receive offers":
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Forbidden: Only sellers receive offers",
+		})
+	case "offer is awaiting the other party":
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Forbidden: The offer is awaiting the other party",
+		})
+	case "book is not available", "insufficient stock", "offer already open", "offer is closed",
+		"offer is not accepted", "offer has expired", "offer has changed":
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": err.Error(),
+	})
+}
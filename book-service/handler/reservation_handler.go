@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"book-service/model"
+	"book-service/service"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ReservationHandler struct {
+	reservationService service.ReservationService
+}
+
+func NewReservationHandler(reservationService service.ReservationService) *ReservationHandler {
+	return &ReservationHandler{
+		reservationService: reservationService,
+	}
+}
+
+func (h *ReservationHandler) CreateReservation(c echo.Context) error {
+	bookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid book ID",
+		})
+	}
+
+	var req model.CreateReservationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	reservation, err := h.reservationService.Reserve(uint(bookID), &req)
+	if err != nil {
+		if err.Error() == "book not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Book not found",
+			})
+		}
+		if err.Error() == "insufficient stock" {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Insufficient stock",
+			})
+		}
+		if err.Error() == "book is not available" {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Book is not available",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Stock reserved successfully",
+		"data":    reservation,
+	})
+}
+
+func (h *ReservationHandler) ConfirmReservation(c echo.Context) error {
+	transactionID, err := strconv.ParseUint(c.Param("transaction_id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	reservations, err := h.reservationService.Confirm(uint(transactionID))
+	if err != nil {
+		if err.Error() == "reservation not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Reservation not found",
+			})
+		}
+		if err.Error() == "insufficient stock" {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Insufficient stock",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Reservation confirmed successfully",
+		"data":    reservations,
+	})
+}
+
+func (h *ReservationHandler) ReleaseReservation(c echo.Context) error {
+	transactionID, err := strconv.ParseUint(c.Param("transaction_id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid transaction ID",
+		})
+	}
+
+	err = h.reservationService.Release(uint(transactionID))
+	if err != nil {
+		if err.Error() == "reservation not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Reservation not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Reservation released successfully",
+	})
+}
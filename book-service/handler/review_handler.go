@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"book-service/model"
+	"book-service/policy"
+	"book-service/service"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ReviewHandler struct {
+	reviewService service.ReviewService
+}
+
+func NewReviewHandler(reviewService service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+	}
+}
+
+func (h *ReviewHandler) CreateReview(c echo.Context) error {
+	var req model.CreateReviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	buyerIDStr := c.Get("user_id").(string)
+	buyerID, err := strconv.ParseUint(buyerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	review, err := h.reviewService.CreateReview(uint(buyerID), &req, c.Request().Header.Get("Authorization"))
+	if err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Review created successfully",
+		"data":    review,
+	})
+}
+
+func (h *ReviewHandler) ReplyToReview(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid review ID",
+		})
+	}
+
+	var req model.ReplyReviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	sellerIDStr := c.Get("user_id").(string)
+	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid seller ID",
+		})
+	}
+
+	review, err := h.reviewService.ReplyToReview(uint(id), uint(sellerID), &req)
+	if err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Reply saved successfully",
+		"data":    review,
+	})
+}
+
+func (h *ReviewHandler) ReportReview(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid review ID",
+		})
+	}
+
+	var req model.ReportReviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	reporterIDStr := c.Get("user_id").(string)
+	reporterID, err := strconv.ParseUint(reporterIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if err := h.reviewService.ReportReview(uint(id), uint(reporterID), &req); err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Review reported successfully",
+	})
+}
+
+func (h *ReviewHandler) GetReportedReviews(c echo.Context) error {
+	if !policy.CanModerateReviews(currentActor(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only admins can moderate reviews",
+		})
+	}
+
+	reviews, err := h.reviewService.GetReportedReviews()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Reported reviews retrieved successfully",
+		"data":    reviews,
+	})
+}
+
+func (h *ReviewHandler) ModerateReview(c echo.Context) error {
+	if !policy.CanModerateReviews(currentActor(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only admins can moderate reviews",
+		})
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid review ID",
+		})
+	}
+
+	var req model.ModerateReviewRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	review, err := h.reviewService.ModerateReview(uint(id), &req)
+	if err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Review updated successfully",
+		"data":    review,
+	})
+}
+
+func (h *ReviewHandler) GetBookReviews(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid book ID",
+		})
+	}
+
+	reviews, err := h.reviewService.GetBookReviews(uint(id))
+	if err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Reviews retrieved successfully",
+		"data":    reviews,
+	})
+}
+
+func (h *ReviewHandler) GetSellerProfile(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid seller ID",
+		})
+	}
+
+	profile, err := h.reviewService.GetSellerProfile(uint(id))
+	if err != nil {
+		return reviewError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Seller profile retrieved successfully",
+		"data":    profile,
+	})
+}
+
+func reviewError(c echo.Context, err error) error {
+	switch err.Error() {
+	case "transaction not found":
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Transaction not found",
+		})
+	case "book not found":
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Book not found",
+		})
+	case "review not found":
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Review not found",
+		})
+	case "seller not found":
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Seller not found",
+		})
+	case "book is not part of the transaction":
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Book is not part of the transaction",
+		})
+	case "transaction is not completed":
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only completed purchases can be reviewed",
+		})
+	case "unauthorized: you can only reply to reviews of your own sales":
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Forbidden: You can only reply to reviews of your own sales",
+		})
+	case "transaction already reviewed", "review already reported":
+		return c.JSON(http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": err.Error(),
+	})
+}
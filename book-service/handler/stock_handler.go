@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"book-service/model"
+	"book-service/policy"
+	"book-service/repository"
+	"book-service/service"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// StockHandler serves the internal batch stock endpoints. They sit behind
+// the service token middleware and are not exposed through the gateway.
+type StockHandler struct {
+	stockService service.StockService
+}
+
+func NewStockHandler(stockService service.StockService) *StockHandler {
+	return &StockHandler{
+		stockService: stockService,
+	}
+}
+
+func (h *StockHandler) DeductStock(c echo.Context) error {
+	return h.apply(c, h.stockService.Deduct, "Stock deducted successfully")
+}
+
+func (h *StockHandler) RestockStock(c echo.Context) error {
+	return h.apply(c, h.stockService.Restock, "Stock restocked successfully")
+}
+
+func (h *StockHandler) apply(c echo.Context, operation func(*model.StockOperationRequest, string) (*model.StockOperation, error), message string) error {
+	if !policy.CanDeductStock(currentActor(c)) {
+		return c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only internal services can change stock",
+		})
+	}
+
+	var req model.StockOperationRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = c.Request().Header.Get("Idempotency-Key")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	caller, _ := c.Get("service").(string)
+	op, err := operation(&req, caller)
+	if err != nil {
+		return stockError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": message,
+		"data":    op,
+	})
+}
+
+func stockError(c echo.Context, err error) error {
+	var itemErr *repository.StockItemError
+	if errors.As(err, &itemErr) {
+		if errors.Is(itemErr.Err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]interface{}{
+				"error":   "Book not found",
+				"book_id": itemErr.BookID,
+			})
+		}
+		if errors.Is(itemErr.Err, repository.ErrInsufficientStock) {
+			return c.JSON(http.StatusConflict, map[string]interface{}{
+				"error":   "Insufficient stock",
+				"book_id": itemErr.BookID,
+			})
+		}
+	}
+
+	if err.Error() == "idempotency key already used for a different request" {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "Idempotency key already used for a different request",
+		})
+	}
+
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"book-service/service"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TrashHandler struct {
+	trashService service.TrashService
+}
+
+func NewTrashHandler(trashService service.TrashService) *TrashHandler {
+	return &TrashHandler{
+		trashService: trashService,
+	}
+}
+
+func (h *TrashHandler) GetTrash(c echo.Context) error {
+	sellerIDStr := c.Get("user_id").(string)
+	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid seller ID",
+		})
+	}
+
+	books, err := h.trashService.GetTrash(uint(sellerID))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Deleted books retrieved successfully",
+		"data":    books,
+	})
+}
+
+func (h *TrashHandler) RestoreBook(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid book ID",
+		})
+	}
+
+	sellerIDStr := c.Get("user_id").(string)
+	sellerID, err := strconv.ParseUint(sellerIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid seller ID",
+		})
+	}
+
+	book, err := h.trashService.RestoreBook(uint(id), uint(sellerID))
+	if err != nil {
+		if err.Error() == "book not found in trash" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Book not found in trash",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	c.Response().Header().Set("ETag", bookETag(book))
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Book restored successfully",
+		"data":    book,
+	})
+}
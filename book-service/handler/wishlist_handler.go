@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"book-service/model"
+	"book-service/service"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WishlistHandler struct {
+	wishlistService  service.WishlistService
+	analyticsService service.AnalyticsService
+}
+
+func NewWishlistHandler(wishlistService service.WishlistService, analyticsService service.AnalyticsService) *WishlistHandler {
+	return &WishlistHandler{
+		wishlistService:  wishlistService,
+		analyticsService: analyticsService,
+	}
+}
+
+func (h *WishlistHandler) AddToWishlist(c echo.Context) error {
+	var req model.AddWishlistRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	userIDStr := c.Get("user_id").(string)
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	item, err := h.wishlistService.AddToWishlist(uint(userID), req.BookID)
+	if err != nil {
+		if err.Error() == "book not found" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Book not found",
+			})
+		}
+		if err.Error() == "book already in wishlist" {
+			return c.JSON(http.StatusConflict, map[string]string{
+				"error": "Book already in wishlist",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	h.analyticsService.RecordWishlistAdd(item.BookID)
+
+	return c.JSON(http.StatusCreated, map[string]interface{}{
+		"message": "Book added to wishlist",
+		"data":    item,
+	})
+}
+
+func (h *WishlistHandler) RemoveFromWishlist(c echo.Context) error {
+	bookID, err := strconv.ParseUint(c.Param("book_id"), 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid book ID",
+		})
+	}
+
+	userIDStr := c.Get("user_id").(string)
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	err = h.wishlistService.RemoveFromWishlist(uint(userID), uint(bookID))
+	if err != nil {
+		if err.Error() == "book not in wishlist" {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Book not in wishlist",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Book removed from wishlist",
+	})
+}
+
+func (h *WishlistHandler) GetWishlist(c echo.Context) error {
+	userIDStr := c.Get("user_id").(string)
+	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID",
+		})
+	}
+
+	items, err := h.wishlistService.GetWishlist(uint(userID))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "Wishlist retrieved successfully",
+		"data":    items,
+	})
+}
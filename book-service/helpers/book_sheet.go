@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+
+	bookSheetName = "Books"
+)
+
+// BookSheetHeader is the column layout shared by imports and exports.
+var BookSheetHeader = []string{"name", "description", "author", "stock", "costs", "category"}
+
+// SheetFormat works out the file format from an explicit format value or,
+// failing that, the file name's extension.
+func SheetFormat(format string, filename string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	default:
+		return "", errors.New("unsupported file format")
+	}
+}
+
+// ReadSheet returns every row of a CSV file or of the first worksheet of an
+// XLSX file, header included.
+func ReadSheet(r io.Reader, format string) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case FormatXLSX:
+		file, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		sheets := file.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("workbook has no sheets")
+		}
+		return file.GetRows(sheets[0])
+	default:
+		return nil, errors.New("unsupported file format")
+	}
+}
+
+// WriteSheet writes rows as CSV or as a single-sheet XLSX workbook.
+func WriteSheet(w io.Writer, format string, rows [][]string) error {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+		return writer.Error()
+	case FormatXLSX:
+		file := excelize.NewFile()
+		defer file.Close()
+
+		if err := file.SetSheetName("Sheet1", bookSheetName); err != nil {
+			return err
+		}
+		for i, row := range rows {
+			cell, err := excelize.CoordinatesToCellName(1, i+1)
+			if err != nil {
+				return err
+			}
+			values := make([]interface{}, len(row))
+			for j, value := range row {
+				values[j] = value
+			}
+			if err := file.SetSheetRow(bookSheetName, cell, &values); err != nil {
+				return err
+			}
+		}
+		return file.Write(w)
+	default:
+		return errors.New("unsupported file format")
+	}
+}
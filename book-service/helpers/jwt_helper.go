@@ -11,8 +11,19 @@ import (
 
 var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 
-func ParseToken(tokenStr string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+// Claims are the claims auth-service puts in user tokens. Internal callers
+// use service tokens instead, see ServiceClaims.
+type Claims struct {
+	UserID uint   `json:"user_id"`
+	Role   string `json:"role"`
+	Email  string `json:"email"`
+	Name   string `json:"full_name"`
+	jwt.RegisteredClaims
+}
+
+func ParseToken(tokenStr string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
 		}
@@ -23,30 +34,15 @@ func ParseToken(tokenStr string) (jwt.MapClaims, error) {
 		return nil, errors.New("invalid token")
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, errors.New("could not parse claims")
-	}
-
 	return claims, nil
 }
 
-func ExtractToken(c echo.Context) (uint, error) {
+func ExtractToken(c echo.Context) (*Claims, error) {
 	authHeader := c.Request().Header.Get("Authorization")
 	if authHeader == "" {
-		return 0, errors.New("missing Authorization header")
+		return nil, errors.New("missing Authorization header")
 	}
 
 	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
-	claims, err := ParseToken(tokenStr)
-	if err != nil {
-		return 0, err
-	}
-
-	userIDFloat, ok := claims["user_id"].(float64)
-	if !ok {
-		return 0, errors.New("invalid user_id in token")
-	}
-
-	return uint(userIDFloat), nil
+	return ParseToken(tokenStr)
 }
@@ -0,0 +1,26 @@
+package helpers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify lowercases s and joins its runs of letters and digits with
+// hyphens, so "Fiction Novel" and " fiction-novel " give the same slug.
+func Slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteRune('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"book-service/service"
+	"log"
+	"time"
+)
+
+// StartAnalyticsSync copies recent sales from transaction-service into the
+// analytics rollups and drops old per-viewer view records every interval.
+func StartAnalyticsSync(analyticsService service.AnalyticsService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := analyticsService.SyncSales(); err != nil {
+				log.Printf("Analytics sync failed: %v", err)
+			}
+
+			purged, err := analyticsService.PurgeViews()
+			if err != nil {
+				log.Printf("Analytics view purge failed: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Analytics view purge removed %d records", purged)
+			}
+		}
+	}()
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"book-service/service"
+	"log"
+	"time"
+)
+
+// StartOfferSweeper marks offers whose deadline has passed as expired
+// every interval.
+func StartOfferSweeper(offerService service.OfferService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			expired, err := offerService.ExpireOverdue()
+			if err != nil {
+				log.Printf("Offer sweeper failed: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Offer sweeper expired %d offers", expired)
+			}
+		}
+	}()
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"book-service/service"
+	"log"
+	"time"
+)
+
+// StartReservationSweeper marks overdue reservations as expired every
+// interval so their stock becomes available again.
+func StartReservationSweeper(reservationService service.ReservationService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			expired, err := reservationService.ExpireOverdue()
+			if err != nil {
+				log.Printf("Reservation sweeper failed: %v", err)
+				continue
+			}
+			if expired > 0 {
+				log.Printf("Reservation sweeper expired %d reservations", expired)
+			}
+		}
+	}()
+}
@@ -0,0 +1,27 @@
+package jobs
+
+import (
+	"book-service/service"
+	"log"
+	"time"
+)
+
+// StartTrashPurger permanently removes books whose restore window has
+// passed every interval.
+func StartTrashPurger(trashService service.TrashService, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			purged, err := trashService.PurgeExpired()
+			if err != nil {
+				log.Printf("Trash purger failed: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("Trash purger removed %d books", purged)
+			}
+		}
+	}()
+}
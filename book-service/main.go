@@ -1,9 +1,12 @@
 package main
 
 import (
+	"book-service/clients"
 	"book-service/config"
 	"book-service/handler"
+	"book-service/jobs"
 	jwtMiddleware "book-service/middleware"
+	"book-service/notifier"
 	"book-service/repository"
 	"book-service/service"
 	"log"
@@ -27,22 +30,154 @@ func main() {
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
-	e.Use(jwtMiddleware.JwtMiddleware())
 
 	db := config.InitDB()
+	bookCache, cacheConfig := config.InitCache()
 
-	bookRepo := repository.NewBookRepository(db)
-	bookService := service.NewBookService(bookRepo)
-	bookHandler := handler.NewBookHandler(bookService)
+	bookRepo := repository.NewCachedBookRepository(
+		repository.NewBookRepository(db),
+		bookCache,
+		cacheConfig.BookTTL,
+		cacheConfig.ListTTL,
+	)
+	reservationRepo := repository.NewReservationRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	reviewRepo := repository.NewReviewRepository(db)
+	historyRepo := repository.NewBookHistoryRepository(db)
+	reservationConfig := config.LoadReservationConfig()
+
+	reviewConfig := config.LoadReviewConfig()
+	transactionClient := clients.NewTransactionClient(reviewConfig.TransactionServiceURL)
+	analyticsConfig := config.LoadAnalyticsConfig()
+	analyticsService := service.NewAnalyticsService(
+		repository.NewAnalyticsRepository(db),
+		transactionClient,
+		analyticsConfig.SalesLookback,
+	)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+
+	wishlistConfig := config.LoadWishlistConfig()
+	wishlistService := service.NewWishlistService(
+		repository.NewWishlistRepository(db),
+		bookRepo,
+		notifier.NewEmailNotifier(wishlistConfig.AuthServiceURL, wishlistConfig.EmailServiceURL),
+		wishlistConfig.AlertCooldown,
+		wishlistConfig.AlertDailyLimit,
+	)
+	wishlistHandler := handler.NewWishlistHandler(wishlistService, analyticsService)
+
+	bookService := service.NewBookService(bookRepo, reservationRepo, categoryRepo, reviewRepo, historyRepo, wishlistService)
+	bookHandler := handler.NewBookHandler(bookService, analyticsService)
+	trashConfig := config.LoadTrashConfig()
+	trashService := service.NewTrashService(bookRepo, historyRepo, trashConfig.Retention)
+	trashHandler := handler.NewTrashHandler(trashService)
+	reviewService := service.NewReviewService(
+		reviewRepo,
+		bookRepo,
+		transactionClient,
+		reviewConfig.ReportHideThreshold,
+	)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+	categoryService := service.NewCategoryService(categoryRepo, bookRepo)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	reservationService := service.NewReservationService(reservationRepo, bookRepo, historyRepo, wishlistService, reservationConfig.TTL)
+	reservationHandler := handler.NewReservationHandler(reservationService)
+
+	offerConfig := config.LoadOfferConfig()
+	offerService := service.NewOfferService(
+		repository.NewOfferRepository(db),
+		bookRepo,
+		reservationRepo,
+		offerConfig.ResponseTTL,
+		offerConfig.AcceptedTTL,
+	)
+	offerHandler := handler.NewOfferHandler(offerService)
+
+	stockService := service.NewStockService(repository.NewStockRepository(db), bookRepo, historyRepo, wishlistService)
+	stockHandler := handler.NewStockHandler(stockService)
+	eventService := service.NewEventService(repository.NewEventRepository(db), reservationRepo)
+	eventHandler := handler.NewEventHandler(eventService)
+
+	importConfig := config.LoadImportConfig()
+	importService := service.NewImportService(
+		bookRepo,
+		repository.NewImportJobRepository(db),
+		categoryRepo,
+		e.Validator,
+		importConfig.AsyncThreshold,
+		importConfig.ChunkSize,
+	)
+	importHandler := handler.NewImportHandler(importService)
+
+	jobs.StartReservationSweeper(reservationService, reservationConfig.SweepInterval)
+	jobs.StartTrashPurger(trashService, trashConfig.PurgeInterval)
+	jobs.StartOfferSweeper(offerService, offerConfig.SweepInterval)
+	jobs.StartAnalyticsSync(analyticsService, analyticsConfig.SyncInterval)
+
+	// Catalogue reads are public; a token, when sent, identifies the caller.
+	// Stock changes are only open to other services holding a service token.
+	// Everything else requires a user token.
+	auth := jwtMiddleware.JwtMiddleware()
+	optionalAuth := jwtMiddleware.OptionalJwtMiddleware()
+	serviceAuth := jwtMiddleware.ServiceAuthMiddleware()
 
 	books := e.Group("/books")
-	books.POST("", bookHandler.CreateBook)
-	books.GET("", bookHandler.GetAllBooks)
-	books.GET("/my", bookHandler.GetMyBooks)
-	books.GET("/:id", bookHandler.GetBookByID)
-	books.PUT("/:id", bookHandler.UpdateBook)
-	books.DELETE("/:id", bookHandler.DeleteBook)
-	books.PATCH("/:id/:amount", bookHandler.DeductStock)
+	books.POST("", bookHandler.CreateBook, auth)
+	books.GET("", bookHandler.GetAllBooks, optionalAuth)
+	books.GET("/my", bookHandler.GetMyBooks, auth)
+	books.GET("/my/export", importHandler.ExportMyBooks, auth)
+	books.GET("/my/analytics", analyticsHandler.GetMyAnalytics, auth)
+	books.GET("/trash", trashHandler.GetTrash, auth)
+	books.POST("/import", importHandler.ImportBooks, auth)
+	books.GET("/import/:job_id", importHandler.GetImportJob, auth)
+	books.GET("/:id", bookHandler.GetBookByID, optionalAuth)
+	books.PUT("/:id", bookHandler.UpdateBook, auth)
+	books.DELETE("/:id", bookHandler.DeleteBook, auth)
+	books.POST("/:id/restore", trashHandler.RestoreBook, auth)
+	books.GET("/:id/history", bookHandler.GetBookHistory, auth)
+	books.PATCH("/:id/:amount", bookHandler.DeductStock, serviceAuth)
+	books.POST("/:id/reservations", reservationHandler.CreateReservation, serviceAuth)
+	books.GET("/:id/reviews", reviewHandler.GetBookReviews, optionalAuth)
+	books.POST("/:id/offers", offerHandler.MakeOffer, auth)
+	books.GET("/:id/offers/accepted", offerHandler.GetAcceptedOffer, auth)
+
+	reviews := e.Group("/reviews", auth)
+	reviews.POST("", reviewHandler.CreateReview)
+	reviews.GET("/reported", reviewHandler.GetReportedReviews)
+	reviews.POST("/:id/reply", reviewHandler.ReplyToReview)
+	reviews.POST("/:id/report", reviewHandler.ReportReview)
+	reviews.PUT("/:id/moderation", reviewHandler.ModerateReview)
+
+	e.GET("/sellers/:id", reviewHandler.GetSellerProfile, optionalAuth)
+
+	offers := e.Group("/offers", auth)
+	offers.GET("", offerHandler.GetOffers)
+	offers.GET("/:id", offerHandler.GetOffer)
+	offers.POST("/:id/counter", offerHandler.CounterOffer)
+	offers.POST("/:id/accept", offerHandler.AcceptOffer)
+	offers.POST("/:id/reject", offerHandler.RejectOffer)
+	offers.POST("/:id/redeem", offerHandler.RedeemOffer)
+
+	categories := e.Group("/categories")
+	categories.GET("", categoryHandler.GetCategoryTree, optionalAuth)
+	categories.POST("", categoryHandler.CreateCategory, auth)
+	categories.PUT("/:id", categoryHandler.UpdateCategory, auth)
+	categories.DELETE("/:id", categoryHandler.DeleteCategory, auth)
+
+	wishlist := e.Group("/wishlist", auth)
+	wishlist.GET("", wishlistHandler.GetWishlist)
+	wishlist.POST("", wishlistHandler.AddToWishlist)
+	wishlist.DELETE("/:book_id", wishlistHandler.RemoveFromWishlist)
+
+	reservations := e.Group("/reservations", serviceAuth)
+	reservations.POST("/:transaction_id/confirm", reservationHandler.ConfirmReservation)
+	reservations.POST("/:transaction_id/release", reservationHandler.ReleaseReservation)
+
+	internal := e.Group("/internal", serviceAuth)
+	internal.GET("/books", bookHandler.GetBooksByIDs)
+	internal.POST("/stock/deduct", stockHandler.DeductStock)
+	internal.POST("/stock/restock", stockHandler.RestockStock)
+	internal.POST("/events", eventHandler.HandleEvent)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -2,20 +2,71 @@ package middleware
 
 import (
 	"book-service/helpers"
+	"book-service/policy"
 	"net/http"
 	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
 
+// JwtMiddleware rejects requests without a valid token.
 func JwtMiddleware() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			userID, err := helpers.ExtractToken(c)
+			claims, err := helpers.ExtractToken(c)
 			if err != nil {
 				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or missing token")
 			}
-			c.Set("user_id", strconv.FormatUint(uint64(userID), 10))
+			setClaims(c, claims)
+			return next(c)
+		}
+	}
+}
+
+// OptionalJwtMiddleware lets anonymous requests through for public
+// endpoints, but still identifies the caller when a token is sent. A token
+// that is sent but invalid is rejected, so clients notice it has expired.
+func OptionalJwtMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Header.Get("Authorization") == "" {
+				return next(c)
+			}
+
+			claims, err := helpers.ExtractToken(c)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or missing token")
+			}
+			setClaims(c, claims)
+			return next(c)
+		}
+	}
+}
+
+// setClaims stores the caller's identity on the context. Handlers read the
+// user ID from "user_id" and pass "actor" to the policy checks.
+func setClaims(c echo.Context, claims *helpers.Claims) {
+	c.Set("user_id", strconv.FormatUint(uint64(claims.UserID), 10))
+	c.Set("role", claims.Role)
+	c.Set("email", claims.Email)
+	c.Set("name", claims.Name)
+	c.Set("actor", policy.Actor{UserID: claims.UserID, Role: claims.Role})
+}
+
+// ServiceAuthMiddleware guards the internal endpoints other services call.
+// Only a service token is accepted; user tokens are rejected whatever their
+// role.
+func ServiceAuthMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, err := helpers.ExtractServiceToken(c)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, "Invalid or missing service token")
+			}
+			c.Set("user_id", "0")
+			c.Set("role", policy.RoleService)
+			c.Set("service", claims.Service)
+			c.Set("actor", policy.Actor{Role: policy.RoleService})
 			return next(c)
 		}
 	}
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// Periods seller analytics can be grouped by.
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// BookView marks that a viewer looked at a book on a day (UTC). It only
+// exists so each viewer is counted once per day, and is purged after a few
+// days; the counts live in BookDailyStat.
+type BookView struct {
+	BookID    uint      `gorm:"primaryKey"`
+	ViewerKey string    `gorm:"primaryKey;size:80"`
+	Day       time.Time `gorm:"primaryKey;type:date"`
+}
+
+// BookDailyStat is the daily rollup seller analytics are read from. Views
+// and wishlist adds are counted as they happen; sales and revenue are
+// copied from transaction-service by the analytics sync job.
+type BookDailyStat struct {
+	BookID       uint      `json:"book_id" gorm:"primaryKey"`
+	Day          time.Time `json:"day" gorm:"primaryKey;type:date"`
+	Views        int64     `json:"views" gorm:"not null;default:0"`
+	WishlistAdds int64     `json:"wishlist_adds" gorm:"not null;default:0"`
+	Sales        int64     `json:"sales" gorm:"not null;default:0"`
+	Revenue      float64   `json:"revenue" gorm:"not null;default:0"`
+	BookName     string    `json:"-" gorm:"->;-:migration"`
+}
+
+type AnalyticsStats struct {
+	Views          int64   `json:"views"`
+	WishlistAdds   int64   `json:"wishlist_adds"`
+	Sales          int64   `json:"sales"`
+	Revenue        float64 `json:"revenue"`
+	ConversionRate float64 `json:"conversion_rate"`
+}
+
+type BookAnalytics struct {
+	BookID uint   `json:"book_id"`
+	Name   string `json:"name"`
+	AnalyticsStats
+}
+
+type PeriodAnalytics struct {
+	PeriodStart time.Time `json:"period_start"`
+	AnalyticsStats
+}
+
+// SellerAnalytics covers the days from through to, both inclusive.
+type SellerAnalytics struct {
+	From    time.Time         `json:"from"`
+	To      time.Time         `json:"to"`
+	Period  string            `json:"period"`
+	Totals  AnalyticsStats    `json:"totals"`
+	Books   []BookAnalytics   `json:"books"`
+	Periods []PeriodAnalytics `json:"periods"`
+}
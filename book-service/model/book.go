@@ -1,8 +1,18 @@
 package model
 
 import (
-	"time"
 	"gorm.io/gorm"
+	"time"
+)
+
+// Listing statuses. Only active listings are shown publicly; sold_out is
+// set and cleared automatically as stock runs out and is replenished.
+const (
+	StatusDraft    = "draft"
+	StatusActive   = "active"
+	StatusPaused   = "paused"
+	StatusSoldOut  = "sold_out"
+	StatusArchived = "archived"
 )
 
 type Book struct {
@@ -14,6 +24,9 @@ type Book struct {
 	Stock       int            `json:"stock" gorm:"default:0"`
 	Costs       float64        `json:"costs" gorm:"not null;type:decimal(10,2)"`
 	Category    string         `json:"category" gorm:"size:100"`
+	CategoryID  *uint          `json:"category_id" gorm:"index"`
+	Status      string         `json:"status" gorm:"not null;size:20;default:active;index"`
+	Version     uint           `json:"version" gorm:"not null;default:1"`
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `json:"-" gorm:"index"`
@@ -26,6 +39,8 @@ type CreateBookRequest struct {
 	Stock       int     `json:"stock" validate:"min=0"`
 	Costs       float64 `json:"costs" validate:"required,min=0"`
 	Category    string  `json:"category"`
+	CategoryID  *uint   `json:"category_id"`
+	Status      string  `json:"status" validate:"omitempty,oneof=draft active"`
 }
 
 type UpdateBookRequest struct {
@@ -35,30 +50,77 @@ type UpdateBookRequest struct {
 	Stock       *int     `json:"stock,omitempty" validate:"omitempty,min=0"`
 	Costs       *float64 `json:"costs,omitempty" validate:"omitempty,min=0"`
 	Category    *string  `json:"category,omitempty"`
+	CategoryID  *uint    `json:"category_id,omitempty"`
+	Status      *string  `json:"status,omitempty" validate:"omitempty,oneof=draft active paused archived"`
 }
 
 type BookResponse struct {
-	ID          uint      `json:"id"`
-	SellerID    uint      `json:"seller_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Author      string    `json:"author"`
-	Stock       int       `json:"stock"`
-	Costs       float64   `json:"costs"`
-	Category    string    `json:"category"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             uint          `json:"id"`
+	SellerID       uint          `json:"seller_id"`
+	Name           string        `json:"name"`
+	Description    string        `json:"description"`
+	Author         string        `json:"author"`
+	Stock          int           `json:"stock"`
+	AvailableStock int           `json:"available_stock"`
+	Costs          float64       `json:"costs"`
+	Category       string        `json:"category"`
+	CategoryID     *uint         `json:"category_id"`
+	Status         string        `json:"status"`
+	Rating         RatingSummary `json:"rating"`
+	SellerRating   RatingSummary `json:"seller_rating"`
+	Version        uint          `json:"version"`
+	CreatedAt      time.Time     `json:"created_at"`
+}
+
+// MaxBookBatchSize caps how many books one batch lookup may ask for.
+const MaxBookBatchSize = 100
+
+// BookBatch is the result of a batch lookup. MissingIDs lists the IDs that
+// do not exist or are not visible to the caller.
+type BookBatch struct {
+	Books      []BookResponse `json:"books"`
+	MissingIDs []uint         `json:"missing_ids"`
+}
+
+// BookFilter narrows a book listing. When CategoryIDs is set it takes
+// precedence over Category, which is matched against the free-text name.
+// An empty Status matches every status.
+type BookFilter struct {
+	CategoryIDs []uint
+	Category    string
+	Status      string
+	// OwnerID, when set, also matches that seller's own listings whatever
+	// their status.
+	OwnerID uint
 }
 
 func (b *Book) ToResponse() BookResponse {
 	return BookResponse{
-		ID:          b.ID,
-		SellerID:    b.SellerID,
-		Name:        b.Name,
-		Description: b.Description,
-		Author:      b.Author,
-		Stock:       b.Stock,
-		Costs:       b.Costs,
-		Category:    b.Category,
-		CreatedAt:   b.CreatedAt,
+		ID:             b.ID,
+		SellerID:       b.SellerID,
+		Name:           b.Name,
+		Description:    b.Description,
+		Author:         b.Author,
+		Stock:          b.Stock,
+		AvailableStock: b.Stock,
+		Costs:          b.Costs,
+		Category:       b.Category,
+		CategoryID:     b.CategoryID,
+		Status:         b.Status,
+		Version:        b.Version,
+		CreatedAt:      b.CreatedAt,
 	}
-}
\ No newline at end of file
+}
+
+// StockStatus keeps the status in step with the stock: an active listing
+// with nothing left is sold out, and a sold-out listing that has been
+// restocked is active again.
+func StockStatus(status string, stock int) string {
+	if status == StatusActive && stock <= 0 {
+		return StatusSoldOut
+	}
+	if status == StatusSoldOut && stock > 0 {
+		return StatusActive
+	}
+	return status
+}
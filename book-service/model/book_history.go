@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Actions recorded in a book's change history.
+const (
+	ChangeUpdated       = "updated"
+	ChangeStockDeducted = "stock_deducted"
+	ChangeDeleted       = "deleted"
+	ChangeRestored      = "restored"
+	ChangeRestocked     = "restocked"
+)
+
+// BookChange is one entry in a book's change history: who did what to the
+// book and, for edits, which fields changed.
+type BookChange struct {
+	ID        uint          `json:"id" gorm:"primaryKey"`
+	BookID    uint          `json:"book_id" gorm:"not null;index"`
+	ActorID   uint          `json:"actor_id" gorm:"not null"`
+	Action    string        `json:"action" gorm:"not null;size:20"`
+	Fields    []FieldChange `json:"fields" gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// TrashedBook is a deleted book that its seller can still restore.
+type TrashedBook struct {
+	BookResponse
+	DeletedAt       time.Time `json:"deleted_at"`
+	RestorableUntil time.Time `json:"restorable_until"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+type Category struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	Slug      string    `json:"slug" gorm:"not null;size:120;uniqueIndex"`
+	ParentID  *uint     `json:"parent_id" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CreateCategoryRequest struct {
+	Name     string `json:"name" validate:"required,max=100"`
+	Slug     string `json:"slug" validate:"omitempty,max=120"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+type UpdateCategoryRequest struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,max=100"`
+	Slug     *string `json:"slug,omitempty" validate:"omitempty,max=120"`
+	ParentID *uint   `json:"parent_id,omitempty"`
+	// MoveToRoot detaches the category from its parent; ParentID cannot
+	// express that because a missing and a null field look the same.
+	MoveToRoot bool `json:"move_to_root,omitempty"`
+}
+
+// CategoryNode is a category in the /categories tree. ListingCount includes
+// the listings of every subcategory.
+type CategoryNode struct {
+	ID           uint           `json:"id"`
+	Name         string         `json:"name"`
+	Slug         string         `json:"slug"`
+	ParentID     *uint          `json:"parent_id"`
+	ListingCount int64          `json:"listing_count"`
+	Children     []CategoryNode `json:"children"`
+}
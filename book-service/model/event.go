@@ -0,0 +1,37 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event types book-service consumes.
+const (
+	EventTransactionStatusChanged = "transaction.status_changed"
+)
+
+// IncomingEvent is an event another service's outbox delivers to
+// book-service. The same event may be delivered more than once; ID is
+// what tells repeats apart.
+type IncomingEvent struct {
+	ID   string          `json:"id" validate:"required,max=100"`
+	Type string          `json:"type" validate:"required,max=50"`
+	Data json.RawMessage `json:"data"`
+}
+
+// TransactionStatusChanged is the part of a transaction.status_changed
+// event book-service acts on.
+type TransactionStatusChanged struct {
+	TransactionID uint   `json:"transaction_id"`
+	ToStatus      string `json:"to_status"`
+}
+
+// ProcessedEvent records an event that has been handled, so a repeated
+// delivery is acknowledged without handling it again.
+type ProcessedEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	EventID   string    `json:"event_id" gorm:"not null;size:100;uniqueIndex"`
+	Type      string    `json:"type" gorm:"not null;size:50"`
+	Service   string    `json:"service" gorm:"size:50"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+const (
+	ImportPending   = "pending"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// ImportJob tracks a bulk import that is too large to run inside the
+// request. Rows have already been validated when the job is created.
+type ImportJob struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	SellerID      uint      `json:"seller_id" gorm:"not null;index"`
+	Status        string    `json:"status" gorm:"not null;size:20"`
+	TotalRows     int       `json:"total_rows"`
+	ProcessedRows int       `json:"processed_rows"`
+	Error         string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ImportRowError describes why a row of an import file was rejected. Row
+// numbers are 1-based and count the header row, matching what the seller
+// sees in a spreadsheet.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Field string `json:"field,omitempty"`
+	Error string `json:"error"`
+}
+
+type ImportResult struct {
+	DryRun    bool             `json:"dry_run"`
+	TotalRows int              `json:"total_rows"`
+	ValidRows int              `json:"valid_rows"`
+	Errors    []ImportRowError `json:"errors"`
+	Created   int              `json:"created"`
+	Job       *ImportJob       `json:"job,omitempty"`
+}
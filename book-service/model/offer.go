@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+const (
+	OfferPending   = "pending"
+	OfferCountered = "countered"
+	OfferAccepted  = "accepted"
+	OfferRejected  = "rejected"
+	OfferExpired   = "expired"
+	OfferRedeemed  = "redeemed"
+)
+
+// Offer is a buyer's proposal to buy Quantity copies of a book at Price.
+// A pending offer waits for the seller, a countered one (whose Price is
+// then the seller's) waits for the buyer. ExpiresAt is the deadline to
+// respond while the offer is open, and how long the agreed price can be
+// used once it is accepted.
+type Offer struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	BookID        uint      `json:"book_id" gorm:"not null;index"`
+	BuyerID       uint      `json:"buyer_id" gorm:"not null;index"`
+	SellerID      uint      `json:"seller_id" gorm:"not null;index"`
+	Price         float64   `json:"price" gorm:"not null;type:decimal(10,2)"`
+	Quantity      int       `json:"quantity" gorm:"not null"`
+	Status        string    `json:"status" gorm:"not null;size:20;index"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"not null;index"`
+	TransactionID *uint     `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type CreateOfferRequest struct {
+	Price    float64 `json:"price" validate:"required,gt=0"`
+	Quantity int     `json:"quantity" validate:"required,min=1"`
+}
+
+type CounterOfferRequest struct {
+	Price float64 `json:"price" validate:"required,gt=0"`
+}
+
+type RedeemOfferRequest struct {
+	TransactionID uint `json:"transaction_id" validate:"required"`
+}
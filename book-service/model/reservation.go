@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+const (
+	ReservationActive    = "active"
+	ReservationConfirmed = "confirmed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// Reservation holds Quantity copies of a book for a pending transaction
+// until it is confirmed, released or ExpiresAt passes.
+type Reservation struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	BookID        uint      `json:"book_id" gorm:"not null;index"`
+	TransactionID uint      `json:"transaction_id" gorm:"not null;index"`
+	Quantity      int       `json:"quantity" gorm:"not null"`
+	Status        string    `json:"status" gorm:"not null;size:20;index"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type CreateReservationRequest struct {
+	TransactionID uint `json:"transaction_id" validate:"required"`
+	Quantity      int  `json:"quantity" validate:"required,min=1"`
+	TTLSeconds    int  `json:"ttl_seconds" validate:"omitempty,min=1"`
+}
@@ -0,0 +1,72 @@
+package model
+
+import "time"
+
+// Review is a buyer's rating of a seller, and optionally of the book, for a
+// completed transaction. Each transaction can be reviewed once.
+type Review struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	TransactionID uint       `json:"transaction_id" gorm:"not null;uniqueIndex"`
+	BookID        uint       `json:"book_id" gorm:"not null;index"`
+	SellerID      uint       `json:"seller_id" gorm:"not null;index"`
+	BuyerID       uint       `json:"buyer_id" gorm:"not null;index"`
+	Rating        int        `json:"rating" gorm:"not null"`
+	BookRating    *int       `json:"book_rating"`
+	Comment       string     `json:"comment" gorm:"type:text"`
+	Reply         string     `json:"reply,omitempty" gorm:"type:text"`
+	RepliedAt     *time.Time `json:"replied_at,omitempty"`
+	ReportCount   int        `json:"-" gorm:"not null;default:0"`
+	Hidden        bool       `json:"-" gorm:"not null;default:false"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ReviewReport is a user's report of an abusive review.
+type ReviewReport struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	ReviewID   uint      `json:"review_id" gorm:"not null;uniqueIndex:idx_review_report_reporter"`
+	ReporterID uint      `json:"reporter_id" gorm:"not null;uniqueIndex:idx_review_report_reporter"`
+	Reason     string    `json:"reason" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateReviewRequest struct {
+	TransactionID uint   `json:"transaction_id" validate:"required"`
+	BookID        uint   `json:"book_id,omitempty"`
+	Rating        int    `json:"rating" validate:"required,min=1,max=5"`
+	BookRating    *int   `json:"book_rating,omitempty" validate:"omitempty,min=1,max=5"`
+	Comment       string `json:"comment" validate:"max=2000"`
+}
+
+type ReplyReviewRequest struct {
+	Reply string `json:"reply" validate:"required,max=2000"`
+}
+
+type ReportReviewRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type ModerateReviewRequest struct {
+	Hidden bool `json:"hidden"`
+}
+
+// ReportedReview is a review as shown to moderators, including whether it
+// is currently hidden.
+type ReportedReview struct {
+	Review
+	ReportCount int  `json:"report_count"`
+	Hidden      bool `json:"hidden"`
+}
+
+// RatingSummary aggregates the visible reviews of a seller or book.
+type RatingSummary struct {
+	Average float64 `json:"average"`
+	Count   int64   `json:"count"`
+}
+
+type SellerProfile struct {
+	SellerID       uint          `json:"seller_id"`
+	Rating         RatingSummary `json:"rating"`
+	ActiveListings int           `json:"active_listings"`
+	Reviews        []Review      `json:"reviews"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Kinds of batch stock operation.
+const (
+	StockDeduct  = "deduct"
+	StockRestock = "restock"
+)
+
+type StockItem struct {
+	BookID   uint `json:"book_id" validate:"required"`
+	Quantity int  `json:"quantity" validate:"required,min=1"`
+}
+
+type StockOperationRequest struct {
+	IdempotencyKey string      `json:"idempotency_key" validate:"required,max=100"`
+	Items          []StockItem `json:"items" validate:"required,min=1,dive"`
+}
+
+// StockOperation is a batch stock change recorded under the caller's
+// idempotency key, so a retried request is applied only once.
+type StockOperation struct {
+	ID             uint        `json:"id" gorm:"primaryKey"`
+	IdempotencyKey string      `json:"idempotency_key" gorm:"not null;size:100;uniqueIndex"`
+	Kind           string      `json:"kind" gorm:"not null;size:20"`
+	Service        string      `json:"service" gorm:"size:50"`
+	Items          []StockItem `json:"items" gorm:"type:jsonb;serializer:json"`
+	Replayed       bool        `json:"replayed" gorm:"-"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
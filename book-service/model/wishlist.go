@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+const (
+	AlertPriceDrop   = "price_drop"
+	AlertBackInStock = "back_in_stock"
+)
+
+type WishlistItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_wishlist_user_book"`
+	BookID    uint      `json:"book_id" gorm:"not null;uniqueIndex:idx_wishlist_user_book;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WishlistAlert records a notification sent to a user about a wishlisted
+// book. It is what the alert rate limits are checked against.
+type WishlistAlert struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index:idx_wishlist_alert_user_created"`
+	BookID    uint      `json:"book_id" gorm:"not null"`
+	Kind      string    `json:"kind" gorm:"not null;size:20"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_wishlist_alert_user_created"`
+}
+
+type AddWishlistRequest struct {
+	BookID uint `json:"book_id" validate:"required"`
+}
+
+type WishlistItemResponse struct {
+	BookID  uint         `json:"book_id"`
+	AddedAt time.Time    `json:"added_at"`
+	Book    BookResponse `json:"book"`
+}
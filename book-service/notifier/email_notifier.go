@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type emailNotifier struct {
+	authServiceURL  string
+	emailServiceURL string
+	client          *http.Client
+}
+
+// NewEmailNotifier sends notifications through email-service, looking up
+// the recipient's address in auth-service.
+func NewEmailNotifier(authServiceURL, emailServiceURL string) Notifier {
+	return &emailNotifier{
+		authServiceURL:  authServiceURL,
+		emailServiceURL: emailServiceURL,
+		client:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *emailNotifier) NotifyWishlist(userID uint, alert WishlistAlert) error {
+	email, err := n.userEmail(userID)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(struct {
+		Email string `json:"email"`
+		WishlistAlert
+	}{Email: email, WishlistAlert: alert})
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.emailServiceURL+"/send-wishlist-alert", "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("email-service returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (n *emailNotifier) userEmail(userID uint) (string, error) {
+	resp, err := n.client.Get(fmt.Sprintf("%s/users/%d", n.authServiceURL, userID))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("auth-service returned %d for user %d", resp.StatusCode, userID)
+	}
+
+	var body struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.User.Email == "" {
+		return "", fmt.Errorf("user %d has no email address", userID)
+	}
+	return body.User.Email, nil
+}
@@ -0,0 +1,16 @@
+package notifier
+
+// WishlistAlert is the content of a price-drop or back-in-stock email.
+type WishlistAlert struct {
+	Kind     string  `json:"kind"`
+	BookID   uint    `json:"book_id"`
+	BookName string  `json:"book_name"`
+	OldCosts float64 `json:"old_costs"`
+	NewCosts float64 `json:"new_costs"`
+	Stock    int     `json:"stock"`
+}
+
+// Notifier delivers notifications to users of the marketplace.
+type Notifier interface {
+	NotifyWishlist(userID uint, alert WishlistAlert) error
+}
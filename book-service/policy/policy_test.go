@@ -0,0 +1,42 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicies(t *testing.T) {
+	anonymous := Actor{}
+	buyer := Actor{UserID: 1, Role: RoleBuyer}
+	seller := Actor{UserID: 2, Role: RoleSeller}
+	admin := Actor{UserID: 3, Role: RoleAdmin}
+	service := Actor{Role: RoleService}
+
+	tests := []struct {
+		name  string
+		check func(Actor) bool
+		allow []Actor
+		deny  []Actor
+	}{
+		{"create books", CanCreateBooks, []Actor{seller}, []Actor{anonymous, buyer, admin, service}},
+		{"manage own book", func(a Actor) bool { return CanManageBook(a, 2) }, []Actor{seller, admin}, []Actor{anonymous, buyer, service}},
+		{"manage anonymous-owned book", func(a Actor) bool { return CanManageBook(a, 0) }, []Actor{admin}, []Actor{anonymous, service}},
+		{"deduct stock", CanDeductStock, []Actor{service}, []Actor{anonymous, buyer, seller, admin}},
+		{"deliver events", CanDeliverEvents, []Actor{service}, []Actor{anonymous, buyer, seller, admin}},
+		{"manage categories", CanManageCategories, []Actor{admin}, []Actor{anonymous, buyer, seller, service}},
+		{"moderate reviews", CanModerateReviews, []Actor{admin}, []Actor{anonymous, buyer, seller, service}},
+		{"receive offers", CanReceiveOffers, []Actor{seller}, []Actor{anonymous, buyer, admin, service}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, a := range tt.allow {
+				assert.True(t, tt.check(a), "%+v should be allowed", a)
+			}
+			for _, a := range tt.deny {
+				assert.False(t, tt.check(a), "%+v should be denied", a)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"book-service/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AnalyticsRepository interface {
+	RecordView(view *model.BookView) (bool, error)
+	Increment(bookID uint, day time.Time, column string) error
+	ReplaceSales(from time.Time, to time.Time, stats []model.BookDailyStat) error
+	PurgeViews(before time.Time) (int64, error)
+	GetSellerStats(sellerID uint, from time.Time, to time.Time) ([]model.BookDailyStat, error)
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// RecordView stores a view and reports whether it is the viewer's first
+// view of the book that day.
+func (r *analyticsRepository) RecordView(view *model.BookView) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(view)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Increment adds one to a counter column ("views" or "wishlist_adds") of a
+// book's rollup for day, creating the row if needed.
+func (r *analyticsRepository) Increment(bookID uint, day time.Time, column string) error {
+	stat := map[string]interface{}{
+		"book_id": bookID,
+		"day":     day,
+		column:    1,
+	}
+	return r.db.Model(&model.BookDailyStat{}).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "book_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			column: gorm.Expr("book_daily_stats." + column + " + 1"),
+		}),
+	}).Create(stat).Error
+}
+
+// ReplaceSales overwrites the sales and revenue of every rollup between
+// from and to with stats, so sales that transaction-service no longer
+// reports are cleared too.
+func (r *analyticsRepository) ReplaceSales(from time.Time, to time.Time, stats []model.BookDailyStat) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.BookDailyStat{}).
+			Where("day >= ? AND day <= ?", from, to).
+			Updates(map[string]interface{}{"sales": 0, "revenue": 0}).Error
+		if err != nil || len(stats) == 0 {
+			return err
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "book_id"}, {Name: "day"}},
+			DoUpdates: clause.AssignmentColumns([]string{"sales", "revenue"}),
+		}).Create(&stats).Error
+	})
+}
+
+// PurgeViews removes the per-viewer records of days before before. They
+// are only needed to deduplicate the current day's views.
+func (r *analyticsRepository) PurgeViews(before time.Time) (int64, error) {
+	result := r.db.Where("day < ?", before).Delete(&model.BookView{})
+	return result.RowsAffected, result.Error
+}
+
+// GetSellerStats returns the rollups of the seller's books, deleted ones
+// included, between from and to.
+func (r *analyticsRepository) GetSellerStats(sellerID uint, from time.Time, to time.Time) ([]model.BookDailyStat, error) {
+	var stats []model.BookDailyStat
+	err := r.db.Model(&model.BookDailyStat{}).
+		Select("book_daily_stats.*, books.name AS book_name").
+		Joins("JOIN books ON books.id = book_daily_stats.book_id").
+		Where("books.seller_id = ? AND book_daily_stats.day >= ? AND book_daily_stats.day <= ?", sellerID, from, to).
+		Order("book_daily_stats.day, book_daily_stats.book_id").
+		Find(&stats).Error
+	return stats, err
+}
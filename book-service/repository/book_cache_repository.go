@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"book-service/cache"
+	"book-service/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const listVersionKey = "books:list:version"
+
+// BookCacheInvalidator is implemented by repositories that cache books. It
+// lets callers that change a book outside of BookRepository, such as
+// reservation confirmation, drop the stale entries.
+type BookCacheInvalidator interface {
+	InvalidateBook(id uint)
+}
+
+// cachedBookRepository decorates a BookRepository with a cache-aside layer.
+// Single books are cached under their ID and invalidated on every write.
+// Listing queries are cached under a shared version number which is bumped
+// on every write, so stale listings are never served after a change.
+type cachedBookRepository struct {
+	BookRepository
+	cache   cache.Cache
+	bookTTL time.Duration
+	listTTL time.Duration
+	group   singleflight.Group
+}
+
+func NewCachedBookRepository(repo BookRepository, c cache.Cache, bookTTL, listTTL time.Duration) BookRepository {
+	return &cachedBookRepository{
+		BookRepository: repo,
+		cache:          c,
+		bookTTL:        bookTTL,
+		listTTL:        listTTL,
+	}
+}
+
+func (r *cachedBookRepository) Create(book *model.Book) error {
+	if err := r.BookRepository.Create(book); err != nil {
+		return err
+	}
+	r.invalidateLists()
+	return nil
+}
+
+func (r *cachedBookRepository) CreateBatch(books []model.Book) error {
+	if err := r.BookRepository.CreateBatch(books); err != nil {
+		return err
+	}
+	r.invalidateLists()
+	return nil
+}
+
+func (r *cachedBookRepository) GetAll(filter model.BookFilter) ([]model.Book, error) {
+	key := fmt.Sprintf("books:list:%s:all:%v:%s:%s:%d", r.listVersion(), filter.CategoryIDs, filter.Category, filter.Status, filter.OwnerID)
+	var books []model.Book
+	err := r.load(key, r.listTTL, &books, func() (interface{}, error) {
+		return r.BookRepository.GetAll(filter)
+	})
+	return books, err
+}
+
+func (r *cachedBookRepository) GetByID(id uint) (*model.Book, error) {
+	var book model.Book
+	err := r.load(bookKey(id), r.bookTTL, &book, func() (interface{}, error) {
+		return r.BookRepository.GetByID(id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// GetByIDs serves the books it can from the single-book cache and loads
+// the rest in one query, caching them for later lookups.
+func (r *cachedBookRepository) GetByIDs(ids []uint) ([]model.Book, error) {
+	ctx := context.Background()
+
+	var books []model.Book
+	var missed []uint
+	for _, id := range ids {
+		data, err := r.cache.Get(ctx, bookKey(id))
+		if err != nil {
+			if !errors.Is(err, cache.ErrCacheMiss) {
+				log.Printf("cache get %s: %v", bookKey(id), err)
+			}
+			missed = append(missed, id)
+			continue
+		}
+
+		var book model.Book
+		if err := json.Unmarshal(data, &book); err != nil {
+			missed = append(missed, id)
+			continue
+		}
+		books = append(books, book)
+	}
+	if len(missed) == 0 {
+		return books, nil
+	}
+
+	loaded, err := r.BookRepository.GetByIDs(missed)
+	if err != nil {
+		return nil, err
+	}
+	for _, book := range loaded {
+		if data, err := json.Marshal(book); err == nil {
+			if err := r.cache.Set(ctx, bookKey(book.ID), data, jitter(r.bookTTL)); err != nil {
+				log.Printf("cache set %s: %v", bookKey(book.ID), err)
+			}
+		}
+	}
+	return append(books, loaded...), nil
+}
+
+func (r *cachedBookRepository) GetBySellerID(sellerID uint) ([]model.Book, error) {
+	key := fmt.Sprintf("books:list:%s:seller:%d", r.listVersion(), sellerID)
+	var books []model.Book
+	err := r.load(key, r.listTTL, &books, func() (interface{}, error) {
+		return r.BookRepository.GetBySellerID(sellerID)
+	})
+	return books, err
+}
+
+func (r *cachedBookRepository) Update(book *model.Book) error {
+	err := r.BookRepository.Update(book)
+	r.InvalidateBook(book.ID)
+	return err
+}
+
+func (r *cachedBookRepository) Delete(id uint, sellerID uint, version uint) error {
+	err := r.BookRepository.Delete(id, sellerID, version)
+	r.InvalidateBook(id)
+	return err
+}
+
+func (r *cachedBookRepository) DeductStock(id uint, amount int) error {
+	err := r.BookRepository.DeductStock(id, amount)
+	r.InvalidateBook(id)
+	return err
+}
+
+func (r *cachedBookRepository) Restore(id uint, sellerID uint, deletedSince time.Time) error {
+	err := r.BookRepository.Restore(id, sellerID, deletedSince)
+	r.InvalidateBook(id)
+	return err
+}
+
+// load reads key from the cache into dest. On a miss, fetch is called once
+// per key no matter how many callers are waiting on it, and its result is
+// written back to the cache.
+func (r *cachedBookRepository) load(key string, ttl time.Duration, dest interface{}, fetch func() (interface{}, error)) error {
+	ctx := context.Background()
+
+	if data, err := r.cache.Get(ctx, key); err == nil {
+		if err := json.Unmarshal(data, dest); err == nil {
+			return nil
+		}
+	} else if !errors.Is(err, cache.ErrCacheMiss) {
+		log.Printf("cache get %s: %v", key, err)
+	}
+
+	data, err, _ := r.group.Do(key, func() (interface{}, error) {
+		value, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.cache.Set(ctx, key, data, jitter(ttl)); err != nil {
+			log.Printf("cache set %s: %v", key, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data.([]byte), dest)
+}
+
+// listVersion returns the current listing version, initialising it if the
+// cache has none yet.
+func (r *cachedBookRepository) listVersion() string {
+	ctx := context.Background()
+
+	data, err := r.cache.Get(ctx, listVersionKey)
+	if err == nil {
+		return string(data)
+	}
+
+	version := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := r.cache.Set(ctx, listVersionKey, []byte(version), 0); err != nil {
+		log.Printf("cache set %s: %v", listVersionKey, err)
+	}
+	return version
+}
+
+func (r *cachedBookRepository) InvalidateBook(id uint) {
+	if err := r.cache.Delete(context.Background(), bookKey(id)); err != nil {
+		log.Printf("cache delete %s: %v", bookKey(id), err)
+	}
+	r.invalidateLists()
+}
+
+func (r *cachedBookRepository) invalidateLists() {
+	version := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := r.cache.Set(context.Background(), listVersionKey, []byte(version), 0); err != nil {
+		log.Printf("cache set %s: %v", listVersionKey, err)
+	}
+}
+
+func bookKey(id uint) string {
+	return fmt.Sprintf("book:%d", id)
+}
+
+// jitter spreads expiry by up to 10% so entries written together do not all
+// expire at the same moment.
+func jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(rand.Int63n(int64(ttl)/10+1))
+}
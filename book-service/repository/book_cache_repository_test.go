@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"book-service/cache"
+	"book-service/model"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubBookRepository struct {
+	BookRepository
+	books      map[uint]model.Book
+	getCalls   int32
+	allCalls   int32
+	batchCalls int32
+	batchIDs   []uint
+	delay      time.Duration
+}
+
+func (s *stubBookRepository) GetByID(id uint) (*model.Book, error) {
+	atomic.AddInt32(&s.getCalls, 1)
+	time.Sleep(s.delay)
+	book := s.books[id]
+	return &book, nil
+}
+
+func (s *stubBookRepository) GetByIDs(ids []uint) ([]model.Book, error) {
+	atomic.AddInt32(&s.batchCalls, 1)
+	s.batchIDs = ids
+	var books []model.Book
+	for _, id := range ids {
+		if book, ok := s.books[id]; ok {
+			books = append(books, book)
+		}
+	}
+	return books, nil
+}
+
+func (s *stubBookRepository) GetAll(filter model.BookFilter) ([]model.Book, error) {
+	atomic.AddInt32(&s.allCalls, 1)
+	var books []model.Book
+	for _, book := range s.books {
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+func (s *stubBookRepository) Update(book *model.Book) error {
+	s.books[book.ID] = *book
+	return nil
+}
+
+func (s *stubBookRepository) DeductStock(id uint, amount int) error {
+	book := s.books[id]
+	book.Stock -= amount
+	s.books[id] = book
+	return nil
+}
+
+func newStubRepo() *stubBookRepository {
+	return &stubBookRepository{books: map[uint]model.Book{
+		1: {ID: 1, Name: "Test Book", Stock: 5},
+	}}
+}
+
+func TestCachedGetByID_HitsCacheAfterFirstRead(t *testing.T) {
+	stub := newStubRepo()
+	repo := NewCachedBookRepository(stub, cache.NewMemoryCache(), time.Minute, time.Minute)
+
+	first, err := repo.GetByID(1)
+	assert.NoError(t, err)
+	second, err := repo.GetByID(1)
+	assert.NoError(t, err)
+
+	assert.Equal(t, first.Name, second.Name)
+	assert.Equal(t, int32(1), stub.getCalls)
+}
+
+func TestCachedGetByIDs_LoadsOnlyUncachedBooks(t *testing.T) {
+	stub := newStubRepo()
+	stub.books[2] = model.Book{ID: 2, Name: "Second Book", Stock: 1}
+	repo := NewCachedBookRepository(stub, cache.NewMemoryCache(), time.Minute, time.Minute)
+
+	_, _ = repo.GetByID(1)
+	books, err := repo.GetByIDs([]uint{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+	assert.Equal(t, []uint{2, 3}, stub.batchIDs)
+
+	books, err = repo.GetByIDs([]uint{1, 2})
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+	assert.Equal(t, int32(1), stub.batchCalls)
+}
+
+func TestCachedGetByID_InvalidatedOnDeductStock(t *testing.T) {
+	stub := newStubRepo()
+	repo := NewCachedBookRepository(stub, cache.NewMemoryCache(), time.Minute, time.Minute)
+
+	_, _ = repo.GetByID(1)
+	assert.NoError(t, repo.DeductStock(1, 2))
+
+	book, err := repo.GetByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, book.Stock)
+	assert.Equal(t, int32(2), stub.getCalls)
+}
+
+func TestCachedGetAll_InvalidatedOnUpdate(t *testing.T) {
+	stub := newStubRepo()
+	repo := NewCachedBookRepository(stub, cache.NewMemoryCache(), time.Minute, time.Minute)
+
+	_, _ = repo.GetAll(model.BookFilter{})
+	_, _ = repo.GetAll(model.BookFilter{})
+	assert.Equal(t, int32(1), stub.allCalls)
+
+	assert.NoError(t, repo.Update(&model.Book{ID: 1, Name: "Renamed"}))
+
+	books, err := repo.GetAll(model.BookFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", books[0].Name)
+	assert.Equal(t, int32(2), stub.allCalls)
+}
+
+func TestCachedGetAll_KeyedByOwner(t *testing.T) {
+	stub := newStubRepo()
+	repo := NewCachedBookRepository(stub, cache.NewMemoryCache(), time.Minute, time.Minute)
+
+	_, _ = repo.GetAll(model.BookFilter{Status: model.StatusActive})
+	_, _ = repo.GetAll(model.BookFilter{Status: model.StatusActive, OwnerID: 7})
+	assert.Equal(t, int32(2), stub.allCalls)
+}
+
+func TestCachedGetByID_CollapsesConcurrentMisses(t *testing.T) {
+	stub := newStubRepo()
+	stub.delay = 50 * time.Millisecond
+	repo := NewCachedBookRepository(stub, cache.NewMemoryCache(), time.Minute, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = repo.GetByID(1)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), stub.getCalls)
+}
+
+func TestCachedGetByID_NoopCacheAlwaysReadsThrough(t *testing.T) {
+	stub := newStubRepo()
+	repo := NewCachedBookRepository(stub, cache.NewNoopCache(), time.Minute, time.Minute)
+
+	_, _ = repo.GetByID(1)
+	_, _ = repo.GetByID(1)
+
+	assert.Equal(t, int32(2), stub.getCalls)
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"book-service/model"
+
+	"gorm.io/gorm"
+)
+
+type BookHistoryRepository interface {
+	Record(change *model.BookChange) error
+	GetByBookID(bookID uint) ([]model.BookChange, error)
+}
+
+type bookHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewBookHistoryRepository(db *gorm.DB) BookHistoryRepository {
+	return &bookHistoryRepository{db: db}
+}
+
+func (r *bookHistoryRepository) Record(change *model.BookChange) error {
+	return r.db.Create(change).Error
+}
+
+// GetByBookID returns a book's history, newest first.
+func (r *bookHistoryRepository) GetByBookID(bookID uint) ([]model.BookChange, error) {
+	var changes []model.BookChange
+	err := r.db.Where("book_id = ?", bookID).Order("created_at DESC, id DESC").Find(&changes).Error
+	return changes, err
+}
@@ -2,18 +2,27 @@ package repository
 
 import (
 	"book-service/model"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+var ErrVersionConflict = errors.New("book has been modified")
+
 type BookRepository interface {
 	Create(book *model.Book) error
-	GetAll(category string) ([]model.Book, error)
+	CreateBatch(books []model.Book) error
+	GetAll(filter model.BookFilter) ([]model.Book, error)
 	GetByID(id uint) (*model.Book, error)
+	GetByIDs(ids []uint) ([]model.Book, error)
 	GetBySellerID(sellerID uint) ([]model.Book, error)
 	Update(book *model.Book) error
-	Delete(id uint, sellerID uint) error
+	Delete(id uint, sellerID uint, version uint) error
 	DeductStock(id uint, amount int) error
+	GetDeletedBySellerID(sellerID uint, deletedSince time.Time) ([]model.Book, error)
+	Restore(id uint, sellerID uint, deletedSince time.Time) error
+	PurgeDeleted(deletedBefore time.Time) (int64, error)
 }
 
 type bookRepository struct {
@@ -28,12 +37,26 @@ func (r *bookRepository) Create(book *model.Book) error {
 	return r.db.Create(book).Error
 }
 
-func (r *bookRepository) GetAll(category string) ([]model.Book, error) {
+// CreateBatch inserts all books in one database transaction.
+func (r *bookRepository) CreateBatch(books []model.Book) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&books, 100).Error
+	})
+}
+
+func (r *bookRepository) GetAll(filter model.BookFilter) ([]model.Book, error) {
 	var books []model.Book
 	query := r.db.Model(&model.Book{})
 
-	if category != "" {
-		query = query.Where("category ILIKE ?", "%"+category+"%")
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("category_id IN ?", filter.CategoryIDs)
+	} else if filter.Category != "" {
+		query = query.Where("category ILIKE ?", "%"+filter.Category+"%")
+	}
+	if filter.Status != "" && filter.OwnerID != 0 {
+		query = query.Where("(status = ? OR seller_id = ?)", filter.Status, filter.OwnerID)
+	} else if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
 	}
 
 	err := query.Find(&books).Error
@@ -49,20 +72,127 @@ func (r *bookRepository) GetByID(id uint) (*model.Book, error) {
 	return &book, nil
 }
 
+// GetByIDs returns the books with the given IDs in one query. IDs without
+// a book are left out, and the order is not guaranteed.
+func (r *bookRepository) GetByIDs(ids []uint) ([]model.Book, error) {
+	var books []model.Book
+	if len(ids) == 0 {
+		return books, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&books).Error
+	return books, err
+}
+
 func (r *bookRepository) GetBySellerID(sellerID uint) ([]model.Book, error) {
 	var books []model.Book
 	err := r.db.Where("seller_id = ?", sellerID).Find(&books).Error
 	return books, err
 }
 
+// Update writes the book only if its version is still the one it was read
+// with, and bumps the version. ErrVersionConflict is returned when another
+// write got there first.
 func (r *bookRepository) Update(book *model.Book) error {
-	return r.db.Save(book).Error
+	current := book.Version
+	book.Version = current + 1
+
+	result := r.db.Model(book).Where("version = ?", current).Select("*").Omit("created_at").Updates(book)
+	if result.Error != nil {
+		book.Version = current
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		book.Version = current
+		return ErrVersionConflict
+	}
+	return nil
 }
 
-func (r *bookRepository) Delete(id uint, sellerID uint) error {
-	return r.db.Where("id = ? AND seller_id = ?", id, sellerID).Delete(&model.Book{}).Error
+func (r *bookRepository) Delete(id uint, sellerID uint, version uint) error {
+	result := r.db.Where("id = ? AND seller_id = ? AND version = ?", id, sellerID, version).Delete(&model.Book{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
 }
 
+// DeductStock takes amount off a book's stock. ErrInsufficientStock is
+// returned when the book no longer has enough stock, e.g. because another
+// order took the last copies between the caller's read and this update.
 func (r *bookRepository) DeductStock(id uint, amount int) error {
-	return r.db.Model(&model.Book{}).Where("id = ? AND stock >= ?", id, amount).Update("stock", gorm.Expr("stock - ?", amount)).Error
-}
\ No newline at end of file
+	result := r.db.Model(&model.Book{}).Where("id = ? AND stock >= ?", id, amount).Updates(deductStockUpdates(amount))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// deductStockUpdates takes amount off a book's stock, bumps its version and
+// marks an active listing sold_out when the last copy goes.
+func deductStockUpdates(amount int) map[string]interface{} {
+	return map[string]interface{}{
+		"stock":   gorm.Expr("stock - ?", amount),
+		"version": gorm.Expr("version + 1"),
+		"status":  gorm.Expr("CASE WHEN stock - ? <= 0 AND status = ? THEN ? ELSE status END", amount, model.StatusActive, model.StatusSoldOut),
+	}
+}
+
+// GetDeletedBySellerID returns the seller's books deleted since
+// deletedSince, most recently deleted first.
+func (r *bookRepository) GetDeletedBySellerID(sellerID uint, deletedSince time.Time) ([]model.Book, error) {
+	var books []model.Book
+	err := r.db.Unscoped().
+		Where("seller_id = ? AND deleted_at IS NOT NULL AND deleted_at >= ?", sellerID, deletedSince).
+		Order("deleted_at DESC").
+		Find(&books).Error
+	return books, err
+}
+
+// Restore undeletes a book deleted since deletedSince and bumps its
+// version. gorm.ErrRecordNotFound is returned when the seller has no such
+// book in the trash.
+func (r *bookRepository) Restore(id uint, sellerID uint, deletedSince time.Time) error {
+	result := r.db.Unscoped().Model(&model.Book{}).
+		Where("id = ? AND seller_id = ? AND deleted_at IS NOT NULL AND deleted_at >= ?", id, sellerID, deletedSince).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// PurgeDeleted permanently removes books deleted before deletedBefore,
+// together with their change history, and returns how many were removed.
+func (r *bookRepository) PurgeDeleted(deletedBefore time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Unscoped().Model(&model.Book{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err := tx.Where("book_id IN ?", ids).Delete(&model.BookChange{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Book{})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"book-service/model"
+
+	"gorm.io/gorm"
+)
+
+type CategoryRepository interface {
+	Create(category *model.Category) error
+	Update(category *model.Category) error
+	Delete(id uint) error
+	GetByID(id uint) (*model.Category, error)
+	GetBySlug(slug string) (*model.Category, error)
+	GetAll() ([]model.Category, error)
+	DescendantIDs(id uint) ([]uint, error)
+	CountChildren(id uint) (int64, error)
+	CountBooksByCategory() (map[uint]int64, error)
+	RenameBooks(categoryID uint, name string) ([]uint, error)
+}
+
+type categoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) CategoryRepository {
+	return &categoryRepository{db: db}
+}
+
+func (r *categoryRepository) Create(category *model.Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r *categoryRepository) Update(category *model.Category) error {
+	return r.db.Save(category).Error
+}
+
+func (r *categoryRepository) Delete(id uint) error {
+	return r.db.Delete(&model.Category{}, id).Error
+}
+
+func (r *categoryRepository) GetByID(id uint) (*model.Category, error) {
+	var category model.Category
+	if err := r.db.First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) GetBySlug(slug string) (*model.Category, error) {
+	var category model.Category
+	if err := r.db.Where("slug = ?", slug).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *categoryRepository) GetAll() ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.Order("name").Find(&categories).Error
+	return categories, err
+}
+
+// DescendantIDs returns id and the IDs of all categories below it.
+func (r *categoryRepository) DescendantIDs(id uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+		)
+		SELECT id FROM tree`, id).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *categoryRepository) CountChildren(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+// CountBooksByCategory returns the number of listings filed directly under
+// each category.
+func (r *categoryRepository) CountBooksByCategory() (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Count      int64
+	}
+	err := r.db.Model(&model.Book{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("category_id IS NOT NULL").
+		Group("category_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64)
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+	return counts, nil
+}
+
+// RenameBooks keeps the category name stored on each book in step with the
+// category and returns the IDs of the books it touched.
+func (r *categoryRepository) RenameBooks(categoryID uint, name string) ([]uint, error) {
+	var ids []uint
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Book{}).Where("category_id = ?", categoryID).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Book{}).Where("category_id = ?", categoryID).Updates(map[string]interface{}{
+			"category": name,
+			"version":  gorm.Expr("version + 1"),
+		}).Error
+	})
+	return ids, err
+}
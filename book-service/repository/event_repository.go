@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"book-service/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type EventRepository interface {
+	IsProcessed(eventID string) (bool, error)
+	MarkProcessed(event *model.ProcessedEvent) error
+}
+
+type eventRepository struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) EventRepository {
+	return &eventRepository{db: db}
+}
+
+func (r *eventRepository) IsProcessed(eventID string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&model.ProcessedEvent{}).Where("event_id = ?", eventID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// MarkProcessed records the event as handled. Recording an event twice,
+// as two deliveries handled at once would, keeps the first record.
+func (r *eventRepository) MarkProcessed(event *model.ProcessedEvent) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(event).Error
+}
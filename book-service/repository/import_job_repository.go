@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"book-service/model"
+
+	"gorm.io/gorm"
+)
+
+type ImportJobRepository interface {
+	Create(job *model.ImportJob) error
+	Update(job *model.ImportJob) error
+	GetByID(id uint) (*model.ImportJob, error)
+}
+
+type importJobRepository struct {
+	db *gorm.DB
+}
+
+func NewImportJobRepository(db *gorm.DB) ImportJobRepository {
+	return &importJobRepository{db: db}
+}
+
+func (r *importJobRepository) Create(job *model.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r *importJobRepository) Update(job *model.ImportJob) error {
+	return r.db.Save(job).Error
+}
+
+func (r *importJobRepository) GetByID(id uint) (*model.ImportJob, error) {
+	var job model.ImportJob
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"book-service/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrOfferChanged is returned when an offer was answered, expired or
+// redeemed by someone else between being read and being written.
+var ErrOfferChanged = errors.New("offer has changed")
+
+type OfferRepository interface {
+	Create(offer *model.Offer) error
+	GetByID(id uint) (*model.Offer, error)
+	GetByBuyerID(buyerID uint) ([]model.Offer, error)
+	GetBySellerID(sellerID uint) ([]model.Offer, error)
+	FindOpen(bookID uint, buyerID uint, now time.Time) (*model.Offer, error)
+	FindAccepted(bookID uint, buyerID uint, now time.Time) (*model.Offer, error)
+	Transition(offer *model.Offer, from string) error
+	ExpireOverdue(now time.Time) (int64, error)
+}
+
+type offerRepository struct {
+	db *gorm.DB
+}
+
+func NewOfferRepository(db *gorm.DB) OfferRepository {
+	return &offerRepository{db: db}
+}
+
+func (r *offerRepository) Create(offer *model.Offer) error {
+	return r.db.Create(offer).Error
+}
+
+func (r *offerRepository) GetByID(id uint) (*model.Offer, error) {
+	var offer model.Offer
+	err := r.db.First(&offer, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (r *offerRepository) GetByBuyerID(buyerID uint) ([]model.Offer, error) {
+	var offers []model.Offer
+	err := r.db.Where("buyer_id = ?", buyerID).Order("created_at DESC").Find(&offers).Error
+	return offers, err
+}
+
+func (r *offerRepository) GetBySellerID(sellerID uint) ([]model.Offer, error) {
+	var offers []model.Offer
+	err := r.db.Where("seller_id = ?", sellerID).Order("created_at DESC").Find(&offers).Error
+	return offers, err
+}
+
+// FindOpen returns the buyer's unexpired pending or countered offer on the
+// book.
+func (r *offerRepository) FindOpen(bookID uint, buyerID uint, now time.Time) (*model.Offer, error) {
+	return r.findLatest(bookID, buyerID, now, model.OfferPending, model.OfferCountered)
+}
+
+// FindAccepted returns the buyer's accepted offer on the book whose price
+// can still be used.
+func (r *offerRepository) FindAccepted(bookID uint, buyerID uint, now time.Time) (*model.Offer, error) {
+	return r.findLatest(bookID, buyerID, now, model.OfferAccepted)
+}
+
+func (r *offerRepository) findLatest(bookID uint, buyerID uint, now time.Time, statuses ...string) (*model.Offer, error) {
+	var offer model.Offer
+	err := r.db.Where("book_id = ? AND buyer_id = ? AND status IN ? AND expires_at > ?", bookID, buyerID, statuses, now).
+		Order("updated_at DESC").
+		First(&offer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &offer, nil
+}
+
+// Transition saves the offer only if its status is still from.
+// ErrOfferChanged is returned when another request changed it first.
+func (r *offerRepository) Transition(offer *model.Offer, from string) error {
+	result := r.db.Model(offer).Where("status = ?", from).Select("*").Omit("created_at").Updates(offer)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOfferChanged
+	}
+	return nil
+}
+
+// ExpireOverdue marks open and accepted offers whose deadline has passed
+// as expired.
+func (r *offerRepository) ExpireOverdue(now time.Time) (int64, error) {
+	result := r.db.Model(&model.Offer{}).
+		Where("status IN ? AND expires_at <= ?", []string{model.OfferPending, model.OfferCountered, model.OfferAccepted}, now).
+		Update("status", model.OfferExpired)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"book-service/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInsufficientStock   = errors.New("insufficient stock")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrBookNotAvailable    = errors.New("book is not available")
+)
+
+type ReservationRepository interface {
+	Reserve(bookID uint, transactionID uint, quantity int, expiresAt time.Time) (*model.Reservation, error)
+	Confirm(transactionID uint) ([]model.Reservation, []StockMovement, error)
+	Release(transactionID uint) error
+	ExpireOverdue(now time.Time) (int64, error)
+	SumActiveByBookIDs(bookIDs []uint) (map[uint]int, error)
+}
+
+type reservationRepository struct {
+	db *gorm.DB
+}
+
+func NewReservationRepository(db *gorm.DB) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+// Reserve locks the book row so concurrent reservations for the same book
+// are serialised, then checks the stock not already held by other active
+// reservations. Reserving again for the same transaction returns the
+// existing reservation.
+func (r *reservationRepository) Reserve(bookID uint, transactionID uint, quantity int, expiresAt time.Time) (*model.Reservation, error) {
+	var reservation model.Reservation
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var book model.Book
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&book, bookID).Error; err != nil {
+			return err
+		}
+		if book.Status != model.StatusActive {
+			return ErrBookNotAvailable
+		}
+
+		err := tx.Where("book_id = ? AND transaction_id = ? AND status = ?", bookID, transactionID, model.ReservationActive).
+			First(&reservation).Error
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		reserved, err := reservedByOthers(tx, bookID, transactionID)
+		if err != nil {
+			return err
+		}
+
+		if book.Stock-reserved < quantity {
+			return ErrInsufficientStock
+		}
+
+		reservation = model.Reservation{
+			BookID:        bookID,
+			TransactionID: transactionID,
+			Quantity:      quantity,
+			Status:        model.ReservationActive,
+			ExpiresAt:     expiresAt,
+		}
+		return tx.Create(&reservation).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Confirm turns the transaction's reservations into real stock deductions
+// in a single database transaction. Reservations that expired before the
+// payment arrived are still honoured as long as the stock is there and not
+// held for other buyers; otherwise ErrInsufficientStock is returned, so
+// the caller sends the payment back. Confirming again returns the
+// confirmed reservations without deducting twice, so callers can safely
+// retry; only the confirmation that deducted the stock returns how each
+// book moved.
+func (r *reservationRepository) Confirm(transactionID uint) ([]model.Reservation, []StockMovement, error) {
+	var reservations []model.Reservation
+	var movements []StockMovement
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("transaction_id = ? AND status IN ?", transactionID, []string{model.ReservationActive, model.ReservationExpired}).
+			Find(&reservations).Error
+		if err != nil {
+			return err
+		}
+		if len(reservations) == 0 {
+			err := tx.Where("transaction_id = ? AND status = ?", transactionID, model.ReservationConfirmed).
+				Find(&reservations).Error
+			if err != nil {
+				return err
+			}
+			if len(reservations) == 0 {
+				return ErrReservationNotFound
+			}
+			return nil
+		}
+
+		now := time.Now()
+		for i := range reservations {
+			var before model.Book
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&before, reservations[i].BookID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInsufficientStock
+			}
+			if err != nil {
+				return err
+			}
+
+			if reservations[i].Status != model.ReservationActive || !reservations[i].ExpiresAt.After(now) {
+				// Its copies are no longer held, and may since have been
+				// reserved by someone else
+				reserved, err := reservedByOthers(tx, reservations[i].BookID, transactionID)
+				if err != nil {
+					return err
+				}
+				if before.Stock-reserved < reservations[i].Quantity {
+					return ErrInsufficientStock
+				}
+			}
+
+			result := tx.Model(&model.Book{}).
+				Where("id = ? AND stock >= ?", reservations[i].BookID, reservations[i].Quantity).
+				Updates(deductStockUpdates(reservations[i].Quantity))
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrInsufficientStock
+			}
+
+			var after model.Book
+			if err := tx.First(&after, reservations[i].BookID).Error; err != nil {
+				return err
+			}
+			movements = append(movements, StockMovement{Before: before, After: after})
+
+			reservations[i].Status = model.ReservationConfirmed
+			if err := tx.Save(&reservations[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return reservations, movements, nil
+}
+
+// reservedByOthers sums the copies of a book held by other transactions'
+// active reservations.
+func reservedByOthers(tx *gorm.DB, bookID uint, transactionID uint) (int, error) {
+	var reserved int
+	err := tx.Model(&model.Reservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("book_id = ? AND transaction_id <> ? AND status = ? AND expires_at > ?", bookID, transactionID, model.ReservationActive, time.Now()).
+		Scan(&reserved).Error
+	return reserved, err
+}
+
+func (r *reservationRepository) Release(transactionID uint) error {
+	result := r.db.Model(&model.Reservation{}).
+		Where("transaction_id = ? AND status IN ?", transactionID, []string{model.ReservationActive, model.ReservationExpired}).
+		Update("status", model.ReservationReleased)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReservationNotFound
+	}
+	return nil
+}
+
+func (r *reservationRepository) ExpireOverdue(now time.Time) (int64, error) {
+	result := r.db.Model(&model.Reservation{}).
+		Where("status = ? AND expires_at <= ?", model.ReservationActive, now).
+		Update("status", model.ReservationExpired)
+	return result.RowsAffected, result.Error
+}
+
+func (r *reservationRepository) SumActiveByBookIDs(bookIDs []uint) (map[uint]int, error) {
+	sums := make(map[uint]int)
+	if len(bookIDs) == 0 {
+		return sums, nil
+	}
+
+	var rows []struct {
+		BookID   uint
+		Quantity int
+	}
+	err := r.db.Model(&model.Reservation{}).
+		Select("book_id, SUM(quantity) AS quantity").
+		Where("book_id IN ? AND status = ? AND expires_at > ?", bookIDs, model.ReservationActive, time.Now()).
+		Group("book_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		sums[row.BookID] = row.Quantity
+	}
+	return sums, nil
+}
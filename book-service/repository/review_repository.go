@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"book-service/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlreadyReviewed = errors.New("transaction already reviewed")
+	ErrAlreadyReported = errors.New("review already reported")
+)
+
+type ReviewRepository interface {
+	Create(review *model.Review) error
+	Update(review *model.Review) error
+	GetByID(id uint) (*model.Review, error)
+	GetByBookID(bookID uint) ([]model.Review, error)
+	GetBySellerID(sellerID uint, limit int) ([]model.Review, error)
+	GetReported() ([]model.Review, error)
+	SummaryBySellerIDs(sellerIDs []uint) (map[uint]model.RatingSummary, error)
+	SummaryByBookIDs(bookIDs []uint) (map[uint]model.RatingSummary, error)
+	Report(report *model.ReviewReport, hideThreshold int) error
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+func (r *reviewRepository) Create(review *model.Review) error {
+	err := r.db.Create(review).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrAlreadyReviewed
+	}
+	return err
+}
+
+func (r *reviewRepository) Update(review *model.Review) error {
+	return r.db.Save(review).Error
+}
+
+func (r *reviewRepository) GetByID(id uint) (*model.Review, error) {
+	var review model.Review
+	if err := r.db.First(&review, id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) GetByBookID(bookID uint) ([]model.Review, error) {
+	var reviews []model.Review
+	err := r.db.Where("book_id = ? AND hidden = ?", bookID, false).Order("created_at DESC").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *reviewRepository) GetBySellerID(sellerID uint, limit int) ([]model.Review, error) {
+	var reviews []model.Review
+	err := r.db.Where("seller_id = ? AND hidden = ?", sellerID, false).Order("created_at DESC").Limit(limit).Find(&reviews).Error
+	return reviews, err
+}
+
+// GetReported returns every review with at least one report, most
+// reported first.
+func (r *reviewRepository) GetReported() ([]model.Review, error) {
+	var reviews []model.Review
+	err := r.db.Where("report_count > 0").Order("report_count DESC, created_at DESC").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *reviewRepository) SummaryBySellerIDs(sellerIDs []uint) (map[uint]model.RatingSummary, error) {
+	return r.summarise("seller_id", "rating", sellerIDs)
+}
+
+// SummaryByBookIDs only counts reviews that rated the book itself.
+func (r *reviewRepository) SummaryByBookIDs(bookIDs []uint) (map[uint]model.RatingSummary, error) {
+	return r.summarise("book_id", "book_rating", bookIDs)
+}
+
+// summarise averages ratingColumn over the visible reviews of each ID in
+// groupColumn. Column names are fixed by the callers above.
+func (r *reviewRepository) summarise(groupColumn string, ratingColumn string, ids []uint) (map[uint]model.RatingSummary, error) {
+	summaries := make(map[uint]model.RatingSummary)
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+
+	var rows []struct {
+		ID      uint
+		Average float64
+		Count   int64
+	}
+	err := r.db.Model(&model.Review{}).
+		Select(groupColumn+" AS id, AVG("+ratingColumn+") AS average, COUNT(*) AS count").
+		Where(groupColumn+" IN ? AND hidden = ? AND "+ratingColumn+" IS NOT NULL", ids, false).
+		Group(groupColumn).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		summaries[row.ID] = model.RatingSummary{Average: row.Average, Count: row.Count}
+	}
+	return summaries, nil
+}
+
+// Report records the report and bumps the review's report count, hiding
+// the review once it reaches hideThreshold reports. A user can report a
+// review only once.
+func (r *reviewRepository) Report(report *model.ReviewReport, hideThreshold int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrAlreadyReported
+			}
+			return err
+		}
+
+		return tx.Model(&model.Review{}).Where("id = ?", report.ReviewID).Updates(map[string]interface{}{
+			"report_count": gorm.Expr("report_count + 1"),
+			"hidden":       gorm.Expr("hidden OR report_count + 1 >= ?", hideThreshold),
+		}).Error
+	})
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"book-service/model"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StockItemError reports which book of a batch stock operation failed.
+// Err is gorm.ErrRecordNotFound or ErrInsufficientStock.
+type StockItemError struct {
+	BookID uint
+	Err    error
+}
+
+func (e *StockItemError) Error() string {
+	return fmt.Sprintf("book %d: %v", e.BookID, e.Err)
+}
+
+func (e *StockItemError) Unwrap() error {
+	return e.Err
+}
+
+// StockMovement is a book before and after its stock changed, in a batch
+// stock operation or by confirming a reservation.
+type StockMovement struct {
+	Before model.Book
+	After  model.Book
+}
+
+type StockRepository interface {
+	Apply(op *model.StockOperation) ([]StockMovement, error)
+	GetByKey(key string) (*model.StockOperation, error)
+}
+
+type stockRepository struct {
+	db *gorm.DB
+}
+
+func NewStockRepository(db *gorm.DB) StockRepository {
+	return &stockRepository{db: db}
+}
+
+// Apply records op and changes the stock of every book in it within one
+// transaction, so either all items are applied or none are. Items are
+// expected in book ID order so concurrent batches lock rows in the same
+// order. gorm.ErrDuplicatedKey is returned when the idempotency key has
+// already been used.
+//
+// A deduction only takes stock not held by active reservations, and marks
+// a listing sold_out when the last copy goes. A restock also reaches
+// deleted books, so a sale can be compensated after the seller removed the
+// listing, and reactivates a sold-out listing.
+func (r *stockRepository) Apply(op *model.StockOperation) ([]StockMovement, error) {
+	var movements []StockMovement
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(op).Error; err != nil {
+			return err
+		}
+
+		movements = make([]StockMovement, 0, len(op.Items))
+		for _, item := range op.Items {
+			query := tx.Clauses(clause.Locking{Strength: "UPDATE"})
+			if op.Kind == model.StockRestock {
+				query = query.Unscoped()
+			}
+
+			var book model.Book
+			if err := query.First(&book, item.BookID).Error; err != nil {
+				return &StockItemError{BookID: item.BookID, Err: err}
+			}
+
+			before := book
+			if op.Kind == model.StockDeduct {
+				var reserved int
+				err := tx.Model(&model.Reservation{}).
+					Select("COALESCE(SUM(quantity), 0)").
+					Where("book_id = ? AND status = ? AND expires_at > ?", book.ID, model.ReservationActive, time.Now()).
+					Scan(&reserved).Error
+				if err != nil {
+					return err
+				}
+				if book.Stock-reserved < item.Quantity {
+					return &StockItemError{BookID: item.BookID, Err: ErrInsufficientStock}
+				}
+				book.Stock -= item.Quantity
+			} else {
+				book.Stock += item.Quantity
+			}
+			book.Status = model.StockStatus(book.Status, book.Stock)
+			book.Version++
+
+			err := tx.Unscoped().Model(&model.Book{}).Where("id = ?", book.ID).Updates(map[string]interface{}{
+				"stock":   book.Stock,
+				"status":  book.Status,
+				"version": book.Version,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			movements = append(movements, StockMovement{Before: before, After: book})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
+
+func (r *stockRepository) GetByKey(key string) (*model.StockOperation, error) {
+	var op model.StockOperation
+	if err := r.db.Where("idempotency_key = ?", key).First(&op).Error; err != nil {
+		return nil, err
+	}
+	return &op, nil
+}
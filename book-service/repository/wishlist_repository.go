@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"book-service/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrAlreadyWishlisted = errors.New("book already in wishlist")
+
+type WishlistRepository interface {
+	Add(item *model.WishlistItem) error
+	Remove(userID uint, bookID uint) error
+	GetByUserID(userID uint) ([]model.WishlistItem, error)
+	UserIDsByBookID(bookID uint) ([]uint, error)
+	LastAlertAt(userID uint, bookID uint) (*time.Time, error)
+	CountAlertsSince(userID uint, since time.Time) (int64, error)
+	RecordAlert(alert *model.WishlistAlert) error
+}
+
+type wishlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWishlistRepository(db *gorm.DB) WishlistRepository {
+	return &wishlistRepository{db: db}
+}
+
+func (r *wishlistRepository) Add(item *model.WishlistItem) error {
+	err := r.db.Create(item).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrAlreadyWishlisted
+	}
+	return err
+}
+
+func (r *wishlistRepository) Remove(userID uint, bookID uint) error {
+	result := r.db.Where("user_id = ? AND book_id = ?", userID, bookID).Delete(&model.WishlistItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *wishlistRepository) GetByUserID(userID uint) ([]model.WishlistItem, error) {
+	var items []model.WishlistItem
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&items).Error
+	return items, err
+}
+
+func (r *wishlistRepository) UserIDsByBookID(bookID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&model.WishlistItem{}).Where("book_id = ?", bookID).Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// LastAlertAt returns when the user was last alerted about the book, or nil
+// if they never were.
+func (r *wishlistRepository) LastAlertAt(userID uint, bookID uint) (*time.Time, error) {
+	var alert model.WishlistAlert
+	err := r.db.Where("user_id = ? AND book_id = ?", userID, bookID).Order("created_at DESC").First(&alert).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert.CreatedAt, nil
+}
+
+func (r *wishlistRepository) CountAlertsSince(userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.WishlistAlert{}).Where("user_id = ? AND created_at >= ?", userID, since).Count(&count).Error
+	return count, err
+}
+
+func (r *wishlistRepository) RecordAlert(alert *model.WishlistAlert) error {
+	return r.db.Create(alert).Error
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"book-service/clients"
+	"book-service/model"
+	"book-service/repository"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// maxAnalyticsRange caps how many days one analytics request may cover.
+const maxAnalyticsRange = 366
+
+type AnalyticsService interface {
+	RecordView(bookID uint, sellerID uint, viewerID uint, clientKey string)
+	RecordWishlistAdd(bookID uint)
+	SyncSales() error
+	PurgeViews() (int64, error)
+	GetSellerAnalytics(sellerID uint, from time.Time, to time.Time, period string) (*model.SellerAnalytics, error)
+}
+
+type analyticsService struct {
+	analyticsRepo     repository.AnalyticsRepository
+	transactionClient clients.TransactionClient
+	salesLookback     time.Duration
+	now               func() time.Time
+}
+
+// NewAnalyticsService creates the seller analytics service. SyncSales
+// refreshes the sales of the last salesLookback, so transactions that
+// complete late are still counted on the day they were made.
+func NewAnalyticsService(analyticsRepo repository.AnalyticsRepository, transactionClient clients.TransactionClient, salesLookback time.Duration) AnalyticsService {
+	return &analyticsService{
+		analyticsRepo:     analyticsRepo,
+		transactionClient: transactionClient,
+		salesLookback:     salesLookback,
+		now:               time.Now,
+	}
+}
+
+// RecordView counts a view of a book, once per viewer per day. Signed-in
+// viewers are told apart by user ID and anonymous ones by clientKey, which
+// is hashed rather than stored. Sellers viewing their own books are not
+// counted. Analytics must never break browsing, so failures are logged.
+func (s *analyticsService) RecordView(bookID uint, sellerID uint, viewerID uint, clientKey string) {
+	if viewerID != 0 && viewerID == sellerID {
+		return
+	}
+
+	viewerKey := fmt.Sprintf("user:%d", viewerID)
+	if viewerID == 0 {
+		sum := sha256.Sum256([]byte(clientKey))
+		viewerKey = "anon:" + hex.EncodeToString(sum[:16])
+	}
+
+	day := s.today()
+	first, err := s.analyticsRepo.RecordView(&model.BookView{BookID: bookID, ViewerKey: viewerKey, Day: day})
+	if err != nil {
+		log.Printf("Failed to record view of book %d: %v", bookID, err)
+		return
+	}
+	if !first {
+		return
+	}
+
+	if err := s.analyticsRepo.Increment(bookID, day, "views"); err != nil {
+		log.Printf("Failed to count view of book %d: %v", bookID, err)
+	}
+}
+
+func (s *analyticsService) RecordWishlistAdd(bookID uint) {
+	if err := s.analyticsRepo.Increment(bookID, s.today(), "wishlist_adds"); err != nil {
+		log.Printf("Failed to count wishlist add of book %d: %v", bookID, err)
+	}
+}
+
+// SyncSales copies the recent daily sales from transaction-service into the
+// rollups.
+func (s *analyticsService) SyncSales() error {
+	to := s.today()
+	from := to.Add(-s.salesLookback).Truncate(24 * time.Hour)
+
+	sales, err := s.transactionClient.GetDailySales(from, to)
+	if err != nil {
+		return err
+	}
+
+	stats := make([]model.BookDailyStat, 0, len(sales))
+	for _, sale := range sales {
+		stats = append(stats, model.BookDailyStat{
+			BookID:  sale.BookID,
+			Day:     sale.Day.UTC().Truncate(24 * time.Hour),
+			Sales:   sale.Sales,
+			Revenue: sale.Revenue,
+		})
+	}
+	return s.analyticsRepo.ReplaceSales(from, to, stats)
+}
+
+// PurgeViews drops the per-viewer view records of earlier days.
+func (s *analyticsService) PurgeViews() (int64, error) {
+	return s.analyticsRepo.PurgeViews(s.today())
+}
+
+// GetSellerAnalytics sums the rollups of the seller's books for the days
+// from through to, per book and per period. Every period in the range is
+// listed, including those without activity.
+func (s *analyticsService) GetSellerAnalytics(sellerID uint, from time.Time, to time.Time, period string) (*model.SellerAnalytics, error) {
+	if period != model.PeriodDay && period != model.PeriodWeek && period != model.PeriodMonth {
+		return nil, errors.New("invalid period")
+	}
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour)
+	if to.Before(from) {
+		return nil, errors.New("invalid date range")
+	}
+	if to.Sub(from) >= maxAnalyticsRange*24*time.Hour {
+		return nil, errors.New("date range too long")
+	}
+
+	stats, err := s.analyticsRepo.GetSellerStats(sellerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.SellerAnalytics{
+		From:    from,
+		To:      to,
+		Period:  period,
+		Books:   []model.BookAnalytics{},
+		Periods: []model.PeriodAnalytics{},
+	}
+
+	periodIndex := make(map[time.Time]int)
+	for start := periodStart(from, period); !start.After(to); start = nextPeriod(start, period) {
+		periodIndex[start] = len(result.Periods)
+		result.Periods = append(result.Periods, model.PeriodAnalytics{PeriodStart: start})
+	}
+
+	bookIndex := make(map[uint]int)
+	for _, stat := range stats {
+		i, ok := bookIndex[stat.BookID]
+		if !ok {
+			i = len(result.Books)
+			bookIndex[stat.BookID] = i
+			result.Books = append(result.Books, model.BookAnalytics{BookID: stat.BookID, Name: stat.BookName})
+		}
+		addStats(&result.Books[i].AnalyticsStats, stat)
+		addStats(&result.Periods[periodIndex[periodStart(stat.Day, period)]].AnalyticsStats, stat)
+		addStats(&result.Totals, stat)
+	}
+
+	for i := range result.Books {
+		setConversionRate(&result.Books[i].AnalyticsStats)
+	}
+	for i := range result.Periods {
+		setConversionRate(&result.Periods[i].AnalyticsStats)
+	}
+	setConversionRate(&result.Totals)
+
+	sort.SliceStable(result.Books, func(i, j int) bool {
+		if result.Books[i].Views != result.Books[j].Views {
+			return result.Books[i].Views > result.Books[j].Views
+		}
+		return result.Books[i].BookID < result.Books[j].BookID
+	})
+	return result, nil
+}
+
+func (s *analyticsService) today() time.Time {
+	return s.now().UTC().Truncate(24 * time.Hour)
+}
+
+func addStats(total *model.AnalyticsStats, stat model.BookDailyStat) {
+	total.Views += stat.Views
+	total.WishlistAdds += stat.WishlistAdds
+	total.Sales += stat.Sales
+	total.Revenue += stat.Revenue
+}
+
+// setConversionRate is the share of views that led to a sale.
+func setConversionRate(stats *model.AnalyticsStats) {
+	if stats.Views == 0 {
+		stats.ConversionRate = 0
+		return
+	}
+	stats.ConversionRate = float64(stats.Sales) / float64(stats.Views)
+}
+
+// periodStart returns the first day of the period day falls in. Weeks
+// start on Monday.
+func periodStart(day time.Time, period string) time.Time {
+	day = day.UTC().Truncate(24 * time.Hour)
+	switch period {
+	case model.PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case model.PeriodMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextPeriod(start time.Time, period string) time.Time {
+	switch period {
+	case model.PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case model.PeriodMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"book-service/clients"
+	"book-service/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAnalyticsRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsRepository) RecordView(view *model.BookView) (bool, error) {
+	args := m.Called(view)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) Increment(bookID uint, day time.Time, column string) error {
+	args := m.Called(bookID, day, column)
+	return args.Error(0)
+}
+
+func (m *MockAnalyticsRepository) ReplaceSales(from time.Time, to time.Time, stats []model.BookDailyStat) error {
+	args := m.Called(from, to, stats)
+	return args.Error(0)
+}
+
+func (m *MockAnalyticsRepository) PurgeViews(before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAnalyticsRepository) GetSellerStats(sellerID uint, from time.Time, to time.Time) ([]model.BookDailyStat, error) {
+	args := m.Called(sellerID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.BookDailyStat), args.Error(1)
+}
+
+func newTestAnalyticsService(analyticsRepo *MockAnalyticsRepository, client *MockTransactionClient, now time.Time) *analyticsService {
+	s := NewAnalyticsService(analyticsRepo, client, 72*time.Hour).(*analyticsService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestRecordView_CountsFirstViewOfTheDay(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	service := newTestAnalyticsService(mockRepo, nil, time.Date(2024, 5, 31, 15, 4, 0, 0, time.UTC))
+
+	mockRepo.On("RecordView", &model.BookView{BookID: 4, ViewerKey: "user:7", Day: date(2024, 5, 31)}).Return(true, nil)
+	mockRepo.On("Increment", uint(4), date(2024, 5, 31), "views").Return(nil)
+
+	service.RecordView(4, 1, 7, "10.0.0.1|curl")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRecordView_RepeatViewNotCounted(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	service := newTestAnalyticsService(mockRepo, nil, time.Now())
+
+	mockRepo.On("RecordView", mock.Anything).Return(false, nil)
+
+	service.RecordView(4, 1, 7, "")
+
+	mockRepo.AssertNotCalled(t, "Increment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRecordView_AnonymousViewerKeyIsHashed(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	service := newTestAnalyticsService(mockRepo, nil, time.Now())
+
+	mockRepo.On("RecordView", mock.MatchedBy(func(v *model.BookView) bool {
+		return strings.HasPrefix(v.ViewerKey, "anon:") && !strings.Contains(v.ViewerKey, "10.0.0.1")
+	})).Return(true, nil)
+	mockRepo.On("Increment", uint(4), mock.Anything, "views").Return(nil)
+
+	service.RecordView(4, 1, 0, "10.0.0.1|curl")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRecordView_SellerViewingOwnBookNotCounted(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	service := newTestAnalyticsService(mockRepo, nil, time.Now())
+
+	service.RecordView(4, 1, 1, "")
+
+	mockRepo.AssertNotCalled(t, "RecordView", mock.Anything)
+}
+
+func TestSyncSales_ReplacesLookbackWindow(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	mockClient := new(MockTransactionClient)
+	service := newTestAnalyticsService(mockRepo, mockClient, time.Date(2024, 5, 31, 9, 0, 0, 0, time.UTC))
+
+	mockClient.On("GetDailySales", date(2024, 5, 28), date(2024, 5, 31)).Return([]clients.DailySales{
+		{BookID: 4, Day: date(2024, 5, 29), Sales: 2, Revenue: 90000},
+	}, nil)
+	mockRepo.On("ReplaceSales", date(2024, 5, 28), date(2024, 5, 31), []model.BookDailyStat{
+		{BookID: 4, Day: date(2024, 5, 29), Sales: 2, Revenue: 90000},
+	}).Return(nil)
+
+	err := service.SyncSales()
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetSellerAnalytics_GroupsByWeek(t *testing.T) {
+	mockRepo := new(MockAnalyticsRepository)
+	service := newTestAnalyticsService(mockRepo, nil, time.Now())
+
+	// 2024-05-01 is a Wednesday, so the first week starts on 2024-04-29.
+	from, to := date(2024, 5, 1), date(2024, 5, 14)
+	mockRepo.On("GetSellerStats", uint(1), from, to).Return([]model.BookDailyStat{
+		{BookID: 4, BookName: "Dune", Day: date(2024, 5, 2), Views: 10, Sales: 1, Revenue: 50000},
+		{BookID: 5, BookName: "Emma", Day: date(2024, 5, 3), Views: 20, WishlistAdds: 2},
+		{BookID: 4, BookName: "Dune", Day: date(2024, 5, 7), Views: 10, Sales: 3, Revenue: 150000},
+	}, nil)
+
+	analytics, err := service.GetSellerAnalytics(1, from, to, model.PeriodWeek)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.AnalyticsStats{Views: 40, WishlistAdds: 2, Sales: 4, Revenue: 200000, ConversionRate: 0.1}, analytics.Totals)
+
+	assert.Len(t, analytics.Books, 2)
+	assert.Equal(t, uint(4), analytics.Books[0].BookID)
+	assert.Equal(t, "Dune", analytics.Books[0].Name)
+	assert.Equal(t, 0.2, analytics.Books[0].ConversionRate)
+	assert.Equal(t, uint(5), analytics.Books[1].BookID)
+	assert.Equal(t, float64(0), analytics.Books[1].ConversionRate)
+
+	assert.Len(t, analytics.Periods, 3)
+	assert.Equal(t, date(2024, 4, 29), analytics.Periods[0].PeriodStart)
+	assert.Equal(t, int64(30), analytics.Periods[0].Views)
+	assert.Equal(t, date(2024, 5, 6), analytics.Periods[1].PeriodStart)
+	assert.Equal(t, int64(3), analytics.Periods[1].Sales)
+	assert.Equal(t, date(2024, 5, 13), analytics.Periods[2].PeriodStart)
+	assert.Equal(t, int64(0), analytics.Periods[2].Views)
+}
+
+func TestGetSellerAnalytics_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to time.Time
+		period   string
+		wantErr  string
+	}{
+		{"unknown period", date(2024, 5, 1), date(2024, 5, 2), "year", "invalid period"},
+		{"from after to", date(2024, 5, 2), date(2024, 5, 1), model.PeriodDay, "invalid date range"},
+		{"range too long", date(2023, 1, 1), date(2024, 5, 1), model.PeriodMonth, "date range too long"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockAnalyticsRepository)
+			service := newTestAnalyticsService(mockRepo, nil, time.Now())
+
+			analytics, err := service.GetSellerAnalytics(1, tt.from, tt.to, tt.period)
+
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, analytics)
+			mockRepo.AssertNotCalled(t, "GetSellerStats", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
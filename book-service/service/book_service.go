@@ -1,34 +1,77 @@
 package service
 
 import (
+	"book-service/helpers"
 	"book-service/model"
+	"book-service/policy"
 	"book-service/repository"
 	"errors"
+	"log"
+	"strconv"
 
 	"gorm.io/gorm"
 )
 
 type BookService interface {
 	CreateBook(req *model.CreateBookRequest, sellerID uint) (*model.BookResponse, error)
-	GetAllBooks(category string) ([]model.BookResponse, error)
-	GetBookByID(id uint) (*model.BookResponse, error)
-	GetBooksBySellerID(sellerID uint) ([]model.BookResponse, error)
-	UpdateBook(id uint, req *model.UpdateBookRequest, sellerID uint) (*model.BookResponse, error)
-	DeleteBook(id uint, sellerID uint) error
-	DeductStock(id uint, amount int) (*model.BookResponse, error)
+	GetAllBooks(category string, viewerID uint) ([]model.BookResponse, error)
+	GetBookByID(id uint, viewerID uint) (*model.BookResponse, error)
+	GetBooksByIDs(ids []uint, actor policy.Actor) (*model.BookBatch, error)
+	GetBooksBySellerID(sellerID uint, status string) ([]model.BookResponse, error)
+	UpdateBook(id uint, req *model.UpdateBookRequest, actor policy.Actor, expectedVersion *uint) (*model.BookResponse, error)
+	DeleteBook(id uint, actor policy.Actor, expectedVersion *uint) error
+	DeductStock(id uint, amount int, actorID uint) (*model.BookResponse, error)
+	GetBookHistory(id uint, actor policy.Actor) ([]model.BookChange, error)
 }
 
+// statusTransitions lists the status changes a seller may make. sold_out is
+// never requested directly: it is set when stock runs out and cleared when
+// stock is added back.
+var statusTransitions = map[string][]string{
+	model.StatusDraft:    {model.StatusActive, model.StatusArchived},
+	model.StatusActive:   {model.StatusPaused, model.StatusArchived},
+	model.StatusPaused:   {model.StatusActive, model.StatusArchived},
+	model.StatusSoldOut:  {model.StatusArchived},
+	model.StatusArchived: {model.StatusDraft},
+}
+
+// maxUpdateAttempts bounds how often UpdateBook retries after losing a race
+// with a concurrent write.
+const maxUpdateAttempts = 3
+
 type bookService struct {
-	bookRepo repository.BookRepository
+	bookRepo        repository.BookRepository
+	reservationRepo repository.ReservationRepository
+	categoryRepo    repository.CategoryRepository
+	reviewRepo      repository.ReviewRepository
+	historyRepo     repository.BookHistoryRepository
+	listener        BookChangeListener
 }
 
-func NewBookService(bookRepo repository.BookRepository) BookService {
+// NewBookService creates the book service. listener may be nil; when set it
+// is told about every successful update.
+func NewBookService(bookRepo repository.BookRepository, reservationRepo repository.ReservationRepository, categoryRepo repository.CategoryRepository, reviewRepo repository.ReviewRepository, historyRepo repository.BookHistoryRepository, listener BookChangeListener) BookService {
 	return &bookService{
-		bookRepo: bookRepo,
+		bookRepo:        bookRepo,
+		reservationRepo: reservationRepo,
+		categoryRepo:    categoryRepo,
+		reviewRepo:      reviewRepo,
+		historyRepo:     historyRepo,
+		listener:        listener,
 	}
 }
 
 func (s *bookService) CreateBook(req *model.CreateBookRequest, sellerID uint) (*model.BookResponse, error) {
+	categoryID, category, err := resolveCategory(s.categoryRepo, req.CategoryID, req.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	status := req.Status
+	if status == "" {
+		status = model.StatusActive
+	}
+
 	book := &model.Book{
 		SellerID:    sellerID,
 		Name:        req.Name,
@@ -36,10 +79,12 @@ func (s *bookService) CreateBook(req *model.CreateBookRequest, sellerID uint) (*
 		Author:      req.Author,
 		Stock:       req.Stock,
 		Costs:       req.Costs,
-		Category:    req.Category,
+		Category:    category,
+		CategoryID:  categoryID,
+		Status:      model.StockStatus(status, req.Stock),
 	}
 
-	err := s.bookRepo.Create(book)
+	err = s.bookRepo.Create(book)
 	if err != nil {
 		return nil, err
 	}
@@ -48,8 +93,20 @@ func (s *bookService) CreateBook(req *model.CreateBookRequest, sellerID uint) (*
 	return &response, nil
 }
 
-func (s *bookService) GetAllBooks(category string) ([]model.BookResponse, error) {
-	books, err := s.bookRepo.GetAll(category)
+// GetAllBooks lists active books, optionally filtered by category. The
+// category may be given as an ID, a slug or a name; a managed category also
+// matches the listings of all its subcategories. A signed-in seller also
+// sees their own listings that are not active, as GetBookByID would show
+// them; anonymous viewers have ID 0.
+func (s *bookService) GetAllBooks(category string, viewerID uint) ([]model.BookResponse, error) {
+	filter, err := s.categoryFilter(category)
+	if err != nil {
+		return nil, err
+	}
+	filter.Status = model.StatusActive
+	filter.OwnerID = viewerID
+
+	books, err := s.bookRepo.GetAll(filter)
 	if err != nil {
 		return nil, err
 	}
@@ -59,10 +116,12 @@ func (s *bookService) GetAllBooks(category string) ([]model.BookResponse, error)
 		responses = append(responses, book.ToResponse())
 	}
 
-	return responses, nil
+	return s.enrich(responses)
 }
 
-func (s *bookService) GetBookByID(id uint) (*model.BookResponse, error) {
+// GetBookByID returns a book. Active and sold-out listings are visible to
+// everyone; drafts, paused and archived listings only to their seller.
+func (s *bookService) GetBookByID(id uint, viewerID uint) (*model.BookResponse, error) {
 	book, err := s.bookRepo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -71,11 +130,66 @@ func (s *bookService) GetBookByID(id uint) (*model.BookResponse, error) {
 		return nil, err
 	}
 
-	response := book.ToResponse()
-	return &response, nil
+	if !publiclyVisible(book) && book.SellerID != viewerID {
+		return nil, errors.New("book not found")
+	}
+
+	responses, err := s.enrich([]model.BookResponse{book.ToResponse()})
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
 }
 
-func (s *bookService) GetBooksBySellerID(sellerID uint) ([]model.BookResponse, error) {
+// GetBooksByIDs looks up many books at once, in the order asked for, and
+// lists the IDs it could not return. Internal services see every listing;
+// other callers only what GetBookByID would show them.
+func (s *bookService) GetBooksByIDs(ids []uint, actor policy.Actor) (*model.BookBatch, error) {
+	ids = uniqueIDs(ids)
+	if len(ids) > model.MaxBookBatchSize {
+		return nil, errors.New("too many book IDs")
+	}
+
+	books, err := s.bookRepo.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[uint]model.Book, len(books))
+	for _, book := range books {
+		if actor.IsService() || publiclyVisible(&book) || (actor.UserID != 0 && book.SellerID == actor.UserID) {
+			found[book.ID] = book
+		}
+	}
+
+	batch := &model.BookBatch{MissingIDs: []uint{}}
+	var responses []model.BookResponse
+	for _, id := range ids {
+		book, ok := found[id]
+		if !ok {
+			batch.MissingIDs = append(batch.MissingIDs, id)
+			continue
+		}
+		responses = append(responses, book.ToResponse())
+	}
+
+	batch.Books, err = s.enrich(responses)
+	if err != nil {
+		return nil, err
+	}
+	if batch.Books == nil {
+		batch.Books = []model.BookResponse{}
+	}
+	return batch, nil
+}
+
+// GetBooksBySellerID returns all of a seller's books, or only those with
+// the given status.
+func (s *bookService) GetBooksBySellerID(sellerID uint, status string) ([]model.BookResponse, error) {
+	if _, ok := statusTransitions[status]; status != "" && !ok {
+		return nil, errors.New("invalid status")
+	}
+
 	books, err := s.bookRepo.GetBySellerID(sellerID)
 	if err != nil {
 		return nil, err
@@ -83,25 +197,121 @@ func (s *bookService) GetBooksBySellerID(sellerID uint) ([]model.BookResponse, e
 
 	var responses []model.BookResponse
 	for _, book := range books {
+		if status != "" && book.Status != status {
+			continue
+		}
 		responses = append(responses, book.ToResponse())
 	}
 
-	return responses, nil
+	return s.enrich(responses)
 }
 
-func (s *bookService) UpdateBook(id uint, req *model.UpdateBookRequest, sellerID uint) (*model.BookResponse, error) {
-	book, err := s.bookRepo.GetByID(id)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("book not found")
+// UpdateBook applies req to the book. When expectedVersion is set (from an
+// If-Match header) the update fails if the book changed since the client
+// read it. Without it, an update that loses a race with another write is
+// retried against the latest version instead of overwriting it, unless it
+// sets the stock: that would undo the sale the other write may have
+// deducted, so it fails with ErrVersionConflict for the seller to look
+// again.
+func (s *bookService) UpdateBook(id uint, req *model.UpdateBookRequest, actor policy.Actor, expectedVersion *uint) (*model.BookResponse, error) {
+	var categoryID *uint
+	var category string
+	categoryChanged := req.CategoryID != nil || req.Category != nil
+	if categoryChanged {
+		name := ""
+		if req.Category != nil {
+			name = *req.Category
 		}
-		return nil, err
+
+		var err error
+		categoryID, category, err = resolveCategory(s.categoryRepo, req.CategoryID, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		book, err := s.bookRepo.GetByID(id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.New("book not found")
+			}
+			return nil, err
+		}
+
+		if !policy.CanManageBook(actor, book.SellerID) {
+			return nil, errors.New("unauthorized: you can only update your own books")
+		}
+
+		if expectedVersion != nil && book.Version != *expectedVersion {
+			return nil, repository.ErrVersionConflict
+		}
+
+		before := *book
+		applyBookUpdate(book, req)
+		if categoryChanged {
+			book.Category = category
+			book.CategoryID = categoryID
+		}
+
+		status := book.Status
+		if req.Status != nil && *req.Status != book.Status {
+			if !canTransition(book.Status, *req.Status) {
+				return nil, errors.New("invalid status transition")
+			}
+			status = *req.Status
+		}
+		book.Status = model.StockStatus(status, book.Stock)
+
+		err = s.bookRepo.Update(book)
+		if errors.Is(err, repository.ErrVersionConflict) && expectedVersion == nil && req.Stock == nil && attempt < maxUpdateAttempts {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if fields := bookChanges(before, *book); len(fields) > 0 {
+			recordChange(s.historyRepo, book.ID, actor.UserID, model.ChangeUpdated, fields)
+		}
+		if s.listener != nil {
+			s.listener.BookChanged(before, *book)
+		}
+
+		response := book.ToResponse()
+		return &response, nil
 	}
+}
+
+func canTransition(from string, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
 
-	if book.SellerID != sellerID {
-		return nil, errors.New("unauthorized: you can only update your own books")
+// publiclyVisible reports whether anyone may see the listing. Drafts,
+// paused and archived listings are only shown to their seller.
+func publiclyVisible(book *model.Book) bool {
+	return book.Status == model.StatusActive || book.Status == model.StatusSoldOut
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence.
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
 	}
+	return unique
+}
 
+func applyBookUpdate(book *model.Book, req *model.UpdateBookRequest) {
 	if req.Name != nil {
 		book.Name = *req.Name
 	}
@@ -117,20 +327,9 @@ func (s *bookService) UpdateBook(id uint, req *model.UpdateBookRequest, sellerID
 	if req.Costs != nil {
 		book.Costs = *req.Costs
 	}
-	if req.Category != nil {
-		book.Category = *req.Category
-	}
-
-	err = s.bookRepo.Update(book)
-	if err != nil {
-		return nil, err
-	}
-
-	response := book.ToResponse()
-	return &response, nil
 }
 
-func (s *bookService) DeleteBook(id uint, sellerID uint) error {
+func (s *bookService) DeleteBook(id uint, actor policy.Actor, expectedVersion *uint) error {
 	book, err := s.bookRepo.GetByID(id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -139,14 +338,23 @@ func (s *bookService) DeleteBook(id uint, sellerID uint) error {
 		return err
 	}
 
-	if book.SellerID != sellerID {
+	if !policy.CanManageBook(actor, book.SellerID) {
 		return errors.New("unauthorized: you can only delete your own books")
 	}
 
-	return s.bookRepo.Delete(id, sellerID)
+	if expectedVersion != nil && book.Version != *expectedVersion {
+		return repository.ErrVersionConflict
+	}
+
+	if err := s.bookRepo.Delete(id, book.SellerID, book.Version); err != nil {
+		return err
+	}
+
+	recordChange(s.historyRepo, id, actor.UserID, model.ChangeDeleted, nil)
+	return nil
 }
 
-func (s *bookService) DeductStock(id uint, amount int) (*model.BookResponse, error) {
+func (s *bookService) DeductStock(id uint, amount int, actorID uint) (*model.BookResponse, error) {
 	if amount <= 0 {
 		return nil, errors.New("deduction amount must be greater than 0")
 	}
@@ -173,8 +381,161 @@ func (s *bookService) DeductStock(id uint, amount int) (*model.BookResponse, err
 		return nil, err
 	}
 
+	recordChange(s.historyRepo, id, actorID, model.ChangeStockDeducted, bookChanges(*book, *updatedBook))
+
 	response := updatedBook.ToResponse()
 	return &response, nil
 
 }
 
+// GetBookHistory returns a book's change history, newest first. Only the
+// seller and admins can see it.
+func (s *bookService) GetBookHistory(id uint, actor policy.Actor) ([]model.BookChange, error) {
+	book, err := s.bookRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("book not found")
+		}
+		return nil, err
+	}
+
+	if !policy.CanManageBook(actor, book.SellerID) {
+		return nil, errors.New("unauthorized: you can only view the history of your own books")
+	}
+
+	return s.historyRepo.GetByBookID(id)
+}
+
+// bookChanges lists the seller-visible fields that differ between before
+// and after.
+func bookChanges(before model.Book, after model.Book) []model.FieldChange {
+	var fields []model.FieldChange
+	add := func(field string, old interface{}, new interface{}) {
+		if old != new {
+			fields = append(fields, model.FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("name", before.Name, after.Name)
+	add("description", before.Description, after.Description)
+	add("author", before.Author, after.Author)
+	add("stock", before.Stock, after.Stock)
+	add("costs", before.Costs, after.Costs)
+	add("category", before.Category, after.Category)
+	add("category_id", categoryIDValue(before.CategoryID), categoryIDValue(after.CategoryID))
+	add("status", before.Status, after.Status)
+	return fields
+}
+
+func categoryIDValue(id *uint) interface{} {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+// recordChange adds an entry to a book's history. The change itself has
+// already been written, so a failure here is logged rather than returned.
+func recordChange(historyRepo repository.BookHistoryRepository, bookID uint, actorID uint, action string, fields []model.FieldChange) {
+	change := &model.BookChange{
+		BookID:  bookID,
+		ActorID: actorID,
+		Action:  action,
+		Fields:  fields,
+	}
+	if err := historyRepo.Record(change); err != nil {
+		log.Printf("Failed to record %s history for book %d: %v", action, bookID, err)
+	}
+}
+
+// enrich fills in the parts of book responses that do not live on the book
+// itself: available stock and ratings.
+func (s *bookService) enrich(responses []model.BookResponse) ([]model.BookResponse, error) {
+	responses, err := s.withAvailability(responses)
+	if err != nil {
+		return nil, err
+	}
+	return s.withRatings(responses)
+}
+
+// withAvailability subtracts the stock held by active reservations from
+// each response's AvailableStock.
+func (s *bookService) withAvailability(responses []model.BookResponse) ([]model.BookResponse, error) {
+	if len(responses) == 0 {
+		return responses, nil
+	}
+
+	ids := make([]uint, 0, len(responses))
+	for _, response := range responses {
+		ids = append(ids, response.ID)
+	}
+
+	reserved, err := s.reservationRepo.SumActiveByBookIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range responses {
+		responses[i].AvailableStock = responses[i].Stock - reserved[responses[i].ID]
+		if responses[i].AvailableStock < 0 {
+			responses[i].AvailableStock = 0
+		}
+	}
+	return responses, nil
+}
+
+// withRatings adds the book's own rating and its seller's rating to each
+// response.
+func (s *bookService) withRatings(responses []model.BookResponse) ([]model.BookResponse, error) {
+	if len(responses) == 0 {
+		return responses, nil
+	}
+
+	bookIDs := make([]uint, 0, len(responses))
+	sellerIDs := make([]uint, 0, len(responses))
+	for _, response := range responses {
+		bookIDs = append(bookIDs, response.ID)
+		sellerIDs = append(sellerIDs, response.SellerID)
+	}
+
+	bookRatings, err := s.reviewRepo.SummaryByBookIDs(bookIDs)
+	if err != nil {
+		return nil, err
+	}
+	sellerRatings, err := s.reviewRepo.SummaryBySellerIDs(sellerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range responses {
+		responses[i].Rating = bookRatings[responses[i].ID]
+		responses[i].SellerRating = sellerRatings[responses[i].SellerID]
+	}
+	return responses, nil
+}
+
+func (s *bookService) categoryFilter(category string) (model.BookFilter, error) {
+	if category == "" {
+		return model.BookFilter{}, nil
+	}
+
+	var root *model.Category
+	var err error
+	if id, parseErr := strconv.ParseUint(category, 10, 32); parseErr == nil {
+		root, err = s.categoryRepo.GetByID(uint(id))
+	} else {
+		root, err = s.categoryRepo.GetBySlug(helpers.Slugify(category))
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.BookFilter{Category: category}, nil
+	}
+	if err != nil {
+		return model.BookFilter{}, err
+	}
+
+	ids, err := s.categoryRepo.DescendantIDs(root.ID)
+	if err != nil {
+		return model.BookFilter{}, err
+	}
+	return model.BookFilter{CategoryIDs: ids}, nil
+}
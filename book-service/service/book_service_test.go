@@ -2,8 +2,11 @@ package service
 
 import (
 	"book-service/model"
+	"book-service/policy"
+	"book-service/repository"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,8 +22,13 @@ func (m *MockBookRepository) Create(book *model.Book) error {
 	return args.Error(0)
 }
 
-func (m *MockBookRepository) GetAll(category string) ([]model.Book, error) {
-	args := m.Called(category)
+func (m *MockBookRepository) CreateBatch(books []model.Book) error {
+	args := m.Called(books)
+	return args.Error(0)
+}
+
+func (m *MockBookRepository) GetAll(filter model.BookFilter) ([]model.Book, error) {
+	args := m.Called(filter)
 	return args.Get(0).([]model.Book), args.Error(1)
 }
 
@@ -32,6 +40,14 @@ func (m *MockBookRepository) GetByID(id uint) (*model.Book, error) {
 	return args.Get(0).(*model.Book), args.Error(1)
 }
 
+func (m *MockBookRepository) GetByIDs(ids []uint) ([]model.Book, error) {
+	args := m.Called(ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Book), args.Error(1)
+}
+
 func (m *MockBookRepository) GetBySellerID(sellerID uint) ([]model.Book, error) {
 	args := m.Called(sellerID)
 	return args.Get(0).([]model.Book), args.Error(1)
@@ -42,8 +58,8 @@ func (m *MockBookRepository) Update(book *model.Book) error {
 	return args.Error(0)
 }
 
-func (m *MockBookRepository) Delete(id uint, sellerID uint) error {
-	args := m.Called(id, sellerID)
+func (m *MockBookRepository) Delete(id uint, sellerID uint, version uint) error {
+	args := m.Called(id, sellerID, version)
 	return args.Error(0)
 }
 
@@ -52,9 +68,97 @@ func (m *MockBookRepository) DeductStock(id uint, amount int) error {
 	return args.Error(0)
 }
 
+func (m *MockBookRepository) GetDeletedBySellerID(sellerID uint, deletedSince time.Time) ([]model.Book, error) {
+	args := m.Called(sellerID, deletedSince)
+	return args.Get(0).([]model.Book), args.Error(1)
+}
+
+func (m *MockBookRepository) Restore(id uint, sellerID uint, deletedSince time.Time) error {
+	args := m.Called(id, sellerID, deletedSince)
+	return args.Error(0)
+}
+
+func (m *MockBookRepository) PurgeDeleted(deletedBefore time.Time) (int64, error) {
+	args := m.Called(deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockBookHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockBookHistoryRepository) Record(change *model.BookChange) error {
+	args := m.Called(change)
+	return args.Error(0)
+}
+
+func (m *MockBookHistoryRepository) GetByBookID(bookID uint) ([]model.BookChange, error) {
+	args := m.Called(bookID)
+	return args.Get(0).([]model.BookChange), args.Error(1)
+}
+
+// newMockBookHistoryRepository returns a history repository that accepts
+// every change.
+func newMockBookHistoryRepository() *MockBookHistoryRepository {
+	m := new(MockBookHistoryRepository)
+	m.On("Record", mock.Anything).Return(nil).Maybe()
+	return m
+}
+
+type MockReservationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReservationRepository) Reserve(bookID uint, transactionID uint, quantity int, expiresAt time.Time) (*model.Reservation, error) {
+	args := m.Called(bookID, transactionID, quantity, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) Confirm(transactionID uint) ([]model.Reservation, []repository.StockMovement, error) {
+	args := m.Called(transactionID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	movements, _ := args.Get(1).([]repository.StockMovement)
+	return args.Get(0).([]model.Reservation), movements, args.Error(2)
+}
+
+func (m *MockReservationRepository) Release(transactionID uint) error {
+	args := m.Called(transactionID)
+	return args.Error(0)
+}
+
+func (m *MockReservationRepository) ExpireOverdue(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockReservationRepository) SumActiveByBookIDs(bookIDs []uint) (map[uint]int, error) {
+	args := m.Called(bookIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uint]int), args.Error(1)
+}
+
+// newMockReservationRepository returns a reservation repository with no
+// active reservations.
+func newMockReservationRepository() *MockReservationRepository {
+	m := new(MockReservationRepository)
+	m.On("SumActiveByBookIDs", mock.Anything).Return(map[uint]int{}, nil).Maybe()
+	return m
+}
+
+func seller(id uint) policy.Actor {
+	return policy.Actor{UserID: id, Role: policy.RoleSeller}
+}
+
 func TestCreateBook_Success(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	req := &model.CreateBookRequest{
 		Name:        "Test Book",
@@ -92,7 +196,7 @@ func TestCreateBook_Success(t *testing.T) {
 
 func TestCreateBook_RepositoryError(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	req := &model.CreateBookRequest{
 		Name:        "Test Book",
@@ -115,16 +219,16 @@ func TestCreateBook_RepositoryError(t *testing.T) {
 
 func TestGetAllBooks_Success(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	expectedBooks := []model.Book{
 		{ID: 1, Name: "Book 1", SellerID: 1, Costs: 29.99},
 		{ID: 2, Name: "Book 2", SellerID: 2, Costs: 39.99},
 	}
 
-	mockRepo.On("GetAll", "fiction").Return(expectedBooks, nil)
+	mockRepo.On("GetAll", model.BookFilter{Category: "fiction", Status: model.StatusActive}).Return(expectedBooks, nil)
 
-	result, err := service.GetAllBooks("fiction")
+	result, err := service.GetAllBooks("fiction", 0)
 
 	assert.NoError(t, err)
 	assert.Len(t, result, 2)
@@ -133,13 +237,32 @@ func TestGetAllBooks_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetAllBooks_SellerSeesOwnListings(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	expectedBooks := []model.Book{
+		{ID: 1, Name: "Book 1", SellerID: 1, Status: model.StatusActive},
+		{ID: 2, Name: "Draft", SellerID: 7, Status: model.StatusDraft},
+	}
+
+	mockRepo.On("GetAll", model.BookFilter{Status: model.StatusActive, OwnerID: 7}).Return(expectedBooks, nil)
+
+	result, err := service.GetAllBooks("", 7)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "Draft", result[1].Name)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetAllBooks_RepositoryError(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
-	mockRepo.On("GetAll", "").Return([]model.Book{}, errors.New("database error"))
+	mockRepo.On("GetAll", model.BookFilter{Status: model.StatusActive}).Return([]model.Book{}, errors.New("database error"))
 
-	result, err := service.GetAllBooks("")
+	result, err := service.GetAllBooks("", 0)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -149,18 +272,19 @@ func TestGetAllBooks_RepositoryError(t *testing.T) {
 
 func TestGetBookByID_Success(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	expectedBook := &model.Book{
 		ID:       1,
 		Name:     "Test Book",
 		SellerID: 1,
 		Costs:    29.99,
+		Status:   model.StatusActive,
 	}
 
 	mockRepo.On("GetByID", uint(1)).Return(expectedBook, nil)
 
-	result, err := service.GetBookByID(1)
+	result, err := service.GetBookByID(1, 2)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -171,11 +295,11 @@ func TestGetBookByID_Success(t *testing.T) {
 
 func TestGetBookByID_NotFound(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	mockRepo.On("GetByID", uint(1)).Return(nil, gorm.ErrRecordNotFound)
 
-	result, err := service.GetBookByID(1)
+	result, err := service.GetBookByID(1, 0)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -185,11 +309,11 @@ func TestGetBookByID_NotFound(t *testing.T) {
 
 func TestGetBookByID_RepositoryError(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	mockRepo.On("GetByID", uint(1)).Return(nil, errors.New("database error"))
 
-	result, err := service.GetBookByID(1)
+	result, err := service.GetBookByID(1, 0)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -197,9 +321,71 @@ func TestGetBookByID_RepositoryError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetBooksByIDs_KeepsOrderAndReportsMissing(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	mockRepo.On("GetByIDs", []uint{3, 1, 2, 4}).Return([]model.Book{
+		{ID: 1, SellerID: 1, Status: model.StatusActive},
+		{ID: 2, SellerID: 1, Status: model.StatusDraft},
+		{ID: 3, SellerID: 1, Status: model.StatusSoldOut},
+	}, nil)
+
+	batch, err := service.GetBooksByIDs([]uint{3, 1, 3, 2, 4}, policy.Actor{})
+
+	assert.NoError(t, err)
+	assert.Len(t, batch.Books, 2)
+	assert.Equal(t, uint(3), batch.Books[0].ID)
+	assert.Equal(t, uint(1), batch.Books[1].ID)
+	assert.Equal(t, []uint{2, 4}, batch.MissingIDs)
+}
+
+func TestGetBooksByIDs_UnpublishedVisibleToSellerAndServices(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor policy.Actor
+	}{
+		{"seller", seller(1)},
+		{"service", policy.Actor{Role: policy.RoleService}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockBookRepository)
+			service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+			mockRepo.On("GetByIDs", []uint{2}).Return([]model.Book{
+				{ID: 2, SellerID: 1, Status: model.StatusPaused},
+			}, nil)
+
+			batch, err := service.GetBooksByIDs([]uint{2}, tt.actor)
+
+			assert.NoError(t, err)
+			assert.Len(t, batch.Books, 1)
+			assert.Empty(t, batch.MissingIDs)
+		})
+	}
+}
+
+func TestGetBooksByIDs_TooMany(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	ids := make([]uint, model.MaxBookBatchSize+1)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+	}
+
+	batch, err := service.GetBooksByIDs(ids, policy.Actor{})
+
+	assert.EqualError(t, err, "too many book IDs")
+	assert.Nil(t, batch)
+	mockRepo.AssertNotCalled(t, "GetByIDs", mock.Anything)
+}
+
 func TestGetBooksBySellerID_Success(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	expectedBooks := []model.Book{
 		{ID: 1, Name: "Book 1", SellerID: 1, Costs: 29.99},
@@ -208,7 +394,7 @@ func TestGetBooksBySellerID_Success(t *testing.T) {
 
 	mockRepo.On("GetBySellerID", uint(1)).Return(expectedBooks, nil)
 
-	result, err := service.GetBooksBySellerID(1)
+	result, err := service.GetBooksBySellerID(1, "")
 
 	assert.NoError(t, err)
 	assert.Len(t, result, 2)
@@ -219,7 +405,7 @@ func TestGetBooksBySellerID_Success(t *testing.T) {
 
 func TestUpdateBook_Success(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	existingBook := &model.Book{
 		ID:          1,
@@ -242,7 +428,7 @@ func TestUpdateBook_Success(t *testing.T) {
 	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
 	mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(nil)
 
-	result, err := service.UpdateBook(1, req, 1)
+	result, err := service.UpdateBook(1, req, seller(1), nil)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -253,13 +439,13 @@ func TestUpdateBook_Success(t *testing.T) {
 
 func TestUpdateBook_BookNotFound(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	req := &model.UpdateBookRequest{}
 
 	mockRepo.On("GetByID", uint(1)).Return(nil, gorm.ErrRecordNotFound)
 
-	result, err := service.UpdateBook(1, req, 1)
+	result, err := service.UpdateBook(1, req, seller(1), nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -269,7 +455,7 @@ func TestUpdateBook_BookNotFound(t *testing.T) {
 
 func TestUpdateBook_Unauthorized(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	existingBook := &model.Book{
 		ID:       1,
@@ -281,7 +467,7 @@ func TestUpdateBook_Unauthorized(t *testing.T) {
 
 	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
 
-	result, err := service.UpdateBook(1, req, 2)
+	result, err := service.UpdateBook(1, req, seller(2), nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -291,7 +477,7 @@ func TestUpdateBook_Unauthorized(t *testing.T) {
 
 func TestDeleteBook_Success(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	existingBook := &model.Book{
 		ID:       1,
@@ -300,9 +486,9 @@ func TestDeleteBook_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
-	mockRepo.On("Delete", uint(1), uint(1)).Return(nil)
+	mockRepo.On("Delete", uint(1), uint(1), uint(0)).Return(nil)
 
-	err := service.DeleteBook(1, 1)
+	err := service.DeleteBook(1, seller(1), nil)
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
@@ -310,11 +496,11 @@ func TestDeleteBook_Success(t *testing.T) {
 
 func TestDeleteBook_BookNotFound(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	mockRepo.On("GetByID", uint(1)).Return(nil, gorm.ErrRecordNotFound)
 
-	err := service.DeleteBook(1, 1)
+	err := service.DeleteBook(1, seller(1), nil)
 
 	assert.Error(t, err)
 	assert.Equal(t, "book not found", err.Error())
@@ -323,7 +509,7 @@ func TestDeleteBook_BookNotFound(t *testing.T) {
 
 func TestDeleteBook_Unauthorized(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	existingBook := &model.Book{
 		ID:       1,
@@ -333,7 +519,7 @@ func TestDeleteBook_Unauthorized(t *testing.T) {
 
 	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
 
-	err := service.DeleteBook(1, 2)
+	err := service.DeleteBook(1, seller(2), nil)
 
 	assert.Error(t, err)
 	assert.Equal(t, "unauthorized: you can only delete your own books", err.Error())
@@ -342,7 +528,7 @@ func TestDeleteBook_Unauthorized(t *testing.T) {
 
 func TestDeductStock_Success(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	existingBook := &model.Book{
 		ID:       1,
@@ -364,7 +550,7 @@ func TestDeductStock_Success(t *testing.T) {
 	mockRepo.On("DeductStock", uint(1), 3).Return(nil)
 	mockRepo.On("GetByID", uint(1)).Return(updatedBook, nil).Once()
 
-	result, err := service.DeductStock(1, 3)
+	result, err := service.DeductStock(1, 3, 2)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -374,9 +560,9 @@ func TestDeductStock_Success(t *testing.T) {
 
 func TestDeductStock_InvalidAmount(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
-	result, err := service.DeductStock(1, 0)
+	result, err := service.DeductStock(1, 0, 2)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -385,11 +571,11 @@ func TestDeductStock_InvalidAmount(t *testing.T) {
 
 func TestDeductStock_BookNotFound(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	mockRepo.On("GetByID", uint(1)).Return(nil, gorm.ErrRecordNotFound)
 
-	result, err := service.DeductStock(1, 3)
+	result, err := service.DeductStock(1, 3, 2)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -399,7 +585,7 @@ func TestDeductStock_BookNotFound(t *testing.T) {
 
 func TestDeductStock_InsufficientStock(t *testing.T) {
 	mockRepo := new(MockBookRepository)
-	service := NewBookService(mockRepo)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
 
 	existingBook := &model.Book{
 		ID:       1,
@@ -411,10 +597,350 @@ func TestDeductStock_InsufficientStock(t *testing.T) {
 
 	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
 
-	result, err := service.DeductStock(1, 5)
+	result, err := service.DeductStock(1, 5, 2)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	assert.Equal(t, "insufficient stock", err.Error())
 	mockRepo.AssertExpectations(t)
-}
\ No newline at end of file
+}
+
+func TestDeductStock_LostRace(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	existingBook := &model.Book{
+		ID:       1,
+		SellerID: 1,
+		Name:     "Test Book",
+		Stock:    3,
+		Costs:    29.99,
+	}
+
+	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil).Once()
+	mockRepo.On("DeductStock", uint(1), 3).Return(repository.ErrInsufficientStock)
+
+	result, err := service.DeductStock(1, 3, 2)
+
+	assert.ErrorIs(t, err, repository.ErrInsufficientStock)
+	assert.Nil(t, result)
+	assert.Equal(t, "insufficient stock", err.Error())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetBookByID_SubtractsActiveReservations(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewBookService(mockRepo, mockReservationRepo, newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	existingBook := &model.Book{
+		ID:       1,
+		SellerID: 1,
+		Name:     "Test Book",
+		Stock:    5,
+		Status:   model.StatusActive,
+	}
+
+	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
+	mockReservationRepo.On("SumActiveByBookIDs", []uint{1}).Return(map[uint]int{1: 3}, nil)
+
+	result, err := service.GetBookByID(1, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.Stock)
+	assert.Equal(t, 2, result.AvailableStock)
+	mockRepo.AssertExpectations(t)
+	mockReservationRepo.AssertExpectations(t)
+}
+
+func TestUpdateBook_VersionMismatch(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	existingBook := &model.Book{
+		ID:       1,
+		SellerID: 1,
+		Name:     "Test Book",
+		Version:  3,
+	}
+
+	newName := "New Name"
+	req := &model.UpdateBookRequest{Name: &newName}
+	staleVersion := uint(2)
+
+	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
+
+	result, err := service.UpdateBook(1, req, seller(1), &staleVersion)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, "book has been modified", err.Error())
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUpdateBook_RetriesAfterConcurrentWrite(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	staleBook := &model.Book{ID: 1, SellerID: 1, Name: "Test Book", Stock: 5, Version: 1}
+	freshBook := &model.Book{ID: 1, SellerID: 1, Name: "Test Book", Stock: 4, Version: 2}
+
+	newName := "New Name"
+	req := &model.UpdateBookRequest{Name: &newName}
+
+	mockRepo.On("GetByID", uint(1)).Return(staleBook, nil).Once()
+	mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(repository.ErrVersionConflict).Once()
+	mockRepo.On("GetByID", uint(1)).Return(freshBook, nil).Once()
+	mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(nil).Once()
+
+	result, err := service.UpdateBook(1, req, seller(1), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, newName, result.Name)
+	assert.Equal(t, 4, result.Stock)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateBook_StockNotRetriedAfterConcurrentWrite(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	staleBook := &model.Book{ID: 1, SellerID: 1, Name: "Test Book", Stock: 5, Version: 1}
+
+	newStock := 10
+	req := &model.UpdateBookRequest{Stock: &newStock}
+
+	mockRepo.On("GetByID", uint(1)).Return(staleBook, nil).Once()
+	mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(repository.ErrVersionConflict).Once()
+
+	result, err := service.UpdateBook(1, req, seller(1), nil)
+
+	assert.Equal(t, repository.ErrVersionConflict, err)
+	assert.Nil(t, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteBook_VersionMismatch(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	existingBook := &model.Book{
+		ID:       1,
+		SellerID: 1,
+		Name:     "Test Book",
+		Version:  3,
+	}
+	staleVersion := uint(2)
+
+	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
+
+	err := service.DeleteBook(1, seller(1), &staleVersion)
+
+	assert.Error(t, err)
+	assert.Equal(t, "book has been modified", err.Error())
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCreateBook_WithoutStockIsSoldOut(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	mockRepo.On("Create", mock.AnythingOfType("*model.Book")).Return(nil)
+
+	result, err := service.CreateBook(&model.CreateBookRequest{Name: "Test Book", Costs: 10, Stock: 0}, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusSoldOut, result.Status)
+}
+
+func TestUpdateBook_StatusTransitions(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		stock   int
+		to      string
+		want    string
+		wantErr string
+	}{
+		{"publish draft", model.StatusDraft, 3, model.StatusActive, model.StatusActive, ""},
+		{"publish draft without stock", model.StatusDraft, 0, model.StatusActive, model.StatusSoldOut, ""},
+		{"pause active", model.StatusActive, 3, model.StatusPaused, model.StatusPaused, ""},
+		{"resume paused", model.StatusPaused, 3, model.StatusActive, model.StatusActive, ""},
+		{"archive sold out", model.StatusSoldOut, 0, model.StatusArchived, model.StatusArchived, ""},
+		{"relist archived as draft", model.StatusArchived, 0, model.StatusDraft, model.StatusDraft, ""},
+		{"pause sold out", model.StatusSoldOut, 0, model.StatusPaused, "", "invalid status transition"},
+		{"activate archived", model.StatusArchived, 3, model.StatusActive, "", "invalid status transition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockBookRepository)
+			service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+			mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 1, Stock: tt.stock, Status: tt.from, Version: 1}, nil)
+			mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(nil).Maybe()
+
+			result, err := service.UpdateBook(1, &model.UpdateBookRequest{Status: &tt.to}, seller(1), nil)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, result.Status)
+		})
+	}
+}
+
+func TestUpdateBook_RestockReactivatesSoldOut(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	stock := 4
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 1, Stock: 0, Status: model.StatusSoldOut, Version: 1}, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(nil)
+
+	result, err := service.UpdateBook(1, &model.UpdateBookRequest{Stock: &stock}, seller(1), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusActive, result.Status)
+}
+
+func TestGetBookByID_DraftOnlyVisibleToSeller(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 1, Status: model.StatusDraft}, nil)
+
+	result, err := service.GetBookByID(1, 2)
+	assert.EqualError(t, err, "book not found")
+	assert.Nil(t, result)
+
+	result, err = service.GetBookByID(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusDraft, result.Status)
+}
+
+func TestGetBooksBySellerID_FiltersByStatus(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	mockRepo.On("GetBySellerID", uint(1)).Return([]model.Book{
+		{ID: 1, SellerID: 1, Status: model.StatusActive},
+		{ID: 2, SellerID: 1, Status: model.StatusDraft},
+		{ID: 3, SellerID: 1, Status: model.StatusDraft},
+	}, nil)
+
+	result, err := service.GetBooksBySellerID(1, model.StatusDraft)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+
+	result, err = service.GetBooksBySellerID(1, "deleted")
+	assert.EqualError(t, err, "invalid status")
+	assert.Nil(t, result)
+}
+
+func TestUpdateBook_RecordsChangedFields(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), mockHistoryRepo, nil)
+
+	costs := 15.0
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 1, Name: "Old", Stock: 2, Costs: 20, Status: model.StatusActive, Version: 1}, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(nil)
+	mockHistoryRepo.On("Record", mock.MatchedBy(func(c *model.BookChange) bool {
+		return c.BookID == 1 && c.ActorID == 1 && c.Action == model.ChangeUpdated &&
+			assert.ObjectsAreEqual([]model.FieldChange{{Field: "costs", Old: 20.0, New: 15.0}}, c.Fields)
+	})).Return(nil)
+
+	_, err := service.UpdateBook(1, &model.UpdateBookRequest{Costs: &costs}, seller(1), nil)
+
+	assert.NoError(t, err)
+	mockHistoryRepo.AssertExpectations(t)
+}
+
+func TestUpdateBook_NoChangesRecordsNothing(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), mockHistoryRepo, nil)
+
+	name := "Same"
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 1, Name: "Same", Stock: 2, Status: model.StatusActive, Version: 1}, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(nil)
+
+	_, err := service.UpdateBook(1, &model.UpdateBookRequest{Name: &name}, seller(1), nil)
+
+	assert.NoError(t, err)
+	mockHistoryRepo.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestDeductStock_RecordsStockAndStatus(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), mockHistoryRepo, nil)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, Stock: 1, Status: model.StatusActive}, nil).Once()
+	mockRepo.On("DeductStock", uint(1), 1).Return(nil)
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, Stock: 0, Status: model.StatusSoldOut}, nil).Once()
+	mockHistoryRepo.On("Record", mock.MatchedBy(func(c *model.BookChange) bool {
+		return c.ActorID == 7 && c.Action == model.ChangeStockDeducted && assert.ObjectsAreEqual([]model.FieldChange{
+			{Field: "stock", Old: 1, New: 0},
+			{Field: "status", Old: model.StatusActive, New: model.StatusSoldOut},
+		}, c.Fields)
+	})).Return(nil)
+
+	_, err := service.DeductStock(1, 1, 7)
+
+	assert.NoError(t, err)
+	mockHistoryRepo.AssertExpectations(t)
+}
+
+func TestDeleteBook_RecordsDeletion(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), mockHistoryRepo, nil)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 1, Version: 3}, nil)
+	mockRepo.On("Delete", uint(1), uint(1), uint(3)).Return(nil)
+	mockHistoryRepo.On("Record", mock.MatchedBy(func(c *model.BookChange) bool {
+		return c.BookID == 1 && c.ActorID == 1 && c.Action == model.ChangeDeleted
+	})).Return(nil)
+
+	err := service.DeleteBook(1, seller(1), nil)
+
+	assert.NoError(t, err)
+	mockHistoryRepo.AssertExpectations(t)
+}
+
+func TestGetBookHistory_OnlySeller(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), mockHistoryRepo, nil)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 1}, nil)
+
+	history, err := service.GetBookHistory(1, seller(2))
+
+	assert.EqualError(t, err, "unauthorized: you can only view the history of your own books")
+	assert.Nil(t, history)
+	mockHistoryRepo.AssertNotCalled(t, "GetByBookID", mock.Anything)
+}
+
+func TestDeleteBook_AdminCanDeleteAnyBook(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), mockHistoryRepo, nil)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 1, Version: 2}, nil)
+	mockRepo.On("Delete", uint(1), uint(1), uint(2)).Return(nil)
+	mockHistoryRepo.On("Record", mock.MatchedBy(func(c *model.BookChange) bool {
+		return c.ActorID == 5 && c.Action == model.ChangeDeleted
+	})).Return(nil)
+
+	err := service.DeleteBook(1, policy.Actor{UserID: 5, Role: policy.RoleAdmin}, nil)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockHistoryRepo.AssertExpectations(t)
+}
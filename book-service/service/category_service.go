@@ -0,0 +1,241 @@
+package service
+
+import (
+	"book-service/helpers"
+	"book-service/model"
+	"book-service/repository"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type CategoryService interface {
+	CreateCategory(req *model.CreateCategoryRequest) (*model.Category, error)
+	UpdateCategory(id uint, req *model.UpdateCategoryRequest) (*model.Category, error)
+	DeleteCategory(id uint) error
+	GetTree() ([]model.CategoryNode, error)
+}
+
+type categoryService struct {
+	categoryRepo repository.CategoryRepository
+	bookRepo     repository.BookRepository
+}
+
+func NewCategoryService(categoryRepo repository.CategoryRepository, bookRepo repository.BookRepository) CategoryService {
+	return &categoryService{
+		categoryRepo: categoryRepo,
+		bookRepo:     bookRepo,
+	}
+}
+
+func (s *categoryService) CreateCategory(req *model.CreateCategoryRequest) (*model.Category, error) {
+	slug := helpers.Slugify(req.Slug)
+	if slug == "" {
+		slug = helpers.Slugify(req.Name)
+	}
+	if slug == "" {
+		return nil, errors.New("invalid category slug")
+	}
+
+	if err := s.ensureSlugFree(slug, 0); err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		if _, err := s.getCategory(*req.ParentID); err != nil {
+			return nil, errors.New("parent category not found")
+		}
+	}
+
+	category := &model.Category{
+		Name:     req.Name,
+		Slug:     slug,
+		ParentID: req.ParentID,
+	}
+	if err := s.categoryRepo.Create(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *categoryService) UpdateCategory(id uint, req *model.UpdateCategoryRequest) (*model.Category, error) {
+	category, err := s.getCategory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := false
+	if req.Name != nil && *req.Name != category.Name {
+		category.Name = *req.Name
+		renamed = true
+	}
+
+	if req.Slug != nil {
+		slug := helpers.Slugify(*req.Slug)
+		if slug == "" {
+			return nil, errors.New("invalid category slug")
+		}
+		if err := s.ensureSlugFree(slug, id); err != nil {
+			return nil, err
+		}
+		category.Slug = slug
+	}
+
+	if req.MoveToRoot {
+		category.ParentID = nil
+	} else if req.ParentID != nil {
+		if _, err := s.getCategory(*req.ParentID); err != nil {
+			return nil, errors.New("parent category not found")
+		}
+
+		descendants, err := s.categoryRepo.DescendantIDs(id)
+		if err != nil {
+			return nil, err
+		}
+		for _, descendant := range descendants {
+			if descendant == *req.ParentID {
+				return nil, errors.New("category cannot be moved under itself")
+			}
+		}
+		category.ParentID = req.ParentID
+	}
+
+	if err := s.categoryRepo.Update(category); err != nil {
+		return nil, err
+	}
+
+	if renamed {
+		bookIDs, err := s.categoryRepo.RenameBooks(category.ID, category.Name)
+		if err != nil {
+			return nil, err
+		}
+		if invalidator, ok := s.bookRepo.(repository.BookCacheInvalidator); ok {
+			for _, bookID := range bookIDs {
+				invalidator.InvalidateBook(bookID)
+			}
+		}
+	}
+	return category, nil
+}
+
+// DeleteCategory refuses to delete categories that still have
+// subcategories or listings, so no book is left pointing at nothing.
+func (s *categoryService) DeleteCategory(id uint) error {
+	if _, err := s.getCategory(id); err != nil {
+		return err
+	}
+
+	children, err := s.categoryRepo.CountChildren(id)
+	if err != nil {
+		return err
+	}
+	counts, err := s.categoryRepo.CountBooksByCategory()
+	if err != nil {
+		return err
+	}
+	if children > 0 || counts[id] > 0 {
+		return errors.New("category is in use")
+	}
+
+	return s.categoryRepo.Delete(id)
+}
+
+func (s *categoryService) GetTree() ([]model.CategoryNode, error) {
+	categories, err := s.categoryRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.categoryRepo.CountBooksByCategory()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]model.Category)
+	var roots []model.Category
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	var build func(category model.Category) model.CategoryNode
+	build = func(category model.Category) model.CategoryNode {
+		node := model.CategoryNode{
+			ID:           category.ID,
+			Name:         category.Name,
+			Slug:         category.Slug,
+			ParentID:     category.ParentID,
+			ListingCount: counts[category.ID],
+			Children:     []model.CategoryNode{},
+		}
+		for _, child := range children[category.ID] {
+			childNode := build(child)
+			node.ListingCount += childNode.ListingCount
+			node.Children = append(node.Children, childNode)
+		}
+		return node
+	}
+
+	tree := []model.CategoryNode{}
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	return tree, nil
+}
+
+func (s *categoryService) getCategory(id uint) (*model.Category, error) {
+	category, err := s.categoryRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("category not found")
+		}
+		return nil, err
+	}
+	return category, nil
+}
+
+func (s *categoryService) ensureSlugFree(slug string, exceptID uint) error {
+	existing, err := s.categoryRepo.GetBySlug(slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if existing.ID != exceptID {
+		return errors.New("category slug already exists")
+	}
+	return nil
+}
+
+// resolveCategory works out the managed category for a book from an
+// explicit category ID or, for older clients, the free-text category name.
+// Names that match no managed category are kept as plain text.
+func resolveCategory(categoryRepo repository.CategoryRepository, categoryID *uint, name string) (*uint, string, error) {
+	if categoryID != nil {
+		category, err := categoryRepo.GetByID(*categoryID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", errors.New("category not found")
+			}
+			return nil, "", err
+		}
+		return &category.ID, category.Name, nil
+	}
+
+	slug := helpers.Slugify(name)
+	if slug == "" {
+		return nil, name, nil
+	}
+
+	category, err := categoryRepo.GetBySlug(slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, name, nil
+		}
+		return nil, "", err
+	}
+	return &category.ID, category.Name, nil
+}
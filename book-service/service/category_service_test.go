@@ -0,0 +1,214 @@
+package service
+
+import (
+	"book-service/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) Create(category *model.Category) error {
+	args := m.Called(category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) Update(category *model.Category) error {
+	args := m.Called(category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) GetByID(id uint) (*model.Category, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetBySlug(slug string) (*model.Category, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetAll() ([]model.Category, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) DescendantIDs(id uint) ([]uint, error) {
+	args := m.Called(id)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockCategoryRepository) CountChildren(id uint) (int64, error) {
+	args := m.Called(id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockCategoryRepository) CountBooksByCategory() (map[uint]int64, error) {
+	args := m.Called()
+	return args.Get(0).(map[uint]int64), args.Error(1)
+}
+
+func (m *MockCategoryRepository) RenameBooks(categoryID uint, name string) ([]uint, error) {
+	args := m.Called(categoryID, name)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+// newMockCategoryRepository returns a category repository with no managed
+// categories, so free-text categories are kept as they are.
+func newMockCategoryRepository() *MockCategoryRepository {
+	m := new(MockCategoryRepository)
+	m.On("GetBySlug", mock.Anything).Return(nil, gorm.ErrRecordNotFound).Maybe()
+	return m
+}
+
+func uintPtr(v uint) *uint {
+	return &v
+}
+
+func TestCreateCategory_GeneratesSlug(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	service := NewCategoryService(mockRepo, new(MockBookRepository))
+
+	mockRepo.On("GetBySlug", "science-fiction").Return(nil, gorm.ErrRecordNotFound)
+	mockRepo.On("GetByID", uint(1)).Return(&model.Category{ID: 1, Name: "Fiction", Slug: "fiction"}, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*model.Category")).Return(nil)
+
+	category, err := service.CreateCategory(&model.CreateCategoryRequest{Name: "Science Fiction", ParentID: uintPtr(1)})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "science-fiction", category.Slug)
+	assert.Equal(t, uint(1), *category.ParentID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateCategory_DuplicateSlug(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	service := NewCategoryService(mockRepo, new(MockBookRepository))
+
+	mockRepo.On("GetBySlug", "fiction").Return(&model.Category{ID: 1, Slug: "fiction"}, nil)
+
+	category, err := service.CreateCategory(&model.CreateCategoryRequest{Name: "Fiction"})
+
+	assert.Error(t, err)
+	assert.Nil(t, category)
+	assert.Equal(t, "category slug already exists", err.Error())
+}
+
+func TestUpdateCategory_RejectsMoveUnderDescendant(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	service := NewCategoryService(mockRepo, new(MockBookRepository))
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Category{ID: 1, Name: "Fiction"}, nil)
+	mockRepo.On("GetByID", uint(2)).Return(&model.Category{ID: 2, Name: "Fantasy", ParentID: uintPtr(1)}, nil)
+	mockRepo.On("DescendantIDs", uint(1)).Return([]uint{1, 2}, nil)
+
+	category, err := service.UpdateCategory(1, &model.UpdateCategoryRequest{ParentID: uintPtr(2)})
+
+	assert.Error(t, err)
+	assert.Nil(t, category)
+	assert.Equal(t, "category cannot be moved under itself", err.Error())
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestUpdateCategory_RenameUpdatesBooks(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	bookRepo := &invalidatingBookRepository{}
+	service := NewCategoryService(mockRepo, bookRepo)
+
+	name := "Fiksi"
+	mockRepo.On("GetByID", uint(1)).Return(&model.Category{ID: 1, Name: "Fiction"}, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*model.Category")).Return(nil)
+	mockRepo.On("RenameBooks", uint(1), "Fiksi").Return([]uint{4, 5}, nil)
+
+	category, err := service.UpdateCategory(1, &model.UpdateCategoryRequest{Name: &name})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Fiksi", category.Name)
+	assert.Equal(t, []uint{4, 5}, bookRepo.invalidated)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestDeleteCategory_InUse(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	service := NewCategoryService(mockRepo, new(MockBookRepository))
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Category{ID: 1}, nil)
+	mockRepo.On("CountChildren", uint(1)).Return(int64(0), nil)
+	mockRepo.On("CountBooksByCategory").Return(map[uint]int64{1: 3}, nil)
+
+	err := service.DeleteCategory(1)
+
+	assert.Error(t, err)
+	assert.Equal(t, "category is in use", err.Error())
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestGetTree_CountsIncludeSubcategories(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	service := NewCategoryService(mockRepo, new(MockBookRepository))
+
+	mockRepo.On("GetAll").Return([]model.Category{
+		{ID: 1, Name: "Fiction", Slug: "fiction"},
+		{ID: 2, Name: "Fantasy", Slug: "fantasy", ParentID: uintPtr(1)},
+		{ID: 3, Name: "History", Slug: "history"},
+	}, nil)
+	mockRepo.On("CountBooksByCategory").Return(map[uint]int64{1: 2, 2: 5}, nil)
+
+	tree, err := service.GetTree()
+
+	assert.NoError(t, err)
+	assert.Len(t, tree, 2)
+	assert.Equal(t, int64(7), tree[0].ListingCount)
+	assert.Len(t, tree[0].Children, 1)
+	assert.Equal(t, int64(5), tree[0].Children[0].ListingCount)
+	assert.Equal(t, int64(0), tree[1].ListingCount)
+}
+
+func TestGetAllBooks_CategorySlugIncludesSubcategories(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), mockCategoryRepo, newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	mockCategoryRepo.On("GetBySlug", "fiction").Return(&model.Category{ID: 1, Slug: "fiction"}, nil)
+	mockCategoryRepo.On("DescendantIDs", uint(1)).Return([]uint{1, 2}, nil)
+	mockRepo.On("GetAll", model.BookFilter{CategoryIDs: []uint{1, 2}, Status: model.StatusActive}).Return([]model.Book{
+		{ID: 1, Name: "Book 1", CategoryID: uintPtr(2)},
+	}, nil)
+
+	result, err := service.GetAllBooks("Fiction", 0)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateBook_UnknownCategoryID(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockCategoryRepo := new(MockCategoryRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), mockCategoryRepo, newMockReviewRepository(), newMockBookHistoryRepository(), nil)
+
+	mockCategoryRepo.On("GetByID", uint(9)).Return(nil, gorm.ErrRecordNotFound)
+
+	result, err := service.CreateBook(&model.CreateBookRequest{Name: "Test", Costs: 10, CategoryID: uintPtr(9)}, 1)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, "category not found", err.Error())
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
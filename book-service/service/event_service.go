@@ -0,0 +1,74 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/repository"
+	"encoding/json"
+	"errors"
+)
+
+type EventService interface {
+	Handle(event *model.IncomingEvent, caller string) (bool, error)
+}
+
+type eventService struct {
+	eventRepo       repository.EventRepository
+	reservationRepo repository.ReservationRepository
+}
+
+// NewEventService creates the service consuming events other services
+// deliver from their outbox.
+func NewEventService(eventRepo repository.EventRepository, reservationRepo repository.ReservationRepository) EventService {
+	return &eventService{
+		eventRepo:       eventRepo,
+		reservationRepo: reservationRepo,
+	}
+}
+
+// Handle acts on the event and reports whether it had already been
+// handled, in which case nothing is done again. It is only recorded once
+// handled, so an event that fails is handled in full when it is delivered
+// again; what it does is safe to repeat should two deliveries race.
+// Events of types book-service does not know are acknowledged and
+// ignored.
+func (s *eventService) Handle(event *model.IncomingEvent, caller string) (bool, error) {
+	processed, err := s.eventRepo.IsProcessed(event.ID)
+	if err != nil {
+		return false, err
+	}
+	if processed {
+		return true, nil
+	}
+
+	switch event.Type {
+	case model.EventTransactionStatusChanged:
+		if err := s.transactionStatusChanged(event.Data); err != nil {
+			return false, err
+		}
+	}
+
+	return false, s.eventRepo.MarkProcessed(&model.ProcessedEvent{
+		EventID: event.ID,
+		Type:    event.Type,
+		Service: caller,
+	})
+}
+
+// transactionStatusChanged gives back the stock held for a transaction
+// that will never be paid. There is none to give back when its
+// reservation was never made or has been released already.
+func (s *eventService) transactionStatusChanged(data json.RawMessage) error {
+	var changed model.TransactionStatusChanged
+	if err := json.Unmarshal(data, &changed); err != nil {
+		return errors.New("invalid event data")
+	}
+
+	switch changed.ToStatus {
+	case "cancelled", "expired":
+		err := s.reservationRepo.Release(changed.TransactionID)
+		if err != nil && !errors.Is(err, repository.ErrReservationNotFound) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/repository"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockEventRepository) IsProcessed(eventID string) (bool, error) {
+	args := m.Called(eventID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEventRepository) MarkProcessed(event *model.ProcessedEvent) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func statusChangedEvent(id string, transactionID uint, toStatus string) *model.IncomingEvent {
+	data, _ := json.Marshal(model.TransactionStatusChanged{TransactionID: transactionID, ToStatus: toStatus})
+	return &model.IncomingEvent{ID: id, Type: model.EventTransactionStatusChanged, Data: data}
+}
+
+func TestHandleEvent_CancelledReleasesReservation(t *testing.T) {
+	mockEventRepo := new(MockEventRepository)
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewEventService(mockEventRepo, mockReservationRepo)
+
+	mockEventRepo.On("IsProcessed", "transaction-7-cancelled").Return(false, nil)
+	mockReservationRepo.On("Release", uint(7)).Return(nil)
+	mockEventRepo.On("MarkProcessed", mock.MatchedBy(func(e *model.ProcessedEvent) bool {
+		return e.EventID == "transaction-7-cancelled" && e.Service == "transaction-service"
+	})).Return(nil)
+
+	replayed, err := service.Handle(statusChangedEvent("transaction-7-cancelled", 7, "cancelled"), "transaction-service")
+
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	mockReservationRepo.AssertExpectations(t)
+	mockEventRepo.AssertExpectations(t)
+}
+
+func TestHandleEvent_ReplayedIsNotHandledAgain(t *testing.T) {
+	mockEventRepo := new(MockEventRepository)
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewEventService(mockEventRepo, mockReservationRepo)
+
+	mockEventRepo.On("IsProcessed", "transaction-7-expired").Return(true, nil)
+
+	replayed, err := service.Handle(statusChangedEvent("transaction-7-expired", 7, "expired"), "transaction-service")
+
+	assert.NoError(t, err)
+	assert.True(t, replayed)
+	mockReservationRepo.AssertNotCalled(t, "Release", mock.Anything)
+	mockEventRepo.AssertNotCalled(t, "MarkProcessed", mock.Anything)
+}
+
+func TestHandleEvent_NoReservationToRelease(t *testing.T) {
+	mockEventRepo := new(MockEventRepository)
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewEventService(mockEventRepo, mockReservationRepo)
+
+	mockEventRepo.On("IsProcessed", "transaction-7-expired").Return(false, nil)
+	mockReservationRepo.On("Release", uint(7)).Return(repository.ErrReservationNotFound)
+	mockEventRepo.On("MarkProcessed", mock.Anything).Return(nil)
+
+	replayed, err := service.Handle(statusChangedEvent("transaction-7-expired", 7, "expired"), "transaction-service")
+
+	assert.NoError(t, err)
+	assert.False(t, replayed)
+	mockEventRepo.AssertExpectations(t)
+}
+
+func TestHandleEvent_FailureIsNotRecorded(t *testing.T) {
+	mockEventRepo := new(MockEventRepository)
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewEventService(mockEventRepo, mockReservationRepo)
+
+	mockEventRepo.On("IsProcessed", "transaction-7-cancelled").Return(false, nil)
+	mockReservationRepo.On("Release", uint(7)).Return(errors.New("connection refused"))
+
+	_, err := service.Handle(statusChangedEvent("transaction-7-cancelled", 7, "cancelled"), "transaction-service")
+
+	assert.Error(t, err)
+	mockEventRepo.AssertNotCalled(t, "MarkProcessed", mock.Anything)
+}
+
+func TestHandleEvent_IgnoresOtherStatusesAndTypes(t *testing.T) {
+	mockEventRepo := new(MockEventRepository)
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewEventService(mockEventRepo, mockReservationRepo)
+
+	mockEventRepo.On("IsProcessed", mock.Anything).Return(false, nil)
+	mockEventRepo.On("MarkProcessed", mock.Anything).Return(nil).Twice()
+
+	_, err := service.Handle(statusChangedEvent("transaction-7-paid", 7, "paid"), "transaction-service")
+	assert.NoError(t, err)
+
+	_, err = service.Handle(&model.IncomingEvent{ID: "user-3-verified", Type: "user.verified"}, "auth-service")
+	assert.NoError(t, err)
+
+	mockReservationRepo.AssertNotCalled(t, "Release", mock.Anything)
+	mockEventRepo.AssertExpectations(t)
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"book-service/helpers"
+	"book-service/model"
+	"book-service/repository"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type ImportService interface {
+	Import(sellerID uint, r io.Reader, format string, dryRun bool) (*model.ImportResult, error)
+	GetJob(id uint, sellerID uint) (*model.ImportJob, error)
+	Export(sellerID uint, w io.Writer, format string) error
+}
+
+type importService struct {
+	bookRepo       repository.BookRepository
+	jobRepo        repository.ImportJobRepository
+	categoryRepo   repository.CategoryRepository
+	validator      echo.Validator
+	asyncThreshold int
+	chunkSize      int
+}
+
+// NewImportService creates the bulk import/export service. Files with more
+// than asyncThreshold rows are imported by a background job, chunkSize rows
+// at a time.
+func NewImportService(bookRepo repository.BookRepository, jobRepo repository.ImportJobRepository, categoryRepo repository.CategoryRepository, v echo.Validator, asyncThreshold int, chunkSize int) ImportService {
+	return &importService{
+		bookRepo:       bookRepo,
+		jobRepo:        jobRepo,
+		categoryRepo:   categoryRepo,
+		validator:      v,
+		asyncThreshold: asyncThreshold,
+		chunkSize:      chunkSize,
+	}
+}
+
+// Import validates every row of the file with the same rules as
+// POST /books. Nothing is written when any row is invalid or when dryRun is
+// set, so a seller can fix the whole file before importing it.
+func (s *importService) Import(sellerID uint, r io.Reader, format string, dryRun bool) (*model.ImportResult, error) {
+	rows, err := helpers.ReadSheet(r, format)
+	if err != nil {
+		return nil, errors.New("could not read import file")
+	}
+
+	books, rowErrors, err := s.parseRows(rows, sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ImportResult{
+		DryRun:    dryRun,
+		TotalRows: len(books) + countRows(rowErrors),
+		ValidRows: len(books),
+		Errors:    rowErrors,
+	}
+	if dryRun || len(rowErrors) > 0 || len(books) == 0 {
+		return result, nil
+	}
+
+	if len(books) <= s.asyncThreshold {
+		if err := s.bookRepo.CreateBatch(books); err != nil {
+			return nil, err
+		}
+		result.Created = len(books)
+		return result, nil
+	}
+
+	job := &model.ImportJob{
+		SellerID:  sellerID,
+		Status:    model.ImportPending,
+		TotalRows: len(books),
+	}
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, err
+	}
+
+	go s.runJob(job, books)
+
+	result.Job = job
+	return result, nil
+}
+
+func (s *importService) GetJob(id uint, sellerID uint) (*model.ImportJob, error) {
+	job, err := s.jobRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("import job not found")
+		}
+		return nil, err
+	}
+
+	if job.SellerID != sellerID {
+		return nil, errors.New("import job not found")
+	}
+	return job, nil
+}
+
+func (s *importService) Export(sellerID uint, w io.Writer, format string) error {
+	books, err := s.bookRepo.GetBySellerID(sellerID)
+	if err != nil {
+		return err
+	}
+
+	rows := [][]string{helpers.BookSheetHeader}
+	for _, book := range books {
+		rows = append(rows, []string{
+			book.Name,
+			book.Description,
+			book.Author,
+			strconv.Itoa(book.Stock),
+			strconv.FormatFloat(book.Costs, 'f', -1, 64),
+			book.Category,
+		})
+	}
+
+	return helpers.WriteSheet(w, format, rows)
+}
+
+// runJob inserts the books chunk by chunk, recording progress after each
+// chunk. Chunks already written stay written if a later one fails.
+func (s *importService) runJob(job *model.ImportJob, books []model.Book) {
+	job.Status = model.ImportRunning
+	s.saveJob(job)
+
+	for start := 0; start < len(books); start += s.chunkSize {
+		end := start + s.chunkSize
+		if end > len(books) {
+			end = len(books)
+		}
+
+		if err := s.bookRepo.CreateBatch(books[start:end]); err != nil {
+			job.Status = model.ImportFailed
+			job.Error = err.Error()
+			s.saveJob(job)
+			return
+		}
+
+		job.ProcessedRows = end
+		s.saveJob(job)
+	}
+
+	job.Status = model.ImportCompleted
+	s.saveJob(job)
+}
+
+func (s *importService) saveJob(job *model.ImportJob) {
+	if err := s.jobRepo.Update(job); err != nil {
+		log.Printf("Failed to update import job %d: %v", job.ID, err)
+	}
+}
+
+// parseRows maps each data row onto a CreateBookRequest using the header
+// row, then validates it. Blank rows are skipped.
+func (s *importService) parseRows(rows [][]string, sellerID uint) ([]model.Book, []model.ImportRowError, error) {
+	if len(rows) == 0 {
+		return nil, nil, errors.New("import file is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "costs"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("import file is missing the %s column", required)
+		}
+	}
+
+	var books []model.Book
+	rowErrors := []model.ImportRowError{}
+	categories := make(map[string]resolvedCategory)
+
+	for i, row := range rows[1:] {
+		rowNumber := i + 2
+		if isBlankRow(row) {
+			continue
+		}
+
+		value := func(column string) string {
+			index, ok := columns[column]
+			if !ok || index >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[index])
+		}
+
+		req := model.CreateBookRequest{
+			Name:        value("name"),
+			Description: value("description"),
+			Author:      value("author"),
+			Category:    value("category"),
+		}
+
+		var errs []model.ImportRowError
+		if stock := value("stock"); stock != "" {
+			parsed, err := strconv.Atoi(stock)
+			if err != nil {
+				errs = append(errs, model.ImportRowError{Row: rowNumber, Field: "stock", Error: "must be a whole number"})
+			}
+			req.Stock = parsed
+		}
+		if costs := value("costs"); costs != "" {
+			parsed, err := strconv.ParseFloat(costs, 64)
+			if err != nil {
+				errs = append(errs, model.ImportRowError{Row: rowNumber, Field: "costs", Error: "must be a number"})
+			}
+			req.Costs = parsed
+		}
+
+		if len(errs) == 0 {
+			errs = s.validate(rowNumber, &req)
+		}
+		if len(errs) > 0 {
+			rowErrors = append(rowErrors, errs...)
+			continue
+		}
+
+		category, ok := categories[req.Category]
+		if !ok {
+			id, name, err := resolveCategory(s.categoryRepo, nil, req.Category)
+			if err != nil {
+				return nil, nil, err
+			}
+			category = resolvedCategory{id: id, name: name}
+			categories[req.Category] = category
+		}
+
+		books = append(books, model.Book{
+			SellerID:    sellerID,
+			Name:        req.Name,
+			Description: req.Description,
+			Author:      req.Author,
+			Stock:       req.Stock,
+			Costs:       req.Costs,
+			Category:    category.name,
+			CategoryID:  category.id,
+			Status:      model.StockStatus(model.StatusActive, req.Stock),
+		})
+	}
+
+	return books, rowErrors, nil
+}
+
+// resolvedCategory caches resolveCategory results so a file with many rows
+// in the same category looks it up only once.
+type resolvedCategory struct {
+	id   *uint
+	name string
+}
+
+func (s *importService) validate(rowNumber int, req *model.CreateBookRequest) []model.ImportRowError {
+	err := s.validator.Validate(req)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []model.ImportRowError{{Row: rowNumber, Error: err.Error()}}
+	}
+
+	var rowErrors []model.ImportRowError
+	for _, fieldError := range validationErrors {
+		rowErrors = append(rowErrors, model.ImportRowError{
+			Row:   rowNumber,
+			Field: strings.ToLower(fieldError.Field()),
+			Error: fmt.Sprintf("failed on the '%s' rule", fieldError.Tag()),
+		})
+	}
+	return rowErrors
+}
+
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// countRows returns the number of distinct rows that have errors.
+func countRows(rowErrors []model.ImportRowError) int {
+	rows := make(map[int]bool)
+	for _, rowError := range rowErrors {
+		rows[rowError.Row] = true
+	}
+	return len(rows)
+}
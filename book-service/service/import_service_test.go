@@ -0,0 +1,129 @@
+package service
+
+import (
+	"book-service/config"
+	"book-service/helpers"
+	"book-service/model"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockImportJobRepository struct {
+	mock.Mock
+}
+
+func (m *MockImportJobRepository) Create(job *model.ImportJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockImportJobRepository) Update(job *model.ImportJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockImportJobRepository) GetByID(id uint) (*model.ImportJob, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ImportJob), args.Error(1)
+}
+
+const importCSV = `name,description,author,stock,costs,category
+Laskar Pelangi,Used,Andrea Hirata,2,45000,Fiction
+Bumi Manusia,Good condition,Pramoedya,1,60000,Fiction
+`
+
+func TestImport_CreatesBooksForValidFile(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewImportService(mockRepo, new(MockImportJobRepository), newMockCategoryRepository(), config.NewValidator(), 100, 50)
+
+	mockRepo.On("CreateBatch", mock.MatchedBy(func(books []model.Book) bool {
+		return len(books) == 2 && books[0].SellerID == 7 && books[1].Name == "Bumi Manusia"
+	})).Return(nil)
+
+	result, err := service.Import(7, strings.NewReader(importCSV), helpers.FormatCSV, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Created)
+	assert.Empty(t, result.Errors)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImport_DryRunReportsRowErrors(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewImportService(mockRepo, new(MockImportJobRepository), newMockCategoryRepository(), config.NewValidator(), 100, 50)
+
+	file := `name,stock,costs
+,1,1000
+Valid Book,1,1000
+Bad Stock,many,1000
+`
+
+	result, err := service.Import(7, strings.NewReader(file), helpers.FormatCSV, true)
+
+	assert.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 3, result.TotalRows)
+	assert.Equal(t, 1, result.ValidRows)
+	assert.Equal(t, []model.ImportRowError{
+		{Row: 2, Field: "name", Error: "failed on the 'required' rule"},
+		{Row: 4, Field: "stock", Error: "must be a whole number"},
+	}, result.Errors)
+	mockRepo.AssertNotCalled(t, "CreateBatch", mock.Anything)
+}
+
+func TestImport_MissingRequiredColumn(t *testing.T) {
+	service := NewImportService(new(MockBookRepository), new(MockImportJobRepository), newMockCategoryRepository(), config.NewValidator(), 100, 50)
+
+	result, err := service.Import(7, strings.NewReader("name,stock\nBook,1\n"), helpers.FormatCSV, false)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, "import file is missing the costs column", err.Error())
+}
+
+func TestImport_LargeFileStartsJob(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockJobRepo := new(MockImportJobRepository)
+	service := NewImportService(mockRepo, mockJobRepo, newMockCategoryRepository(), config.NewValidator(), 1, 1)
+
+	done := make(chan struct{})
+	mockJobRepo.On("Create", mock.AnythingOfType("*model.ImportJob")).Return(nil)
+	mockJobRepo.On("Update", mock.AnythingOfType("*model.ImportJob")).Return(nil).Run(func(args mock.Arguments) {
+		if args.Get(0).(*model.ImportJob).Status == model.ImportCompleted {
+			close(done)
+		}
+	})
+	mockRepo.On("CreateBatch", mock.Anything).Return(nil).Twice()
+
+	result, err := service.Import(7, strings.NewReader(importCSV), helpers.FormatCSV, false)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, result.Job)
+	assert.Equal(t, 2, result.Job.TotalRows)
+
+	<-done
+	assert.Equal(t, 2, result.Job.ProcessedRows)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestExport_WritesImportableCSV(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewImportService(mockRepo, new(MockImportJobRepository), newMockCategoryRepository(), config.NewValidator(), 100, 50)
+
+	mockRepo.On("GetBySellerID", uint(7)).Return([]model.Book{
+		{ID: 1, SellerID: 7, Name: "Laskar Pelangi", Description: "Used", Author: "Andrea Hirata", Stock: 2, Costs: 45000, Category: "Fiction"},
+	}, nil)
+
+	var buf bytes.Buffer
+	err := service.Export(7, &buf, helpers.FormatCSV)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "name,description,author,stock,costs,category\nLaskar Pelangi,Used,Andrea Hirata,2,45000,Fiction\n", buf.String())
+}
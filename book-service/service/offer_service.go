@@ -0,0 +1,260 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/policy"
+	"book-service/repository"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OfferService interface {
+	MakeOffer(bookID uint, buyerID uint, req *model.CreateOfferRequest) (*model.Offer, error)
+	CounterOffer(id uint, userID uint, req *model.CounterOfferRequest) (*model.Offer, error)
+	AcceptOffer(id uint, userID uint) (*model.Offer, error)
+	RejectOffer(id uint, userID uint) (*model.Offer, error)
+	GetOffers(actor policy.Actor, received bool) ([]model.Offer, error)
+	GetOffer(id uint, userID uint) (*model.Offer, error)
+	GetAcceptedOffer(bookID uint, buyerID uint) (*model.Offer, error)
+	RedeemOffer(id uint, buyerID uint, req *model.RedeemOfferRequest) (*model.Offer, error)
+	ExpireOverdue() (int64, error)
+}
+
+type offerService struct {
+	offerRepo       repository.OfferRepository
+	bookRepo        repository.BookRepository
+	reservationRepo repository.ReservationRepository
+	responseTTL     time.Duration
+	acceptedTTL     time.Duration
+	now             func() time.Time
+}
+
+// NewOfferService creates the offer service. Each side has responseTTL to
+// answer an offer or counter-offer, and an accepted price can be used for
+// acceptedTTL.
+func NewOfferService(offerRepo repository.OfferRepository, bookRepo repository.BookRepository, reservationRepo repository.ReservationRepository, responseTTL time.Duration, acceptedTTL time.Duration) OfferService {
+	return &offerService{
+		offerRepo:       offerRepo,
+		bookRepo:        bookRepo,
+		reservationRepo: reservationRepo,
+		responseTTL:     responseTTL,
+		acceptedTTL:     acceptedTTL,
+		now:             time.Now,
+	}
+}
+
+// MakeOffer proposes a price below the listing price for an active book,
+// for no more copies than are not held for other buyers. A buyer can have
+// only one open offer per book.
+func (s *offerService) MakeOffer(bookID uint, buyerID uint, req *model.CreateOfferRequest) (*model.Offer, error) {
+	book, err := s.bookRepo.GetByID(bookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("book not found")
+		}
+		return nil, err
+	}
+
+	if book.SellerID == buyerID {
+		return nil, errors.New("cannot make an offer on your own book")
+	}
+	if book.Status != model.StatusActive {
+		return nil, repository.ErrBookNotAvailable
+	}
+	reserved, err := s.reservationRepo.SumActiveByBookIDs([]uint{bookID})
+	if err != nil {
+		return nil, err
+	}
+	if req.Quantity > book.Stock-reserved[bookID] {
+		return nil, repository.ErrInsufficientStock
+	}
+	if req.Price >= book.Costs {
+		return nil, errors.New("offer price must be below the listing price")
+	}
+
+	now := s.now()
+	if _, err := s.offerRepo.FindOpen(bookID, buyerID, now); err == nil {
+		return nil, errors.New("offer already open")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	offer := &model.Offer{
+		BookID:    bookID,
+		BuyerID:   buyerID,
+		SellerID:  book.SellerID,
+		Price:     req.Price,
+		Quantity:  req.Quantity,
+		Status:    model.OfferPending,
+		ExpiresAt: now.Add(s.responseTTL),
+	}
+	if err := s.offerRepo.Create(offer); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+// CounterOffer answers an open offer with a different price. The seller
+// counters a pending offer and the buyer a countered one, so the two can
+// go back and forth until one side accepts or rejects.
+func (s *offerService) CounterOffer(id uint, userID uint, req *model.CounterOfferRequest) (*model.Offer, error) {
+	offer, err := s.openOfferAwaiting(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := offer.Status
+	if from == model.OfferPending {
+		offer.Status = model.OfferCountered
+	} else {
+		offer.Status = model.OfferPending
+	}
+	offer.Price = req.Price
+	offer.ExpiresAt = s.now().Add(s.responseTTL)
+
+	if err := s.offerRepo.Transition(offer, from); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+// AcceptOffer agrees to the current price. From then on the buyer pays
+// that price for up to the offered quantity until the offer expires.
+func (s *offerService) AcceptOffer(id uint, userID uint) (*model.Offer, error) {
+	offer, err := s.openOfferAwaiting(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := offer.Status
+	offer.Status = model.OfferAccepted
+	offer.ExpiresAt = s.now().Add(s.acceptedTTL)
+
+	if err := s.offerRepo.Transition(offer, from); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+func (s *offerService) RejectOffer(id uint, userID uint) (*model.Offer, error) {
+	offer, err := s.openOfferAwaiting(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := offer.Status
+	offer.Status = model.OfferRejected
+
+	if err := s.offerRepo.Transition(offer, from); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+// GetOffers returns the offers the actor made as a buyer, or with received
+// the ones made on its books, which only sellers have.
+func (s *offerService) GetOffers(actor policy.Actor, received bool) ([]model.Offer, error) {
+	if !received {
+		return s.offerRepo.GetByBuyerID(actor.UserID)
+	}
+	if !policy.CanReceiveOffers(actor) {
+		return nil, errors.New("unauthorized: only sellers receive offers")
+	}
+	return s.offerRepo.GetBySellerID(actor.UserID)
+}
+
+func (s *offerService) GetOffer(id uint, userID uint) (*model.Offer, error) {
+	offer, err := s.getOffer(id)
+	if err != nil {
+		return nil, err
+	}
+	if offer.BuyerID != userID && offer.SellerID != userID {
+		return nil, errors.New("offer not found")
+	}
+	return offer, nil
+}
+
+// GetAcceptedOffer returns the price the buyer has agreed with the seller
+// for the book, if any. transaction-service uses it when pricing a
+// purchase.
+func (s *offerService) GetAcceptedOffer(bookID uint, buyerID uint) (*model.Offer, error) {
+	offer, err := s.offerRepo.FindAccepted(bookID, buyerID, s.now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("offer not found")
+		}
+		return nil, err
+	}
+	return offer, nil
+}
+
+// RedeemOffer marks an accepted offer as used by a transaction so its
+// price cannot be applied twice.
+func (s *offerService) RedeemOffer(id uint, buyerID uint, req *model.RedeemOfferRequest) (*model.Offer, error) {
+	offer, err := s.getOffer(id)
+	if err != nil {
+		return nil, err
+	}
+	if offer.BuyerID != buyerID {
+		return nil, errors.New("offer not found")
+	}
+	if offer.Status != model.OfferAccepted {
+		return nil, errors.New("offer is not accepted")
+	}
+	if !offer.ExpiresAt.After(s.now()) {
+		return nil, errors.New("offer has expired")
+	}
+
+	offer.Status = model.OfferRedeemed
+	offer.TransactionID = &req.TransactionID
+
+	if err := s.offerRepo.Transition(offer, model.OfferAccepted); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+func (s *offerService) ExpireOverdue() (int64, error) {
+	return s.offerRepo.ExpireOverdue(s.now())
+}
+
+// openOfferAwaiting loads an unexpired open offer that is waiting for
+// userID to respond: the seller for a pending offer, the buyer for a
+// countered one.
+func (s *offerService) openOfferAwaiting(id uint, userID uint) (*model.Offer, error) {
+	offer, err := s.GetOffer(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var awaiting uint
+	switch offer.Status {
+	case model.OfferPending:
+		awaiting = offer.SellerID
+	case model.OfferCountered:
+		awaiting = offer.BuyerID
+	default:
+		return nil, errors.New("offer is closed")
+	}
+
+	if !offer.ExpiresAt.After(s.now()) {
+		return nil, errors.New("offer has expired")
+	}
+	if awaiting != userID {
+		return nil, errors.New("offer is awaiting the other party")
+	}
+	return offer, nil
+}
+
+func (s *offerService) getOffer(id uint) (*model.Offer, error) {
+	offer, err := s.offerRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("offer not found")
+		}
+		return nil, err
+	}
+	return offer, nil
+}
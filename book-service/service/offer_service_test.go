@@ -0,0 +1,283 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/policy"
+	"book-service/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockOfferRepository struct {
+	mock.Mock
+}
+
+func (m *MockOfferRepository) Create(offer *model.Offer) error {
+	args := m.Called(offer)
+	return args.Error(0)
+}
+
+func (m *MockOfferRepository) GetByID(id uint) (*model.Offer, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Offer), args.Error(1)
+}
+
+func (m *MockOfferRepository) GetByBuyerID(buyerID uint) ([]model.Offer, error) {
+	args := m.Called(buyerID)
+	return args.Get(0).([]model.Offer), args.Error(1)
+}
+
+func (m *MockOfferRepository) GetBySellerID(sellerID uint) ([]model.Offer, error) {
+	args := m.Called(sellerID)
+	return args.Get(0).([]model.Offer), args.Error(1)
+}
+
+func (m *MockOfferRepository) FindOpen(bookID uint, buyerID uint, now time.Time) (*model.Offer, error) {
+	args := m.Called(bookID, buyerID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Offer), args.Error(1)
+}
+
+func (m *MockOfferRepository) FindAccepted(bookID uint, buyerID uint, now time.Time) (*model.Offer, error) {
+	args := m.Called(bookID, buyerID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Offer), args.Error(1)
+}
+
+func (m *MockOfferRepository) Transition(offer *model.Offer, from string) error {
+	args := m.Called(offer, from)
+	return args.Error(0)
+}
+
+func (m *MockOfferRepository) ExpireOverdue(now time.Time) (int64, error) {
+	args := m.Called(now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+var offerNow = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestOfferService(offerRepo *MockOfferRepository, bookRepo *MockBookRepository) *offerService {
+	return newTestOfferServiceWithReservations(offerRepo, bookRepo, newMockReservationRepository())
+}
+
+func newTestOfferServiceWithReservations(offerRepo *MockOfferRepository, bookRepo *MockBookRepository, reservationRepo *MockReservationRepository) *offerService {
+	s := NewOfferService(offerRepo, bookRepo, reservationRepo, 48*time.Hour, 24*time.Hour).(*offerService)
+	s.now = func() time.Time { return offerNow }
+	return s
+}
+
+func TestMakeOffer_Success(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	mockRepo := new(MockBookRepository)
+	service := newTestOfferService(mockOfferRepo, mockRepo)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 9, Stock: 3, Costs: 50, Status: model.StatusActive}, nil)
+	mockOfferRepo.On("FindOpen", uint(1), uint(2), offerNow).Return(nil, gorm.ErrRecordNotFound)
+	mockOfferRepo.On("Create", mock.AnythingOfType("*model.Offer")).Return(nil)
+
+	offer, err := service.MakeOffer(1, 2, &model.CreateOfferRequest{Price: 40, Quantity: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), offer.SellerID)
+	assert.Equal(t, model.OfferPending, offer.Status)
+	assert.Equal(t, offerNow.Add(48*time.Hour), offer.ExpiresAt)
+}
+
+func TestMakeOffer_Rejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		book    model.Book
+		req     model.CreateOfferRequest
+		wantErr string
+	}{
+		{"own book", model.Book{ID: 1, SellerID: 2, Stock: 3, Costs: 50, Status: model.StatusActive}, model.CreateOfferRequest{Price: 40, Quantity: 1}, "cannot make an offer on your own book"},
+		{"paused book", model.Book{ID: 1, SellerID: 9, Stock: 3, Costs: 50, Status: model.StatusPaused}, model.CreateOfferRequest{Price: 40, Quantity: 1}, "book is not available"},
+		{"more than stock", model.Book{ID: 1, SellerID: 9, Stock: 1, Costs: 50, Status: model.StatusActive}, model.CreateOfferRequest{Price: 40, Quantity: 2}, "insufficient stock"},
+		{"not a discount", model.Book{ID: 1, SellerID: 9, Stock: 3, Costs: 50, Status: model.StatusActive}, model.CreateOfferRequest{Price: 50, Quantity: 1}, "offer price must be below the listing price"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockOfferRepo := new(MockOfferRepository)
+			mockRepo := new(MockBookRepository)
+			service := newTestOfferService(mockOfferRepo, mockRepo)
+
+			book := tt.book
+			mockRepo.On("GetByID", uint(1)).Return(&book, nil)
+
+			offer, err := service.MakeOffer(1, 2, &tt.req)
+
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, offer)
+			mockOfferRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestMakeOffer_AlreadyOpen(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	mockRepo := new(MockBookRepository)
+	service := newTestOfferService(mockOfferRepo, mockRepo)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 9, Stock: 3, Costs: 50, Status: model.StatusActive}, nil)
+	mockOfferRepo.On("FindOpen", uint(1), uint(2), offerNow).Return(&model.Offer{ID: 5}, nil)
+
+	offer, err := service.MakeOffer(1, 2, &model.CreateOfferRequest{Price: 40, Quantity: 1})
+
+	assert.EqualError(t, err, "offer already open")
+	assert.Nil(t, offer)
+}
+
+func TestCounterOffer_AlternatesBetweenParties(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	service := newTestOfferService(mockOfferRepo, new(MockBookRepository))
+
+	offer := &model.Offer{ID: 5, BuyerID: 2, SellerID: 9, Price: 40, Status: model.OfferPending, ExpiresAt: offerNow.Add(time.Hour)}
+	mockOfferRepo.On("GetByID", uint(5)).Return(offer, nil)
+	mockOfferRepo.On("Transition", offer, model.OfferPending).Return(nil).Once()
+	mockOfferRepo.On("Transition", offer, model.OfferCountered).Return(nil).Once()
+
+	_, err := service.CounterOffer(5, 2, &model.CounterOfferRequest{Price: 45})
+	assert.EqualError(t, err, "offer is awaiting the other party")
+
+	countered, err := service.CounterOffer(5, 9, &model.CounterOfferRequest{Price: 46})
+	assert.NoError(t, err)
+	assert.Equal(t, model.OfferCountered, countered.Status)
+	assert.Equal(t, 46.0, countered.Price)
+
+	again, err := service.CounterOffer(5, 2, &model.CounterOfferRequest{Price: 44})
+	assert.NoError(t, err)
+	assert.Equal(t, model.OfferPending, again.Status)
+	mockOfferRepo.AssertExpectations(t)
+}
+
+func TestAcceptOffer_StartsPriceWindow(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	service := newTestOfferService(mockOfferRepo, new(MockBookRepository))
+
+	offer := &model.Offer{ID: 5, BuyerID: 2, SellerID: 9, Status: model.OfferCountered, ExpiresAt: offerNow.Add(time.Hour)}
+	mockOfferRepo.On("GetByID", uint(5)).Return(offer, nil)
+	mockOfferRepo.On("Transition", offer, model.OfferCountered).Return(nil)
+
+	accepted, err := service.AcceptOffer(5, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.OfferAccepted, accepted.Status)
+	assert.Equal(t, offerNow.Add(24*time.Hour), accepted.ExpiresAt)
+}
+
+func TestAcceptOffer_Expired(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	service := newTestOfferService(mockOfferRepo, new(MockBookRepository))
+
+	mockOfferRepo.On("GetByID", uint(5)).Return(&model.Offer{ID: 5, BuyerID: 2, SellerID: 9, Status: model.OfferPending, ExpiresAt: offerNow}, nil)
+
+	offer, err := service.AcceptOffer(5, 9)
+
+	assert.EqualError(t, err, "offer has expired")
+	assert.Nil(t, offer)
+	mockOfferRepo.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything)
+}
+
+func TestGetOffer_HiddenFromOthers(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	service := newTestOfferService(mockOfferRepo, new(MockBookRepository))
+
+	mockOfferRepo.On("GetByID", uint(5)).Return(&model.Offer{ID: 5, BuyerID: 2, SellerID: 9}, nil)
+
+	offer, err := service.GetOffer(5, 3)
+
+	assert.EqualError(t, err, "offer not found")
+	assert.Nil(t, offer)
+}
+
+func TestRedeemOffer_Success(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	service := newTestOfferService(mockOfferRepo, new(MockBookRepository))
+
+	offer := &model.Offer{ID: 5, BuyerID: 2, SellerID: 9, Status: model.OfferAccepted, ExpiresAt: offerNow.Add(time.Hour)}
+	mockOfferRepo.On("GetByID", uint(5)).Return(offer, nil)
+	mockOfferRepo.On("Transition", offer, model.OfferAccepted).Return(nil)
+
+	redeemed, err := service.RedeemOffer(5, 2, &model.RedeemOfferRequest{TransactionID: 77})
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.OfferRedeemed, redeemed.Status)
+	assert.Equal(t, uint(77), *redeemed.TransactionID)
+}
+
+func TestRedeemOffer_RedeemedConcurrently(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	service := newTestOfferService(mockOfferRepo, new(MockBookRepository))
+
+	offer := &model.Offer{ID: 5, BuyerID: 2, SellerID: 9, Status: model.OfferAccepted, ExpiresAt: offerNow.Add(time.Hour)}
+	mockOfferRepo.On("GetByID", uint(5)).Return(offer, nil)
+	mockOfferRepo.On("Transition", offer, model.OfferAccepted).Return(repository.ErrOfferChanged)
+
+	redeemed, err := service.RedeemOffer(5, 2, &model.RedeemOfferRequest{TransactionID: 77})
+
+	assert.ErrorIs(t, err, repository.ErrOfferChanged)
+	assert.Nil(t, redeemed)
+}
+
+func TestGetAcceptedOffer_None(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	service := newTestOfferService(mockOfferRepo, new(MockBookRepository))
+
+	mockOfferRepo.On("FindAccepted", uint(1), uint(2), offerNow).Return(nil, gorm.ErrRecordNotFound)
+
+	offer, err := service.GetAcceptedOffer(1, 2)
+
+	assert.EqualError(t, err, "offer not found")
+	assert.Nil(t, offer)
+}
+
+func TestMakeOffer_StockHeldForOthers(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	mockRepo := new(MockBookRepository)
+	mockReservationRepo := new(MockReservationRepository)
+	service := newTestOfferServiceWithReservations(mockOfferRepo, mockRepo, mockReservationRepo)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 9, Stock: 3, Costs: 50, Status: model.StatusActive}, nil)
+	mockReservationRepo.On("SumActiveByBookIDs", []uint{1}).Return(map[uint]int{1: 2}, nil)
+
+	offer, err := service.MakeOffer(1, 2, &model.CreateOfferRequest{Price: 40, Quantity: 2})
+
+	assert.EqualError(t, err, "insufficient stock")
+	assert.Nil(t, offer)
+	mockOfferRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestGetOffers(t *testing.T) {
+	mockOfferRepo := new(MockOfferRepository)
+	service := newTestOfferService(mockOfferRepo, new(MockBookRepository))
+
+	made := []model.Offer{{ID: 1, BuyerID: 2}}
+	received := []model.Offer{{ID: 2, SellerID: 9}}
+	mockOfferRepo.On("GetByBuyerID", uint(2)).Return(made, nil)
+	mockOfferRepo.On("GetBySellerID", uint(9)).Return(received, nil)
+
+	offers, err := service.GetOffers(policy.Actor{UserID: 2, Role: policy.RoleBuyer}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, made, offers)
+
+	offers, err = service.GetOffers(seller(9), true)
+	assert.NoError(t, err)
+	assert.Equal(t, received, offers)
+
+	offers, err = service.GetOffers(policy.Actor{UserID: 2, Role: policy.RoleBuyer}, true)
+	assert.EqualError(t, err, "unauthorized: only sellers receive offers")
+	assert.Nil(t, offers)
+	mockOfferRepo.AssertNotCalled(t, "GetBySellerID", uint(2))
+}
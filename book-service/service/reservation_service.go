@@ -0,0 +1,88 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/repository"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReservationService interface {
+	Reserve(bookID uint, req *model.CreateReservationRequest) (*model.Reservation, error)
+	Confirm(transactionID uint) ([]model.Reservation, error)
+	Release(transactionID uint) error
+	ExpireOverdue() (int64, error)
+}
+
+type reservationService struct {
+	reservationRepo repository.ReservationRepository
+	bookRepo        repository.BookRepository
+	historyRepo     repository.BookHistoryRepository
+	listener        BookChangeListener
+	defaultTTL      time.Duration
+}
+
+// NewReservationService creates the service holding stock for pending
+// transactions. listener may be nil; when set it is told about every book
+// whose stock a confirmed reservation deducted.
+func NewReservationService(reservationRepo repository.ReservationRepository, bookRepo repository.BookRepository, historyRepo repository.BookHistoryRepository, listener BookChangeListener, defaultTTL time.Duration) ReservationService {
+	return &reservationService{
+		reservationRepo: reservationRepo,
+		bookRepo:        bookRepo,
+		historyRepo:     historyRepo,
+		listener:        listener,
+		defaultTTL:      defaultTTL,
+	}
+}
+
+func (s *reservationService) Reserve(bookID uint, req *model.CreateReservationRequest) (*model.Reservation, error) {
+	if req.Quantity <= 0 {
+		return nil, errors.New("reservation quantity must be greater than 0")
+	}
+
+	ttl := s.defaultTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+
+	reservation, err := s.reservationRepo.Reserve(bookID, req.TransactionID, req.Quantity, time.Now().Add(ttl))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("book not found")
+		}
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// Confirm deducts the stock held for a paid transaction. The deduction is
+// recorded in each book's history like any other sale.
+func (s *reservationService) Confirm(transactionID uint) ([]model.Reservation, error) {
+	reservations, movements, err := s.reservationRepo.Confirm(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invalidator, ok := s.bookRepo.(repository.BookCacheInvalidator); ok {
+		for _, reservation := range reservations {
+			invalidator.InvalidateBook(reservation.BookID)
+		}
+	}
+	for _, movement := range movements {
+		recordChange(s.historyRepo, movement.After.ID, 0, model.ChangeStockDeducted, bookChanges(movement.Before, movement.After))
+		if s.listener != nil {
+			s.listener.BookChanged(movement.Before, movement.After)
+		}
+	}
+	return reservations, nil
+}
+
+func (s *reservationService) Release(transactionID uint) error {
+	return s.reservationRepo.Release(transactionID)
+}
+
+func (s *reservationService) ExpireOverdue() (int64, error) {
+	return s.reservationRepo.ExpireOverdue(time.Now())
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type invalidatingBookRepository struct {
+	MockBookRepository
+	invalidated []uint
+}
+
+func (r *invalidatingBookRepository) InvalidateBook(id uint) {
+	r.invalidated = append(r.invalidated, id)
+}
+
+func TestReserve_UsesDefaultTTL(t *testing.T) {
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewReservationService(mockReservationRepo, new(MockBookRepository), newMockBookHistoryRepository(), nil, 30*time.Minute)
+
+	req := &model.CreateReservationRequest{TransactionID: 7, Quantity: 2}
+	expected := &model.Reservation{ID: 1, BookID: 1, TransactionID: 7, Quantity: 2, Status: model.ReservationActive}
+
+	mockReservationRepo.On("Reserve", uint(1), uint(7), 2, mock.MatchedBy(func(expiresAt time.Time) bool {
+		return expiresAt.After(time.Now().Add(29*time.Minute)) && expiresAt.Before(time.Now().Add(31*time.Minute))
+	})).Return(expected, nil)
+
+	result, err := service.Reserve(1, req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, result)
+	mockReservationRepo.AssertExpectations(t)
+}
+
+func TestReserve_BookNotFound(t *testing.T) {
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewReservationService(mockReservationRepo, new(MockBookRepository), newMockBookHistoryRepository(), nil, time.Minute)
+
+	req := &model.CreateReservationRequest{TransactionID: 7, Quantity: 1, TTLSeconds: 60}
+	mockReservationRepo.On("Reserve", uint(1), uint(7), 1, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+
+	result, err := service.Reserve(1, req)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, "book not found", err.Error())
+}
+
+func TestReserve_InsufficientStock(t *testing.T) {
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewReservationService(mockReservationRepo, new(MockBookRepository), newMockBookHistoryRepository(), nil, time.Minute)
+
+	req := &model.CreateReservationRequest{TransactionID: 7, Quantity: 3}
+	mockReservationRepo.On("Reserve", uint(1), uint(7), 3, mock.Anything).Return(nil, repository.ErrInsufficientStock)
+
+	result, err := service.Reserve(1, req)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, "insufficient stock", err.Error())
+}
+
+func TestConfirm_InvalidatesCachedBooks(t *testing.T) {
+	mockReservationRepo := new(MockReservationRepository)
+	bookRepo := new(invalidatingBookRepository)
+	service := NewReservationService(mockReservationRepo, bookRepo, newMockBookHistoryRepository(), nil, time.Minute)
+
+	confirmed := []model.Reservation{
+		{ID: 1, BookID: 3, TransactionID: 7, Quantity: 1, Status: model.ReservationConfirmed},
+	}
+	mockReservationRepo.On("Confirm", uint(7)).Return(confirmed, nil, nil)
+
+	result, err := service.Confirm(7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, confirmed, result)
+	assert.Equal(t, []uint{3}, bookRepo.invalidated)
+}
+
+func TestConfirm_RecordsStockDeduction(t *testing.T) {
+	mockReservationRepo := new(MockReservationRepository)
+	historyRepo := new(MockBookHistoryRepository)
+	listener := &recordingListener{}
+	service := NewReservationService(mockReservationRepo, new(MockBookRepository), historyRepo, listener, time.Minute)
+
+	confirmed := []model.Reservation{
+		{ID: 1, BookID: 3, TransactionID: 7, Quantity: 1, Status: model.ReservationConfirmed},
+	}
+	before := model.Book{ID: 3, Stock: 1, Status: model.StatusActive, Version: 2}
+	after := model.Book{ID: 3, Stock: 0, Status: model.StatusSoldOut, Version: 3}
+	mockReservationRepo.On("Confirm", uint(7)).Return(confirmed, []repository.StockMovement{{Before: before, After: after}}, nil)
+	historyRepo.On("Record", mock.MatchedBy(func(change *model.BookChange) bool {
+		return change.BookID == 3 && change.Action == model.ChangeStockDeducted && len(change.Fields) > 0
+	})).Return(nil).Once()
+
+	_, err := service.Confirm(7)
+
+	assert.NoError(t, err)
+	historyRepo.AssertExpectations(t)
+	assert.Equal(t, 1, listener.calls)
+	assert.Equal(t, after, listener.after)
+}
+
+func TestConfirm_RepeatRecordsNothing(t *testing.T) {
+	mockReservationRepo := new(MockReservationRepository)
+	historyRepo := new(MockBookHistoryRepository)
+	service := NewReservationService(mockReservationRepo, new(MockBookRepository), historyRepo, nil, time.Minute)
+
+	confirmed := []model.Reservation{
+		{ID: 1, BookID: 3, TransactionID: 7, Quantity: 1, Status: model.ReservationConfirmed},
+	}
+	mockReservationRepo.On("Confirm", uint(7)).Return(confirmed, nil, nil)
+
+	_, err := service.Confirm(7)
+
+	assert.NoError(t, err)
+	historyRepo.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestRelease_NotFound(t *testing.T) {
+	mockReservationRepo := new(MockReservationRepository)
+	service := NewReservationService(mockReservationRepo, new(MockBookRepository), newMockBookHistoryRepository(), nil, time.Minute)
+
+	mockReservationRepo.On("Release", uint(7)).Return(repository.ErrReservationNotFound)
+
+	err := service.Release(7)
+
+	assert.Error(t, err)
+	assert.Equal(t, "reservation not found", err.Error())
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"book-service/clients"
+	"book-service/model"
+	"book-service/repository"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sellerProfileReviews is how many recent reviews a seller profile shows.
+const sellerProfileReviews = 20
+
+type ReviewService interface {
+	CreateReview(buyerID uint, req *model.CreateReviewRequest, authorization string) (*model.Review, error)
+	ReplyToReview(id uint, sellerID uint, req *model.ReplyReviewRequest) (*model.Review, error)
+	ReportReview(id uint, reporterID uint, req *model.ReportReviewRequest) error
+	ModerateReview(id uint, req *model.ModerateReviewRequest) (*model.ReportedReview, error)
+	GetBookReviews(bookID uint) ([]model.Review, error)
+	GetReportedReviews() ([]model.ReportedReview, error)
+	GetSellerProfile(sellerID uint) (*model.SellerProfile, error)
+}
+
+type reviewService struct {
+	reviewRepo        repository.ReviewRepository
+	bookRepo          repository.BookRepository
+	transactionClient clients.TransactionClient
+	hideThreshold     int
+}
+
+// NewReviewService creates the review service. A review is hidden
+// automatically once hideThreshold users have reported it.
+func NewReviewService(reviewRepo repository.ReviewRepository, bookRepo repository.BookRepository, transactionClient clients.TransactionClient, hideThreshold int) ReviewService {
+	return &reviewService{
+		reviewRepo:        reviewRepo,
+		bookRepo:          bookRepo,
+		transactionClient: transactionClient,
+		hideThreshold:     hideThreshold,
+	}
+}
+
+// CreateReview lets a buyer review the seller of a completed purchase. The
+// transaction is looked up in transaction-service with the buyer's own
+// credentials, so only the buyer can review it. The reviewed book, by
+// default the transaction's first, must be one of its items, and the seller
+// is the one it was bought from rather than whoever lists the book now.
+func (s *reviewService) CreateReview(buyerID uint, req *model.CreateReviewRequest, authorization string) (*model.Review, error) {
+	transaction, err := s.transactionClient.GetTransaction(req.TransactionID, authorization)
+	if err != nil {
+		if errors.Is(err, clients.ErrTransactionNotFound) {
+			return nil, errors.New("transaction not found")
+		}
+		return nil, err
+	}
+	if transaction.UserID != buyerID {
+		return nil, errors.New("transaction not found")
+	}
+	if !transaction.Paid() {
+		return nil, errors.New("transaction is not completed")
+	}
+
+	bookID := req.BookID
+	if bookID == 0 {
+		bookID = transaction.BookID
+	}
+	item, ok := transaction.Item(bookID)
+	if !ok {
+		return nil, errors.New("book is not part of the transaction")
+	}
+	if item.SellerID == 0 {
+		return nil, errors.New("seller not found")
+	}
+
+	review := &model.Review{
+		TransactionID: transaction.TransactionID,
+		BookID:        item.BookID,
+		SellerID:      item.SellerID,
+		BuyerID:       buyerID,
+		Rating:        req.Rating,
+		BookRating:    req.BookRating,
+		Comment:       req.Comment,
+	}
+	if err := s.reviewRepo.Create(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *reviewService) ReplyToReview(id uint, sellerID uint, req *model.ReplyReviewRequest) (*model.Review, error) {
+	review, err := s.getReview(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if review.SellerID != sellerID {
+		return nil, errors.New("unauthorized: you can only reply to reviews of your own sales")
+	}
+
+	now := time.Now()
+	review.Reply = req.Reply
+	review.RepliedAt = &now
+	if err := s.reviewRepo.Update(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *reviewService) ReportReview(id uint, reporterID uint, req *model.ReportReviewRequest) error {
+	if _, err := s.getReview(id); err != nil {
+		return err
+	}
+
+	report := &model.ReviewReport{
+		ReviewID:   id,
+		ReporterID: reporterID,
+		Reason:     req.Reason,
+	}
+	return s.reviewRepo.Report(report, s.hideThreshold)
+}
+
+// ModerateReview hides or restores a review. Hidden reviews are left out of
+// listings and rating aggregates.
+func (s *reviewService) ModerateReview(id uint, req *model.ModerateReviewRequest) (*model.ReportedReview, error) {
+	review, err := s.getReview(id)
+	if err != nil {
+		return nil, err
+	}
+
+	review.Hidden = req.Hidden
+	if err := s.reviewRepo.Update(review); err != nil {
+		return nil, err
+	}
+	return toReportedReview(*review), nil
+}
+
+func (s *reviewService) GetBookReviews(bookID uint) ([]model.Review, error) {
+	if _, err := s.bookRepo.GetByID(bookID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("book not found")
+		}
+		return nil, err
+	}
+
+	return s.reviewRepo.GetByBookID(bookID)
+}
+
+func (s *reviewService) GetReportedReviews() ([]model.ReportedReview, error) {
+	reviews, err := s.reviewRepo.GetReported()
+	if err != nil {
+		return nil, err
+	}
+
+	reported := []model.ReportedReview{}
+	for _, review := range reviews {
+		reported = append(reported, *toReportedReview(review))
+	}
+	return reported, nil
+}
+
+func (s *reviewService) GetSellerProfile(sellerID uint) (*model.SellerProfile, error) {
+	books, err := s.bookRepo.GetBySellerID(sellerID)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries, err := s.reviewRepo.SummaryBySellerIDs([]uint{sellerID})
+	if err != nil {
+		return nil, err
+	}
+
+	reviews, err := s.reviewRepo.GetBySellerID(sellerID, sellerProfileReviews)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(books) == 0 && summaries[sellerID].Count == 0 {
+		return nil, errors.New("seller not found")
+	}
+
+	activeListings := 0
+	for _, book := range books {
+		if book.Status == model.StatusActive {
+			activeListings++
+		}
+	}
+
+	return &model.SellerProfile{
+		SellerID:       sellerID,
+		Rating:         summaries[sellerID],
+		ActiveListings: activeListings,
+		Reviews:        reviews,
+	}, nil
+}
+
+func (s *reviewService) getReview(id uint) (*model.Review, error) {
+	review, err := s.reviewRepo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("review not found")
+		}
+		return nil, err
+	}
+	return review, nil
+}
+
+func toReportedReview(review model.Review) *model.ReportedReview {
+	return &model.ReportedReview{
+		Review:      review,
+		ReportCount: review.ReportCount,
+		Hidden:      review.Hidden,
+	}
+}
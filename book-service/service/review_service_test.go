@@ -0,0 +1,299 @@
+package service
+
+import (
+	"book-service/clients"
+	"book-service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockReviewRepository struct {
+	mock.Mock
+}
+
+func (m *MockReviewRepository) Create(review *model.Review) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) Update(review *model.Review) error {
+	args := m.Called(review)
+	return args.Error(0)
+}
+
+func (m *MockReviewRepository) GetByID(id uint) (*model.Review, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) GetByBookID(bookID uint) ([]model.Review, error) {
+	args := m.Called(bookID)
+	return args.Get(0).([]model.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) GetBySellerID(sellerID uint, limit int) ([]model.Review, error) {
+	args := m.Called(sellerID, limit)
+	return args.Get(0).([]model.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) GetReported() ([]model.Review, error) {
+	args := m.Called()
+	return args.Get(0).([]model.Review), args.Error(1)
+}
+
+func (m *MockReviewRepository) SummaryBySellerIDs(sellerIDs []uint) (map[uint]model.RatingSummary, error) {
+	args := m.Called(sellerIDs)
+	return args.Get(0).(map[uint]model.RatingSummary), args.Error(1)
+}
+
+func (m *MockReviewRepository) SummaryByBookIDs(bookIDs []uint) (map[uint]model.RatingSummary, error) {
+	args := m.Called(bookIDs)
+	return args.Get(0).(map[uint]model.RatingSummary), args.Error(1)
+}
+
+func (m *MockReviewRepository) Report(report *model.ReviewReport, hideThreshold int) error {
+	args := m.Called(report, hideThreshold)
+	return args.Error(0)
+}
+
+// newMockReviewRepository returns a review repository with no reviews.
+func newMockReviewRepository() *MockReviewRepository {
+	m := new(MockReviewRepository)
+	m.On("SummaryByBookIDs", mock.Anything).Return(map[uint]model.RatingSummary{}, nil).Maybe()
+	m.On("SummaryBySellerIDs", mock.Anything).Return(map[uint]model.RatingSummary{}, nil).Maybe()
+	return m
+}
+
+type MockTransactionClient struct {
+	mock.Mock
+}
+
+func (m *MockTransactionClient) GetTransaction(id uint, authorization string) (*clients.Transaction, error) {
+	args := m.Called(id, authorization)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*clients.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionClient) GetDailySales(from time.Time, to time.Time) ([]clients.DailySales, error) {
+	args := m.Called(from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]clients.DailySales), args.Error(1)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCreateReview_Success(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	mockRepo := new(MockBookRepository)
+	mockClient := new(MockTransactionClient)
+	service := NewReviewService(mockReviewRepo, mockRepo, mockClient, 3)
+
+	mockClient.On("GetTransaction", uint(7), "Bearer token").Return(&clients.Transaction{
+		TransactionID: 7, UserID: 2, SellerID: 9, BookID: 1, Status: "delivered",
+		Items: []clients.TransactionItem{{BookID: 1, SellerID: 9}},
+	}, nil)
+	mockReviewRepo.On("Create", mock.AnythingOfType("*model.Review")).Return(nil)
+
+	req := &model.CreateReviewRequest{TransactionID: 7, Rating: 5, BookRating: intPtr(4), Comment: "Fast shipping"}
+	review, err := service.CreateReview(2, req, "Bearer token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), review.SellerID)
+	assert.Equal(t, uint(2), review.BuyerID)
+	assert.Equal(t, uint(1), review.BookID)
+	assert.Equal(t, 4, *review.BookRating)
+	mockReviewRepo.AssertExpectations(t)
+}
+
+func TestCreateReview_SellerFromTransaction(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	mockRepo := new(MockBookRepository)
+	mockClient := new(MockTransactionClient)
+	service := NewReviewService(mockReviewRepo, mockRepo, mockClient, 3)
+
+	mockClient.On("GetTransaction", uint(7), "Bearer token").Return(&clients.Transaction{
+		TransactionID: 7, UserID: 2, SellerID: 9, BookID: 1, Status: "completed",
+		Items: []clients.TransactionItem{{BookID: 1, SellerID: 9}, {BookID: 3, SellerID: 9}},
+	}, nil)
+	mockReviewRepo.On("Create", mock.AnythingOfType("*model.Review")).Return(nil)
+
+	req := &model.CreateReviewRequest{TransactionID: 7, BookID: 3, Rating: 4}
+	review, err := service.CreateReview(2, req, "Bearer token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), review.BookID)
+	assert.Equal(t, uint(9), review.SellerID)
+	mockRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+func TestCreateReview_BookNotInTransaction(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	mockClient := new(MockTransactionClient)
+	service := NewReviewService(mockReviewRepo, new(MockBookRepository), mockClient, 3)
+
+	mockClient.On("GetTransaction", uint(7), "Bearer token").Return(&clients.Transaction{
+		TransactionID: 7, UserID: 2, SellerID: 9, BookID: 1, Status: "delivered",
+		Items: []clients.TransactionItem{{BookID: 1, SellerID: 9}},
+	}, nil)
+
+	req := &model.CreateReviewRequest{TransactionID: 7, BookID: 5, Rating: 5}
+	review, err := service.CreateReview(2, req, "Bearer token")
+
+	assert.Error(t, err)
+	assert.Nil(t, review)
+	assert.Equal(t, "book is not part of the transaction", err.Error())
+	mockReviewRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreateReview_TransactionWithoutItems(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	mockClient := new(MockTransactionClient)
+	service := NewReviewService(mockReviewRepo, new(MockBookRepository), mockClient, 3)
+
+	mockClient.On("GetTransaction", uint(7), "Bearer token").Return(&clients.Transaction{
+		TransactionID: 7, UserID: 2, SellerID: 9, BookID: 1, Status: "paid",
+	}, nil)
+	mockReviewRepo.On("Create", mock.AnythingOfType("*model.Review")).Return(nil)
+
+	review, err := service.CreateReview(2, &model.CreateReviewRequest{TransactionID: 7, Rating: 5}, "Bearer token")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), review.BookID)
+	assert.Equal(t, uint(9), review.SellerID)
+}
+
+func TestCreateReview_PendingTransaction(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	mockClient := new(MockTransactionClient)
+	service := NewReviewService(mockReviewRepo, new(MockBookRepository), mockClient, 3)
+
+	mockClient.On("GetTransaction", uint(7), "Bearer token").Return(&clients.Transaction{
+		TransactionID: 7, UserID: 2, BookID: 1, Status: "pending",
+	}, nil)
+
+	review, err := service.CreateReview(2, &model.CreateReviewRequest{TransactionID: 7, Rating: 5}, "Bearer token")
+
+	assert.Error(t, err)
+	assert.Nil(t, review)
+	assert.Equal(t, "transaction is not completed", err.Error())
+	mockReviewRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreateReview_RefundedTransaction(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	mockClient := new(MockTransactionClient)
+	service := NewReviewService(mockReviewRepo, new(MockBookRepository), mockClient, 3)
+
+	mockClient.On("GetTransaction", uint(7), "Bearer token").Return(&clients.Transaction{
+		TransactionID: 7, UserID: 2, BookID: 1, Status: "refunded",
+	}, nil)
+
+	review, err := service.CreateReview(2, &model.CreateReviewRequest{TransactionID: 7, Rating: 5}, "Bearer token")
+
+	assert.Error(t, err)
+	assert.Nil(t, review)
+	assert.Equal(t, "transaction is not completed", err.Error())
+	mockReviewRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestCreateReview_SomeoneElsesTransaction(t *testing.T) {
+	mockClient := new(MockTransactionClient)
+	service := NewReviewService(new(MockReviewRepository), new(MockBookRepository), mockClient, 3)
+
+	mockClient.On("GetTransaction", uint(7), "Bearer token").Return(nil, clients.ErrTransactionNotFound)
+
+	review, err := service.CreateReview(2, &model.CreateReviewRequest{TransactionID: 7, Rating: 5}, "Bearer token")
+
+	assert.Error(t, err)
+	assert.Nil(t, review)
+	assert.Equal(t, "transaction not found", err.Error())
+}
+
+func TestReplyToReview_OnlySeller(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	service := NewReviewService(mockReviewRepo, new(MockBookRepository), new(MockTransactionClient), 3)
+
+	mockReviewRepo.On("GetByID", uint(1)).Return(&model.Review{ID: 1, SellerID: 9}, nil)
+
+	review, err := service.ReplyToReview(1, 2, &model.ReplyReviewRequest{Reply: "Thanks"})
+
+	assert.Error(t, err)
+	assert.Nil(t, review)
+	assert.Equal(t, "unauthorized: you can only reply to reviews of your own sales", err.Error())
+	mockReviewRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestReportReview_PassesHideThreshold(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	service := NewReviewService(mockReviewRepo, new(MockBookRepository), new(MockTransactionClient), 3)
+
+	mockReviewRepo.On("GetByID", uint(1)).Return(&model.Review{ID: 1}, nil)
+	mockReviewRepo.On("Report", mock.MatchedBy(func(r *model.ReviewReport) bool {
+		return r.ReviewID == 1 && r.ReporterID == 4 && r.Reason == "spam"
+	}), 3).Return(nil)
+
+	err := service.ReportReview(1, 4, &model.ReportReviewRequest{Reason: "spam"})
+
+	assert.NoError(t, err)
+	mockReviewRepo.AssertExpectations(t)
+}
+
+func TestGetSellerProfile_Success(t *testing.T) {
+	mockReviewRepo := new(MockReviewRepository)
+	mockRepo := new(MockBookRepository)
+	service := NewReviewService(mockReviewRepo, mockRepo, new(MockTransactionClient), 3)
+
+	mockRepo.On("GetBySellerID", uint(9)).Return([]model.Book{{ID: 1, Stock: 2, Status: model.StatusActive}, {ID: 2, Stock: 0, Status: model.StatusSoldOut}}, nil)
+	mockReviewRepo.On("SummaryBySellerIDs", []uint{9}).Return(map[uint]model.RatingSummary{9: {Average: 4.5, Count: 2}}, nil)
+	mockReviewRepo.On("GetBySellerID", uint(9), sellerProfileReviews).Return([]model.Review{{ID: 1}, {ID: 2}}, nil)
+
+	profile, err := service.GetSellerProfile(9)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4.5, profile.Rating.Average)
+	assert.Equal(t, int64(2), profile.Rating.Count)
+	assert.Equal(t, 1, profile.ActiveListings)
+	assert.Len(t, profile.Reviews, 2)
+}
+
+func TestGetBookByID_IncludesRatings(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockReviewRepo := new(MockReviewRepository)
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), mockReviewRepo, newMockBookHistoryRepository(), nil)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 9, Status: model.StatusActive}, nil)
+	mockReviewRepo.On("SummaryByBookIDs", []uint{1}).Return(map[uint]model.RatingSummary{1: {Average: 4, Count: 1}}, nil)
+	mockReviewRepo.On("SummaryBySellerIDs", []uint{9}).Return(map[uint]model.RatingSummary{9: {Average: 4.5, Count: 2}}, nil)
+
+	result, err := service.GetBookByID(1, 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.RatingSummary{Average: 4, Count: 1}, result.Rating)
+	assert.Equal(t, model.RatingSummary{Average: 4.5, Count: 2}, result.SellerRating)
+}
+
+func TestGetBookReviews_BookNotFound(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	service := NewReviewService(new(MockReviewRepository), mockRepo, new(MockTransactionClient), 3)
+
+	mockRepo.On("GetByID", uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+	reviews, err := service.GetBookReviews(1)
+
+	assert.Error(t, err)
+	assert.Nil(t, reviews)
+	assert.Equal(t, "book not found", err.Error())
+}
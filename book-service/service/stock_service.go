@@ -0,0 +1,116 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/repository"
+	"errors"
+	"reflect"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+type StockService interface {
+	Deduct(req *model.StockOperationRequest, caller string) (*model.StockOperation, error)
+	Restock(req *model.StockOperationRequest, caller string) (*model.StockOperation, error)
+}
+
+type stockService struct {
+	stockRepo   repository.StockRepository
+	bookRepo    repository.BookRepository
+	historyRepo repository.BookHistoryRepository
+	listener    BookChangeListener
+}
+
+// NewStockService creates the service behind the internal batch stock
+// endpoints. listener may be nil; when set it is told about every listed
+// book whose stock changed.
+func NewStockService(stockRepo repository.StockRepository, bookRepo repository.BookRepository, historyRepo repository.BookHistoryRepository, listener BookChangeListener) StockService {
+	return &stockService{
+		stockRepo:   stockRepo,
+		bookRepo:    bookRepo,
+		historyRepo: historyRepo,
+		listener:    listener,
+	}
+}
+
+// Deduct takes stock off every book in the request, or off none of them
+// when one has too little left. A *repository.StockItemError names the
+// book that failed.
+func (s *stockService) Deduct(req *model.StockOperationRequest, caller string) (*model.StockOperation, error) {
+	return s.apply(model.StockDeduct, req, caller)
+}
+
+// Restock puts stock back, typically to compensate an earlier Deduct.
+func (s *stockService) Restock(req *model.StockOperationRequest, caller string) (*model.StockOperation, error) {
+	return s.apply(model.StockRestock, req, caller)
+}
+
+func (s *stockService) apply(kind string, req *model.StockOperationRequest, caller string) (*model.StockOperation, error) {
+	op := &model.StockOperation{
+		IdempotencyKey: req.IdempotencyKey,
+		Kind:           kind,
+		Service:        caller,
+		Items:          mergeStockItems(req.Items),
+	}
+
+	movements, err := s.stockRepo.Apply(op)
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return s.replay(op)
+		}
+		return nil, err
+	}
+
+	action := model.ChangeStockDeducted
+	if kind == model.StockRestock {
+		action = model.ChangeRestocked
+	}
+
+	invalidator, _ := s.bookRepo.(repository.BookCacheInvalidator)
+	for _, movement := range movements {
+		if invalidator != nil {
+			invalidator.InvalidateBook(movement.After.ID)
+		}
+		recordChange(s.historyRepo, movement.After.ID, 0, action, bookChanges(movement.Before, movement.After))
+		if s.listener != nil && !movement.After.DeletedAt.Valid {
+			s.listener.BookChanged(movement.Before, movement.After)
+		}
+	}
+	return op, nil
+}
+
+// replay answers a retried request with the operation already recorded
+// under its idempotency key. Reusing a key for a different request is an
+// error rather than a silent no-op.
+func (s *stockService) replay(op *model.StockOperation) (*model.StockOperation, error) {
+	existing, err := s.stockRepo.GetByKey(op.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.Kind != op.Kind || !reflect.DeepEqual(existing.Items, op.Items) {
+		return nil, errors.New("idempotency key already used for a different request")
+	}
+
+	existing.Replayed = true
+	return existing, nil
+}
+
+// mergeStockItems adds up the quantities of repeated books and sorts the
+// items by book ID, which is also the order the rows are locked in.
+func mergeStockItems(items []model.StockItem) []model.StockItem {
+	quantities := make(map[uint]int)
+	for _, item := range items {
+		quantities[item.BookID] += item.Quantity
+	}
+
+	merged := make([]model.StockItem, 0, len(quantities))
+	for bookID, quantity := range quantities {
+		merged = append(merged, model.StockItem{BookID: bookID, Quantity: quantity})
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].BookID < merged[j].BookID
+	})
+	return merged
+}
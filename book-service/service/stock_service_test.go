@@ -0,0 +1,161 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/repository"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockStockRepository struct {
+	mock.Mock
+}
+
+func (m *MockStockRepository) Apply(op *model.StockOperation) ([]repository.StockMovement, error) {
+	args := m.Called(op)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]repository.StockMovement), args.Error(1)
+}
+
+func (m *MockStockRepository) GetByKey(key string) (*model.StockOperation, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.StockOperation), args.Error(1)
+}
+
+func TestDeductBatch_MergesItemsAndRecordsHistory(t *testing.T) {
+	mockStockRepo := new(MockStockRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	listener := &recordingListener{}
+	service := NewStockService(mockStockRepo, new(MockBookRepository), mockHistoryRepo, listener)
+
+	mockStockRepo.On("Apply", mock.MatchedBy(func(op *model.StockOperation) bool {
+		return op.Kind == model.StockDeduct && op.Service == "transaction-service" &&
+			assert.ObjectsAreEqual([]model.StockItem{{BookID: 2, Quantity: 1}, {BookID: 5, Quantity: 3}}, op.Items)
+	})).Return([]repository.StockMovement{
+		{Before: model.Book{ID: 2, Stock: 1, Status: model.StatusActive}, After: model.Book{ID: 2, Stock: 0, Status: model.StatusSoldOut}},
+		{Before: model.Book{ID: 5, Stock: 10, Status: model.StatusActive}, After: model.Book{ID: 5, Stock: 7, Status: model.StatusActive}},
+	}, nil)
+	mockHistoryRepo.On("Record", mock.MatchedBy(func(c *model.BookChange) bool {
+		return c.Action == model.ChangeStockDeducted && c.ActorID == 0
+	})).Return(nil).Twice()
+
+	op, err := service.Deduct(&model.StockOperationRequest{
+		IdempotencyKey: "order-1",
+		Items:          []model.StockItem{{BookID: 5, Quantity: 1}, {BookID: 2, Quantity: 1}, {BookID: 5, Quantity: 2}},
+	}, "transaction-service")
+
+	assert.NoError(t, err)
+	assert.False(t, op.Replayed)
+	assert.Equal(t, 2, listener.calls)
+	mockHistoryRepo.AssertExpectations(t)
+}
+
+func TestDeductBatch_InsufficientStockNamesBook(t *testing.T) {
+	mockStockRepo := new(MockStockRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := NewStockService(mockStockRepo, new(MockBookRepository), mockHistoryRepo, nil)
+
+	mockStockRepo.On("Apply", mock.Anything).Return(nil, &repository.StockItemError{BookID: 5, Err: repository.ErrInsufficientStock})
+
+	op, err := service.Deduct(&model.StockOperationRequest{
+		IdempotencyKey: "order-1",
+		Items:          []model.StockItem{{BookID: 5, Quantity: 3}},
+	}, "transaction-service")
+
+	var itemErr *repository.StockItemError
+	assert.True(t, errors.As(err, &itemErr))
+	assert.Equal(t, uint(5), itemErr.BookID)
+	assert.ErrorIs(t, err, repository.ErrInsufficientStock)
+	assert.Nil(t, op)
+	mockHistoryRepo.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestDeductBatch_ReplaysSameRequest(t *testing.T) {
+	mockStockRepo := new(MockStockRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := NewStockService(mockStockRepo, new(MockBookRepository), mockHistoryRepo, nil)
+
+	mockStockRepo.On("Apply", mock.Anything).Return(nil, gorm.ErrDuplicatedKey)
+	mockStockRepo.On("GetByKey", "order-1").Return(&model.StockOperation{
+		ID:             9,
+		IdempotencyKey: "order-1",
+		Kind:           model.StockDeduct,
+		Items:          []model.StockItem{{BookID: 5, Quantity: 3}},
+	}, nil)
+
+	op, err := service.Deduct(&model.StockOperationRequest{
+		IdempotencyKey: "order-1",
+		Items:          []model.StockItem{{BookID: 5, Quantity: 3}},
+	}, "transaction-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(9), op.ID)
+	assert.True(t, op.Replayed)
+	mockHistoryRepo.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestDeductBatch_RejectsReusedKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing model.StockOperation
+	}{
+		{"different items", model.StockOperation{Kind: model.StockDeduct, Items: []model.StockItem{{BookID: 5, Quantity: 1}}}},
+		{"different kind", model.StockOperation{Kind: model.StockRestock, Items: []model.StockItem{{BookID: 5, Quantity: 3}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStockRepo := new(MockStockRepository)
+			service := NewStockService(mockStockRepo, new(MockBookRepository), newMockBookHistoryRepository(), nil)
+
+			existing := tt.existing
+			mockStockRepo.On("Apply", mock.Anything).Return(nil, gorm.ErrDuplicatedKey)
+			mockStockRepo.On("GetByKey", "order-1").Return(&existing, nil)
+
+			op, err := service.Deduct(&model.StockOperationRequest{
+				IdempotencyKey: "order-1",
+				Items:          []model.StockItem{{BookID: 5, Quantity: 3}},
+			}, "transaction-service")
+
+			assert.EqualError(t, err, "idempotency key already used for a different request")
+			assert.Nil(t, op)
+		})
+	}
+}
+
+func TestRestockBatch_SkipsListenerForDeletedBooks(t *testing.T) {
+	mockStockRepo := new(MockStockRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	listener := &recordingListener{}
+	service := NewStockService(mockStockRepo, new(MockBookRepository), mockHistoryRepo, listener)
+
+	deleted := gorm.DeletedAt{Valid: true}
+	mockStockRepo.On("Apply", mock.MatchedBy(func(op *model.StockOperation) bool {
+		return op.Kind == model.StockRestock
+	})).Return([]repository.StockMovement{
+		{Before: model.Book{ID: 2, Stock: 0, Status: model.StatusSoldOut}, After: model.Book{ID: 2, Stock: 1, Status: model.StatusActive}},
+		{Before: model.Book{ID: 3, Stock: 0, DeletedAt: deleted}, After: model.Book{ID: 3, Stock: 2, DeletedAt: deleted}},
+	}, nil)
+	mockHistoryRepo.On("Record", mock.MatchedBy(func(c *model.BookChange) bool {
+		return c.Action == model.ChangeRestocked
+	})).Return(nil).Twice()
+
+	_, err := service.Restock(&model.StockOperationRequest{
+		IdempotencyKey: "order-1-compensate",
+		Items:          []model.StockItem{{BookID: 2, Quantity: 1}, {BookID: 3, Quantity: 2}},
+	}, "transaction-service")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, listener.calls)
+	assert.Equal(t, uint(2), listener.after.ID)
+	mockHistoryRepo.AssertExpectations(t)
+}
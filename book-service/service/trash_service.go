@@ -0,0 +1,85 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/repository"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TrashService interface {
+	GetTrash(sellerID uint) ([]model.TrashedBook, error)
+	RestoreBook(id uint, sellerID uint) (*model.BookResponse, error)
+	PurgeExpired() (int64, error)
+}
+
+type trashService struct {
+	bookRepo    repository.BookRepository
+	historyRepo repository.BookHistoryRepository
+	retention   time.Duration
+	now         func() time.Time
+}
+
+// NewTrashService creates the trash service. Deleted books can be restored
+// by their seller for retention, after which PurgeExpired removes them for
+// good.
+func NewTrashService(bookRepo repository.BookRepository, historyRepo repository.BookHistoryRepository, retention time.Duration) TrashService {
+	return &trashService{
+		bookRepo:    bookRepo,
+		historyRepo: historyRepo,
+		retention:   retention,
+		now:         time.Now,
+	}
+}
+
+// GetTrash returns the seller's books that can still be restored, most
+// recently deleted first.
+func (s *trashService) GetTrash(sellerID uint) ([]model.TrashedBook, error) {
+	books, err := s.bookRepo.GetDeletedBySellerID(sellerID, s.cutoff())
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := []model.TrashedBook{}
+	for _, book := range books {
+		deletedAt := book.DeletedAt.Time
+		trashed = append(trashed, model.TrashedBook{
+			BookResponse:    book.ToResponse(),
+			DeletedAt:       deletedAt,
+			RestorableUntil: deletedAt.Add(s.retention),
+		})
+	}
+	return trashed, nil
+}
+
+func (s *trashService) RestoreBook(id uint, sellerID uint) (*model.BookResponse, error) {
+	err := s.bookRepo.Restore(id, sellerID, s.cutoff())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("book not found in trash")
+		}
+		return nil, err
+	}
+
+	recordChange(s.historyRepo, id, sellerID, model.ChangeRestored, nil)
+
+	book, err := s.bookRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := book.ToResponse()
+	return &response, nil
+}
+
+// PurgeExpired permanently removes books that have been in the trash for
+// longer than the retention period.
+func (s *trashService) PurgeExpired() (int64, error) {
+	return s.bookRepo.PurgeDeleted(s.cutoff())
+}
+
+func (s *trashService) cutoff() time.Time {
+	return s.now().Add(-s.retention)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"book-service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func newTestTrashService(bookRepo *MockBookRepository, historyRepo *MockBookHistoryRepository, now time.Time) *trashService {
+	s := NewTrashService(bookRepo, historyRepo, 30*24*time.Hour).(*trashService)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func TestGetTrash_IncludesRestoreDeadline(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	service := newTestTrashService(mockRepo, newMockBookHistoryRepository(), now)
+
+	deletedAt := time.Date(2024, 5, 20, 12, 0, 0, 0, time.UTC)
+	mockRepo.On("GetDeletedBySellerID", uint(1), now.Add(-30*24*time.Hour)).Return([]model.Book{
+		{ID: 4, SellerID: 1, DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}},
+	}, nil)
+
+	trash, err := service.GetTrash(1)
+
+	assert.NoError(t, err)
+	assert.Len(t, trash, 1)
+	assert.Equal(t, uint(4), trash[0].ID)
+	assert.Equal(t, deletedAt, trash[0].DeletedAt)
+	assert.Equal(t, time.Date(2024, 6, 19, 12, 0, 0, 0, time.UTC), trash[0].RestorableUntil)
+}
+
+func TestRestoreBook_Success(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	now := time.Now()
+	service := newTestTrashService(mockRepo, mockHistoryRepo, now)
+
+	mockRepo.On("Restore", uint(4), uint(1), now.Add(-30*24*time.Hour)).Return(nil)
+	mockRepo.On("GetByID", uint(4)).Return(&model.Book{ID: 4, SellerID: 1, Version: 3}, nil)
+	mockHistoryRepo.On("Record", mock.MatchedBy(func(c *model.BookChange) bool {
+		return c.BookID == 4 && c.ActorID == 1 && c.Action == model.ChangeRestored
+	})).Return(nil)
+
+	book, err := service.RestoreBook(4, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), book.Version)
+	mockHistoryRepo.AssertExpectations(t)
+}
+
+func TestRestoreBook_NotInTrash(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	mockHistoryRepo := new(MockBookHistoryRepository)
+	service := newTestTrashService(mockRepo, mockHistoryRepo, time.Now())
+
+	mockRepo.On("Restore", uint(4), uint(1), mock.AnythingOfType("time.Time")).Return(gorm.ErrRecordNotFound)
+
+	book, err := service.RestoreBook(4, 1)
+
+	assert.EqualError(t, err, "book not found in trash")
+	assert.Nil(t, book)
+	mockHistoryRepo.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestPurgeExpired_UsesRetentionCutoff(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	now := time.Now()
+	service := newTestTrashService(mockRepo, newMockBookHistoryRepository(), now)
+
+	mockRepo.On("PurgeDeleted", now.Add(-30*24*time.Hour)).Return(int64(2), nil)
+
+	purged, err := service.PurgeExpired()
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
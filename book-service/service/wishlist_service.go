@@ -0,0 +1,210 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/notifier"
+	"book-service/repository"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BookChangeListener is told about every successful book update, with the
+// book as it was before and after the change.
+type BookChangeListener interface {
+	BookChanged(before model.Book, after model.Book)
+}
+
+type WishlistService interface {
+	BookChangeListener
+	AddToWishlist(userID uint, bookID uint) (*model.WishlistItemResponse, error)
+	RemoveFromWishlist(userID uint, bookID uint) error
+	GetWishlist(userID uint) ([]model.WishlistItemResponse, error)
+}
+
+type wishlistService struct {
+	wishlistRepo repository.WishlistRepository
+	bookRepo     repository.BookRepository
+	notifier     notifier.Notifier
+	cooldown     time.Duration
+	dailyLimit   int
+
+	// alertMu serialises alert runs so two quick updates to the same book
+	// cannot both pass the rate limit check.
+	alertMu sync.Mutex
+}
+
+// NewWishlistService creates the wishlist service. A user is alerted about
+// the same book at most once per cooldown and receives at most dailyLimit
+// alerts in any 24 hours.
+func NewWishlistService(wishlistRepo repository.WishlistRepository, bookRepo repository.BookRepository, n notifier.Notifier, cooldown time.Duration, dailyLimit int) WishlistService {
+	return &wishlistService{
+		wishlistRepo: wishlistRepo,
+		bookRepo:     bookRepo,
+		notifier:     n,
+		cooldown:     cooldown,
+		dailyLimit:   dailyLimit,
+	}
+}
+
+func (s *wishlistService) AddToWishlist(userID uint, bookID uint) (*model.WishlistItemResponse, error) {
+	book, err := s.bookRepo.GetByID(bookID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("book not found")
+		}
+		return nil, err
+	}
+	if !publiclyVisible(book) && book.SellerID != userID {
+		return nil, errors.New("book not found")
+	}
+
+	item := &model.WishlistItem{
+		UserID: userID,
+		BookID: bookID,
+	}
+	if err := s.wishlistRepo.Add(item); err != nil {
+		return nil, err
+	}
+
+	return &model.WishlistItemResponse{
+		BookID:  item.BookID,
+		AddedAt: item.CreatedAt,
+		Book:    book.ToResponse(),
+	}, nil
+}
+
+func (s *wishlistService) RemoveFromWishlist(userID uint, bookID uint) error {
+	err := s.wishlistRepo.Remove(userID, bookID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.New("book not in wishlist")
+	}
+	return err
+}
+
+// GetWishlist returns the user's wishlist, newest first. Books that have
+// since been deleted, or that GetBookByID would not show the user, are left
+// out; the items are kept so they reappear once the book is listed again.
+func (s *wishlistService) GetWishlist(userID uint) ([]model.WishlistItemResponse, error) {
+	items, err := s.wishlistRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := []model.WishlistItemResponse{}
+	for _, item := range items {
+		book, err := s.bookRepo.GetByID(item.BookID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !publiclyVisible(book) && book.SellerID != userID {
+			continue
+		}
+
+		responses = append(responses, model.WishlistItemResponse{
+			BookID:  item.BookID,
+			AddedAt: item.CreatedAt,
+			Book:    book.ToResponse(),
+		})
+	}
+	return responses, nil
+}
+
+// BookChanged alerts the users who wishlisted a book when its price drops
+// or it comes back in stock. Alerts are sent in the background so updates
+// are not slowed down by email delivery.
+func (s *wishlistService) BookChanged(before model.Book, after model.Book) {
+	alert, ok := wishlistAlertFor(before, after)
+	if !ok {
+		return
+	}
+	go s.sendAlerts(after.SellerID, alert)
+}
+
+func wishlistAlertFor(before model.Book, after model.Book) (notifier.WishlistAlert, bool) {
+	if after.Status != model.StatusActive {
+		return notifier.WishlistAlert{}, false
+	}
+
+	alert := notifier.WishlistAlert{
+		BookID:   after.ID,
+		BookName: after.Name,
+		OldCosts: before.Costs,
+		NewCosts: after.Costs,
+		Stock:    after.Stock,
+	}
+
+	switch {
+	case before.Stock == 0 && after.Stock > 0:
+		alert.Kind = model.AlertBackInStock
+	case after.Costs < before.Costs && after.Stock > 0:
+		alert.Kind = model.AlertPriceDrop
+	default:
+		return alert, false
+	}
+	return alert, true
+}
+
+func (s *wishlistService) sendAlerts(sellerID uint, alert notifier.WishlistAlert) {
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+
+	userIDs, err := s.wishlistRepo.UserIDsByBookID(alert.BookID)
+	if err != nil {
+		log.Printf("Failed to load wishlists for book %d: %v", alert.BookID, err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		if userID == sellerID {
+			continue
+		}
+
+		allowed, err := s.alertAllowed(userID, alert.BookID)
+		if err != nil {
+			log.Printf("Failed to check alert limit for user %d: %v", userID, err)
+			continue
+		}
+		if !allowed {
+			continue
+		}
+
+		if err := s.notifier.NotifyWishlist(userID, alert); err != nil {
+			log.Printf("Failed to send %s alert for book %d to user %d: %v", alert.Kind, alert.BookID, userID, err)
+			continue
+		}
+
+		record := &model.WishlistAlert{
+			UserID: userID,
+			BookID: alert.BookID,
+			Kind:   alert.Kind,
+		}
+		if err := s.wishlistRepo.RecordAlert(record); err != nil {
+			log.Printf("Failed to record alert for user %d: %v", userID, err)
+		}
+	}
+}
+
+func (s *wishlistService) alertAllowed(userID uint, bookID uint) (bool, error) {
+	now := time.Now()
+
+	last, err := s.wishlistRepo.LastAlertAt(userID, bookID)
+	if err != nil {
+		return false, err
+	}
+	if last != nil && now.Sub(*last) < s.cooldown {
+		return false, nil
+	}
+
+	sent, err := s.wishlistRepo.CountAlertsSince(userID, now.Add(-24*time.Hour))
+	if err != nil {
+		return false, err
+	}
+	return sent < int64(s.dailyLimit), nil
+}
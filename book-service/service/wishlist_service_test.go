@@ -0,0 +1,246 @@
+package service
+
+import (
+	"book-service/model"
+	"book-service/notifier"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type MockWishlistRepository struct {
+	mock.Mock
+}
+
+func (m *MockWishlistRepository) Add(item *model.WishlistItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockWishlistRepository) Remove(userID uint, bookID uint) error {
+	args := m.Called(userID, bookID)
+	return args.Error(0)
+}
+
+func (m *MockWishlistRepository) GetByUserID(userID uint) ([]model.WishlistItem, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]model.WishlistItem), args.Error(1)
+}
+
+func (m *MockWishlistRepository) UserIDsByBookID(bookID uint) ([]uint, error) {
+	args := m.Called(bookID)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockWishlistRepository) LastAlertAt(userID uint, bookID uint) (*time.Time, error) {
+	args := m.Called(userID, bookID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockWishlistRepository) CountAlertsSince(userID uint, since time.Time) (int64, error) {
+	args := m.Called(userID, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockWishlistRepository) RecordAlert(alert *model.WishlistAlert) error {
+	args := m.Called(alert)
+	return args.Error(0)
+}
+
+type MockNotifier struct {
+	mock.Mock
+}
+
+func (m *MockNotifier) NotifyWishlist(userID uint, alert notifier.WishlistAlert) error {
+	args := m.Called(userID, alert)
+	return args.Error(0)
+}
+
+type recordingListener struct {
+	before, after model.Book
+	calls         int
+}
+
+func (l *recordingListener) BookChanged(before model.Book, after model.Book) {
+	l.before, l.after = before, after
+	l.calls++
+}
+
+func TestWishlistAlertFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		before model.Book
+		after  model.Book
+		kind   string
+		ok     bool
+	}{
+		{"price drop", model.Book{Costs: 100, Stock: 2}, model.Book{Costs: 80, Stock: 2, Status: model.StatusActive}, model.AlertPriceDrop, true},
+		{"price rise", model.Book{Costs: 80, Stock: 2}, model.Book{Costs: 100, Stock: 2, Status: model.StatusActive}, "", false},
+		{"back in stock", model.Book{Costs: 100, Stock: 0}, model.Book{Costs: 100, Stock: 3, Status: model.StatusActive}, model.AlertBackInStock, true},
+		{"restock while in stock", model.Book{Costs: 100, Stock: 1}, model.Book{Costs: 100, Stock: 3, Status: model.StatusActive}, "", false},
+		{"price drop while sold out", model.Book{Costs: 100, Stock: 0}, model.Book{Costs: 80, Stock: 0, Status: model.StatusSoldOut}, "", false},
+		{"price drop while paused", model.Book{Costs: 100, Stock: 2}, model.Book{Costs: 80, Stock: 2, Status: model.StatusPaused}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert, ok := wishlistAlertFor(tt.before, tt.after)
+			assert.Equal(t, tt.ok, ok)
+			if ok {
+				assert.Equal(t, tt.kind, alert.Kind)
+			}
+		})
+	}
+}
+
+func TestAddToWishlist_BookNotFound(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	mockRepo := new(MockBookRepository)
+	service := NewWishlistService(mockWishlistRepo, mockRepo, new(MockNotifier), time.Hour, 5)
+
+	mockRepo.On("GetByID", uint(1)).Return(nil, gorm.ErrRecordNotFound)
+
+	result, err := service.AddToWishlist(2, 1)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, "book not found", err.Error())
+	mockWishlistRepo.AssertNotCalled(t, "Add", mock.Anything)
+}
+
+func TestAddToWishlist_HiddenBook(t *testing.T) {
+	for _, status := range []string{model.StatusDraft, model.StatusPaused, model.StatusArchived} {
+		t.Run(status, func(t *testing.T) {
+			mockWishlistRepo := new(MockWishlistRepository)
+			mockRepo := new(MockBookRepository)
+			service := NewWishlistService(mockWishlistRepo, mockRepo, new(MockNotifier), time.Hour, 5)
+
+			mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 7, Status: status}, nil)
+
+			result, err := service.AddToWishlist(2, 1)
+
+			assert.Error(t, err)
+			assert.Nil(t, result)
+			assert.Equal(t, "book not found", err.Error())
+			mockWishlistRepo.AssertNotCalled(t, "Add", mock.Anything)
+		})
+	}
+}
+
+func TestAddToWishlist_SellerSeesOwnDraft(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	mockRepo := new(MockBookRepository)
+	service := NewWishlistService(mockWishlistRepo, mockRepo, new(MockNotifier), time.Hour, 5)
+
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, SellerID: 7, Status: model.StatusDraft}, nil)
+	mockWishlistRepo.On("Add", mock.Anything).Return(nil)
+
+	result, err := service.AddToWishlist(7, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), result.BookID)
+}
+
+func TestGetWishlist_HidesUnlistedBooks(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	mockRepo := new(MockBookRepository)
+	service := NewWishlistService(mockWishlistRepo, mockRepo, new(MockNotifier), time.Hour, 5)
+
+	mockWishlistRepo.On("GetByUserID", uint(2)).Return([]model.WishlistItem{{BookID: 1}, {BookID: 3}, {BookID: 4}}, nil)
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, Name: "Book 1", SellerID: 7, Status: model.StatusPaused}, nil)
+	mockRepo.On("GetByID", uint(3)).Return(&model.Book{ID: 3, Name: "Book 3", SellerID: 7, Status: model.StatusSoldOut}, nil)
+	mockRepo.On("GetByID", uint(4)).Return(&model.Book{ID: 4, Name: "Book 4", SellerID: 7, Status: model.StatusArchived}, nil)
+
+	result, err := service.GetWishlist(2)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "Book 3", result[0].Book.Name)
+	mockWishlistRepo.AssertNotCalled(t, "Remove", mock.Anything, mock.Anything)
+}
+
+func TestGetWishlist_SkipsDeletedBooks(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	mockRepo := new(MockBookRepository)
+	service := NewWishlistService(mockWishlistRepo, mockRepo, new(MockNotifier), time.Hour, 5)
+
+	mockWishlistRepo.On("GetByUserID", uint(2)).Return([]model.WishlistItem{{BookID: 1}, {BookID: 3}}, nil)
+	mockRepo.On("GetByID", uint(1)).Return(&model.Book{ID: 1, Name: "Book 1", Status: model.StatusActive}, nil)
+	mockRepo.On("GetByID", uint(3)).Return(nil, gorm.ErrRecordNotFound)
+
+	result, err := service.GetWishlist(2)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	assert.Equal(t, "Book 1", result[0].Book.Name)
+}
+
+func TestSendAlerts_AppliesRateLimits(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	mockNotifier := new(MockNotifier)
+	service := NewWishlistService(mockWishlistRepo, new(MockBookRepository), mockNotifier, time.Hour, 5).(*wishlistService)
+
+	alert := notifier.WishlistAlert{Kind: model.AlertPriceDrop, BookID: 1, OldCosts: 100, NewCosts: 80, Stock: 1}
+	recent := time.Now().Add(-10 * time.Minute)
+
+	// User 2 is eligible, user 3 was alerted about this book recently, user
+	// 4 has used up their daily alerts and user 9 is the seller.
+	mockWishlistRepo.On("UserIDsByBookID", uint(1)).Return([]uint{2, 3, 4, 9}, nil)
+	mockWishlistRepo.On("LastAlertAt", uint(2), uint(1)).Return(nil, nil)
+	mockWishlistRepo.On("LastAlertAt", uint(3), uint(1)).Return(&recent, nil)
+	mockWishlistRepo.On("LastAlertAt", uint(4), uint(1)).Return(nil, nil)
+	mockWishlistRepo.On("CountAlertsSince", uint(2), mock.Anything).Return(int64(0), nil)
+	mockWishlistRepo.On("CountAlertsSince", uint(4), mock.Anything).Return(int64(5), nil)
+	mockNotifier.On("NotifyWishlist", uint(2), alert).Return(nil)
+	mockWishlistRepo.On("RecordAlert", mock.MatchedBy(func(a *model.WishlistAlert) bool {
+		return a.UserID == 2 && a.BookID == 1 && a.Kind == model.AlertPriceDrop
+	})).Return(nil)
+
+	service.sendAlerts(9, alert)
+
+	mockNotifier.AssertNumberOfCalls(t, "NotifyWishlist", 1)
+	mockWishlistRepo.AssertExpectations(t)
+}
+
+func TestSendAlerts_FailedDeliveryIsNotRecorded(t *testing.T) {
+	mockWishlistRepo := new(MockWishlistRepository)
+	mockNotifier := new(MockNotifier)
+	service := NewWishlistService(mockWishlistRepo, new(MockBookRepository), mockNotifier, time.Hour, 5).(*wishlistService)
+
+	alert := notifier.WishlistAlert{Kind: model.AlertBackInStock, BookID: 1, Stock: 2}
+
+	mockWishlistRepo.On("UserIDsByBookID", uint(1)).Return([]uint{2}, nil)
+	mockWishlistRepo.On("LastAlertAt", uint(2), uint(1)).Return(nil, nil)
+	mockWishlistRepo.On("CountAlertsSince", uint(2), mock.Anything).Return(int64(0), nil)
+	mockNotifier.On("NotifyWishlist", uint(2), alert).Return(errors.New("email-service unavailable"))
+
+	service.sendAlerts(9, alert)
+
+	mockWishlistRepo.AssertNotCalled(t, "RecordAlert", mock.Anything)
+}
+
+func TestUpdateBook_NotifiesListener(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+	listener := &recordingListener{}
+	service := NewBookService(mockRepo, newMockReservationRepository(), newMockCategoryRepository(), newMockReviewRepository(), newMockBookHistoryRepository(), listener)
+
+	existingBook := &model.Book{ID: 1, SellerID: 1, Name: "Test Book", Costs: 100, Stock: 2, Version: 1}
+	newCosts := 80.0
+
+	mockRepo.On("GetByID", uint(1)).Return(existingBook, nil)
+	mockRepo.On("Update", mock.AnythingOfType("*model.Book")).Return(nil)
+
+	_, err := service.UpdateBook(1, &model.UpdateBookRequest{Costs: &newCosts}, seller(1), nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, listener.calls)
+	assert.Equal(t, 100.0, listener.before.Costs)
+	assert.Equal(t, 80.0, listener.after.Costs)
+}
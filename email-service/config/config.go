@@ -1,9 +1,12 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/joho/godotenv"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -14,3 +17,25 @@ func LoadEnv() {
 		log.Println("No .env file found, pakai environment bawaan OS")
 	}
 }
+
+func DBInit() *gorm.DB {
+	user := os.Getenv("DB_USER")
+	pass := os.Getenv("DB_PASS")
+	host := os.Getenv("DB_HOST")
+	port := os.Getenv("DB_PORT")
+	name := os.Getenv("DB_NAME")
+	sslmode := os.Getenv("DB_SSLMODE") // Optional
+
+	// PostgreSQL DSN format
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		host, user, pass, name, port, sslmode)
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		log.Fatal("Gagal koneksi database via GORM (PostgreSQL):", err)
+	}
+
+	DB = db
+	log.Println("Koneksi db berhasil")
+	return db
+}
@@ -6,11 +6,43 @@ type VerificationEmailRequest struct {
 }
 
 type TransactionEmailRequest struct {
-	Email         string  `json:"email"`
-	TransactionID string  `json:"transaction_id"`
-	Product       string  `json:"product"`
-	Amount        float64 `json:"amount"`
-	Status        string  `json:"status"`
-	Timestamp     string  `json:"timestamp"`
-	InvoiceURL    string  `json:"invoice_url"`
+	Email         string                 `json:"email"`
+	TransactionID string                 `json:"transaction_id"`
+	Product       string                 `json:"product"`
+	Amount        float64                `json:"amount"`
+	Status        string                 `json:"status"`
+	Timestamp     string                 `json:"timestamp"`
+	InvoiceURL    string                 `json:"invoice_url"`
+	Items         []TransactionEmailItem `json:"items"`
+}
+
+// TransactionEmailItem is one line of a receipt.
+type TransactionEmailItem struct {
+	Title     string  `json:"title"`
+	Qty       int     `json:"qty"`
+	UnitPrice float64 `json:"unit_price"`
+	Subtotal  float64 `json:"subtotal"`
+}
+
+// RefundEmailRequest tells a buyer their transaction was refunded. Method
+// is where the money went: "wallet" or "original".
+type RefundEmailRequest struct {
+	Email         string                 `json:"email"`
+	TransactionID string                 `json:"transaction_id"`
+	Product       string                 `json:"product"`
+	Amount        float64                `json:"amount"`
+	Method        string                 `json:"method"`
+	Reason        string                 `json:"reason"`
+	Timestamp     string                 `json:"timestamp"`
+	Items         []TransactionEmailItem `json:"items"`
+}
+
+type WishlistAlertRequest struct {
+	Email    string  `json:"email"`
+	Kind     string  `json:"kind"`
+	BookID   uint    `json:"book_id"`
+	BookName string  `json:"book_name"`
+	OldCosts float64 `json:"old_costs"`
+	NewCosts float64 `json:"new_costs"`
+	Stock    int     `json:"stock"`
 }
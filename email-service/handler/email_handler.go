@@ -3,6 +3,7 @@ package handler
 import (
 	"email-service/dto"
 	"email-service/utility"
+	"log"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -32,6 +33,56 @@ func SendVerificationEmail(c echo.Context) error {
 	})
 }
 
+// sentReceipts keeps receipts and refund emails sent with an
+// Idempotency-Key from going out twice when the sender retries. It is set
+// with SetSentEmails on startup.
+var sentReceipts *utility.SentEmails
+
+func SetSentEmails(sent *utility.SentEmails) {
+	sentReceipts = sent
+}
+
+// sendOnce sends an email at most once per Idempotency-Key, replying with
+// what happened. A key already sent is answered as a replay without
+// sending, and one being sent by another request with 409; an email that
+// fails to send can be asked for again. Without a key it is just sent.
+func sendOnce(c echo.Context, name string, email string, send func() error) error {
+	key := c.Request().Header.Get("Idempotency-Key")
+	if key != "" {
+		sent, busy, err := sentReceipts.Begin(key)
+		if err != nil {
+			log.Println("Failed to check idempotency key", key, ":", err)
+			return c.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to send email"})
+		}
+		if sent {
+			return c.JSON(http.StatusOK, echo.Map{
+				"message":  name + " already sent",
+				"email":    email,
+				"replayed": true,
+			})
+		}
+		if busy {
+			return c.JSON(http.StatusConflict, echo.Map{"message": "Email is being sent"})
+		}
+	}
+
+	err := send()
+	if key != "" {
+		if doneErr := sentReceipts.Done(key, err == nil); doneErr != nil {
+			log.Println("Failed to record idempotency key", key, ":", doneErr)
+		}
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"message": "Failed to send email"})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": name + " sent",
+		"email":   email,
+	})
+}
+
 // Dummy handler for sending transaction success email
 func SendTransactionSuccess(c echo.Context) error {
 	var req dto.TransactionEmailRequest
@@ -39,19 +90,71 @@ func SendTransactionSuccess(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid input"})
 	}
 
-	htmlBody := utility.BuildTransactionHTMLBody(
-		req.Email,
-		req.TransactionID,
-		req.Product,
-		req.Amount,
-		req.Status,
-		req.Timestamp,
-		req.InvoiceURL,
+	return sendOnce(c, "Transaction success email", req.Email, func() error {
+		htmlBody := utility.BuildTransactionHTMLBody(
+			req.Email,
+			req.TransactionID,
+			req.Product,
+			req.Amount,
+			req.Status,
+			req.Timestamp,
+			req.InvoiceURL,
+			req.Items,
+		)
+
+		return utility.Send(
+			[]string{req.Email},
+			"Your Purchase Receipt",
+			htmlBody,
+		)
+	})
+}
+
+// SendRefund tells a buyer their transaction was refunded. Like receipts,
+// it is sent once per Idempotency-Key.
+func SendRefund(c echo.Context) error {
+	var req dto.RefundEmailRequest
+	if err := c.Bind(&req); err != nil || req.Email == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid input"})
+	}
+
+	return sendOnce(c, "Refund email", req.Email, func() error {
+		htmlBody := utility.BuildRefundHTMLBody(
+			req.Email,
+			req.TransactionID,
+			req.Product,
+			req.Amount,
+			req.Method,
+			req.Reason,
+			req.Timestamp,
+			req.Items,
+		)
+
+		return utility.Send(
+			[]string{req.Email},
+			"Your Refund",
+			htmlBody,
+		)
+	})
+}
+
+func SendWishlistAlert(c echo.Context) error {
+	var req dto.WishlistAlertRequest
+	if err := c.Bind(&req); err != nil || req.Email == "" {
+		return c.JSON(http.StatusBadRequest, echo.Map{"message": "Invalid input"})
+	}
+
+	subject, htmlBody := utility.BuildWishlistAlertHTMLBody(
+		req.Kind,
+		req.BookName,
+		req.OldCosts,
+		req.NewCosts,
+		req.Stock,
 	)
 
 	err := utility.Send(
 		[]string{req.Email},
-		"Your Purchase Receipt",
+		subject,
 		htmlBody,
 	)
 
@@ -60,7 +163,7 @@ func SendTransactionSuccess(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, echo.Map{
-		"message": "Transaction success email sent",
+		"message": "Wishlist alert sent",
 		"email":   req.Email,
 	})
 }
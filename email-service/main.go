@@ -3,7 +3,10 @@ package main
 import (
 	"email-service/config"
 	"email-service/handler"
+	"email-service/model"
+	"email-service/utility"
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -11,6 +14,14 @@ import (
 
 func main() {
 	config.LoadEnv()
+	db := config.DBInit()
+
+	// Keys of receipts already sent, so a retried request does not send
+	// one twice
+	if err := db.AutoMigrate(&model.SentEmail{}); err != nil {
+		log.Fatal("Failed to migrate sent emails:", err)
+	}
+	handler.SetSentEmails(utility.NewSentEmails(db))
 
 	e := echo.New()
 
@@ -20,6 +31,8 @@ func main() {
 
 	e.POST("/send-verification-email", handler.SendVerificationEmail)
 	e.POST("/send-transaction-success", handler.SendTransactionSuccess)
+	e.POST("/send-refund", handler.SendRefund)
+	e.POST("/send-wishlist-alert", handler.SendWishlistAlert)
 
 	fmt.Println("Connected to db")
 	e.Logger.Fatal(e.Start(":8084"))
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Sent email statuses. A key is sending while a request is sending its
+// email and sent once the email went out.
+const (
+	SentEmailSending = "sending"
+	SentEmailSent    = "sent"
+)
+
+// SentEmail records an email asked for with an Idempotency-Key, so a sender
+// retrying it does not send it twice.
+type SentEmail struct {
+	IdempotencyKey string    `gorm:"primaryKey;type:varchar(255)"`
+	Status         string    `gorm:"type:varchar(10);not null;index"`
+	UpdatedAt      time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,77 @@
+package utility
+
+import (
+	"email-service/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// sentTTL is how long a sent email's key is remembered. Senders retry
+// within minutes, so a day is plenty.
+const sentTTL = 24 * time.Hour
+
+// sendingLease is how long a key claimed for sending is held. A request
+// that died while sending leaves its claim behind, and after this long a
+// retry may take it over.
+const sendingLease = 5 * time.Minute
+
+// SentEmails remembers the idempotency keys of emails being sent or
+// already sent, so an email asked for twice goes out once. Keys are kept in
+// the database, so they survive restarts and are shared by every instance.
+type SentEmails struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewSentEmails(db *gorm.DB) *SentEmails {
+	return &SentEmails{db: db, now: time.Now}
+}
+
+// Begin claims key for sending. It reports whether the email was already
+// sent, and whether it is being sent right now by another request.
+func (s *SentEmails) Begin(key string) (sent bool, busy bool, err error) {
+	now := s.now()
+	if err := s.db.Where("status = ? AND updated_at < ?", model.SentEmailSent, now.Add(-sentTTL)).
+		Delete(&model.SentEmail{}).Error; err != nil {
+		return false, false, err
+	}
+
+	claim := model.SentEmail{IdempotencyKey: key, Status: model.SentEmailSending, UpdatedAt: now}
+	res := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&claim)
+	if res.Error != nil {
+		return false, false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return false, false, nil
+	}
+
+	var existing model.SentEmail
+	if err := s.db.Where("idempotency_key = ?", key).First(&existing).Error; err != nil {
+		return false, false, err
+	}
+	if existing.Status == model.SentEmailSent {
+		return true, false, nil
+	}
+
+	// Take over a claim its request abandoned; of two retries doing so at
+	// once only one gets it
+	res = s.db.Model(&model.SentEmail{}).
+		Where("idempotency_key = ? AND status = ? AND updated_at = ? AND updated_at < ?", key, model.SentEmailSending, existing.UpdatedAt, now.Add(-sendingLease)).
+		Update("updated_at", now)
+	if res.Error != nil {
+		return false, false, res.Error
+	}
+	return false, res.RowsAffected == 0, nil
+}
+
+// Done records the outcome of sending the email claimed with Begin. Only a
+// sent email is remembered; one that failed can be asked for again.
+func (s *SentEmails) Done(key string, sent bool) error {
+	if !sent {
+		return s.db.Where("idempotency_key = ? AND status = ?", key, model.SentEmailSending).Delete(&model.SentEmail{}).Error
+	}
+	return s.db.Model(&model.SentEmail{}).Where("idempotency_key = ?", key).
+		Updates(map[string]interface{}{"status": model.SentEmailSent, "updated_at": s.now()}).Error
+}
@@ -0,0 +1,113 @@
+package utility
+
+import (
+	"email-service/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupSentEmails returns SentEmails on a fresh in-memory database, with
+// a clock the test moves along.
+func setupSentEmails(t *testing.T) (*SentEmails, *time.Time) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.SentEmail{}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	s := NewSentEmails(db)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func TestSentEmails_FirstSend(t *testing.T) {
+	s, _ := setupSentEmails(t)
+
+	sent, busy, err := s.Begin("transaction-1")
+
+	assert.NoError(t, err)
+	assert.False(t, sent)
+	assert.False(t, busy)
+}
+
+func TestSentEmails_Replay(t *testing.T) {
+	s, _ := setupSentEmails(t)
+	_, _, err := s.Begin("transaction-1")
+	assert.NoError(t, err)
+	assert.NoError(t, s.Done("transaction-1", true))
+
+	sent, busy, err := s.Begin("transaction-1")
+
+	assert.NoError(t, err)
+	assert.True(t, sent)
+	assert.False(t, busy)
+}
+
+func TestSentEmails_Busy(t *testing.T) {
+	s, now := setupSentEmails(t)
+	_, _, err := s.Begin("transaction-1")
+	assert.NoError(t, err)
+	*now = now.Add(time.Minute)
+
+	sent, busy, err := s.Begin("transaction-1")
+
+	assert.NoError(t, err)
+	assert.False(t, sent)
+	assert.True(t, busy)
+}
+
+func TestSentEmails_ExpiredLeaseTakenOver(t *testing.T) {
+	s, now := setupSentEmails(t)
+	_, _, err := s.Begin("transaction-1")
+	assert.NoError(t, err)
+	*now = now.Add(sendingLease + time.Minute)
+
+	sent, busy, err := s.Begin("transaction-1")
+	assert.NoError(t, err)
+	assert.False(t, sent)
+	assert.False(t, busy)
+
+	// The takeover holds a lease of its own
+	*now = now.Add(time.Minute)
+	_, busy, err = s.Begin("transaction-1")
+	assert.NoError(t, err)
+	assert.True(t, busy)
+}
+
+func TestSentEmails_FailureDeletesClaim(t *testing.T) {
+	s, _ := setupSentEmails(t)
+	_, _, err := s.Begin("transaction-1")
+	assert.NoError(t, err)
+	assert.NoError(t, s.Done("transaction-1", false))
+
+	var count int64
+	s.db.Model(&model.SentEmail{}).Count(&count)
+	assert.Zero(t, count)
+
+	sent, busy, err := s.Begin("transaction-1")
+	assert.NoError(t, err)
+	assert.False(t, sent)
+	assert.False(t, busy)
+}
+
+func TestSentEmails_SentKeyForgottenAfterTTL(t *testing.T) {
+	s, now := setupSentEmails(t)
+	_, _, err := s.Begin("transaction-1")
+	assert.NoError(t, err)
+	assert.NoError(t, s.Done("transaction-1", true))
+	*now = now.Add(sentTTL + time.Minute)
+
+	sent, busy, err := s.Begin("transaction-1")
+
+	assert.NoError(t, err)
+	assert.False(t, sent)
+	assert.False(t, busy)
+}
@@ -0,0 +1,53 @@
+package utility
+
+import (
+	"email-service/dto"
+	"fmt"
+	"html"
+)
+
+// refundDestinations describes where each refund method sends the money.
+var refundDestinations = map[string]string{
+	"wallet":   "your wallet balance",
+	"original": "your original payment method",
+}
+
+func BuildRefundHTMLBody(reqEmail, txnID, product string, amount float64, method, reason, timestamp string, items []dto.TransactionEmailItem) string {
+	destination, ok := refundDestinations[method]
+	if !ok {
+		destination = "you"
+	}
+
+	reasonRow := ""
+	if reason != "" {
+		reasonRow = fmt.Sprintf(`
+					<tr><td><strong>Reason</strong></td><td>%s</td></tr>`, html.EscapeString(reason))
+	}
+
+	return fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<meta charset="UTF-8">
+			<title>Refund Receipt</title>
+		</head>
+		<body style="font-family: Arial, sans-serif; background-color: #f7f9fc; padding: 20px;">
+			<div style="max-width: 600px; margin: auto; background-color: white; padding: 30px; border-radius: 10px; box-shadow: 0 2px 8px rgba(0,0,0,0.1);">
+				<h2 style="color: #2c3e50;">💸 Transaction Refunded</h2>
+				<p>Hi %s,</p>
+				<p>Your purchase has been refunded to %s:</p>
+
+				<table style="width: 100%%; border-collapse: collapse; margin-top: 20px;">
+					<tr><td><strong>Transaction ID</strong></td><td>%s</td></tr>
+					<tr><td><strong>Product</strong></td><td>%s</td></tr>
+					<tr><td><strong>Refunded</strong></td><td>$%.2f</td></tr>%s
+					<tr><td><strong>Date</strong></td><td>%s</td></tr>
+				</table>
+				%s
+				<p style="margin-top: 20px;">Refunds back to a card or bank can take a few days to arrive.</p>
+			</div>
+		</body>
+		</html>`,
+		reqEmail, destination, txnID, html.EscapeString(product), amount, reasonRow, timestamp, buildItemsTable(items),
+	)
+}
@@ -1,8 +1,13 @@
 package utility
 
-import "fmt"
+import (
+	"email-service/dto"
+	"fmt"
+	"html"
+	"strings"
+)
 
-func BuildTransactionHTMLBody(reqEmail, txnID, product string, amount float64, status, timestamp, invoiceURL string) string {
+func BuildTransactionHTMLBody(reqEmail, txnID, product string, amount float64, status, timestamp, invoiceURL string, items []dto.TransactionEmailItem) string {
 	return fmt.Sprintf(`
 		<!DOCTYPE html>
 		<html>
@@ -24,11 +29,30 @@ func BuildTransactionHTMLBody(reqEmail, txnID, product string, amount float64, s
 					<tr><td><strong>Date</strong></td><td>%s</td></tr>
 					<tr><td><strong>Invoice</strong></td><td><a href="%s">View Invoice</a></td></tr>
 				</table>
-
+				%s
 				<p style="margin-top: 20px;">Thank you for your transaction!</p>
 			</div>
 		</body>
 		</html>`,
-		reqEmail, txnID, product, amount, status, timestamp, invoiceURL,
+		reqEmail, txnID, product, amount, status, timestamp, invoiceURL, buildItemsTable(items),
 	)
 }
+
+// buildItemsTable lists the books of the purchase, if the receipt has them.
+func buildItemsTable(items []dto.TransactionEmailItem) string {
+	if len(items) == 0 {
+		return ""
+	}
+
+	var rows strings.Builder
+	for _, item := range items {
+		rows.WriteString(fmt.Sprintf(`
+					<tr><td>%s</td><td>%d</td><td>$%.2f</td><td>$%.2f</td></tr>`,
+			html.EscapeString(item.Title), item.Qty, item.UnitPrice, item.Subtotal))
+	}
+
+	return fmt.Sprintf(`
+				<table style="width: 100%%; border-collapse: collapse; margin-top: 20px;">
+					<tr><th align="left">Book</th><th align="left">Qty</th><th align="left">Price</th><th align="left">Subtotal</th></tr>%s
+				</table>`, rows.String())
+}
@@ -0,0 +1,39 @@
+package utility
+
+import (
+	"fmt"
+	"html"
+)
+
+// BuildWishlistAlertHTMLBody renders a price-drop or back-in-stock alert.
+// The book name comes from sellers, so it is escaped.
+func BuildWishlistAlertHTMLBody(kind, bookName string, oldCosts, newCosts float64, stock int) (string, string) {
+	name := html.EscapeString(bookName)
+
+	subject := "A book on your wishlist is back in stock"
+	headline := "📚 Back in stock"
+	detail := fmt.Sprintf(`<p><strong>%s</strong> is available again, with %d in stock at $%.2f.</p>`, name, stock, newCosts)
+	if kind == "price_drop" {
+		subject = "A book on your wishlist dropped in price"
+		headline = "💸 Price drop"
+		detail = fmt.Sprintf(`<p><strong>%s</strong> now costs <strong>$%.2f</strong> (was <s>$%.2f</s>).</p>`, name, newCosts, oldCosts)
+	}
+
+	return subject, fmt.Sprintf(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<meta charset="UTF-8">
+			<title>Wishlist Alert</title>
+		</head>
+		<body style="font-family: Arial, sans-serif; background-color: #f7f9fc; padding: 20px;">
+			<div style="max-width: 600px; margin: auto; background-color: white; padding: 30px; border-radius: 10px; box-shadow: 0 2px 8px rgba(0,0,0,0.1);">
+				<h2 style="color: #2c3e50;">%s</h2>
+				%s
+				<p style="margin-top: 20px;">You are receiving this because the book is on your wishlist.</p>
+			</div>
+		</body>
+		</html>`,
+		headline, detail,
+	)
+}
@@ -75,16 +75,17 @@ func (h *GatewayHandler) GetUserByID(c echo.Context) error {
 }
 // UpdateUser godoc
 // @Summary Update user information
-// @Description Update user profile information
+// @Description Update the name and address of the signed-in user's own account. Role and balance can not be changed this way
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
 // @Param Authorization header string true "Bearer token"
-// @Param request body object{fullname=string,email=string,address=string} true "User update data"
+// @Param request body object{full_name=string,address=string} true "User update data"
 // @Success 200 {object} object{message=string,data=object}
 // @Failure 400 {object} object{message=string}
 // @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
 // @Failure 404 {object} object{message=string}
 // @Security BearerAuth
 // @Router /auth/users/{id} [put]
@@ -92,25 +93,6 @@ func (h *GatewayHandler) UpdateUser(c echo.Context) error {
 	return proxyRequest(c, h.AuthServiceURL+"/users/"+c.Param("id"))
 }
 
-// UpdateBalance godoc
-// @Summary Update user balance
-// @Description Update user account balance
-// @Tags auth
-// @Accept json
-// @Produce json
-// @Param id path int true "User ID"
-// @Param Authorization header string true "Bearer token"
-// @Param request body object{balance=number} true "Balance update data"
-// @Success 200 {object} object{message=string,data=object}
-// @Failure 400 {object} object{message=string}
-// @Failure 401 {object} object{message=string}
-// @Failure 404 {object} object{message=string}
-// @Security BearerAuth
-// @Router /auth/users/{id} [patch]
-func (h *GatewayHandler) UpdateBalance(c echo.Context) error {
-	return proxyRequest(c, h.AuthServiceURL+"/users/"+c.Param("id"))
-}
-
 // VerifyUser godoc
 // @Summary Verify user email
 // @Description Verify user email address with token
@@ -145,12 +127,15 @@ func (h *GatewayHandler) ResendVerificationEmail(c echo.Context) error {
 
 // GetBooks godoc
 // @Summary Get all books
-// @Description Get list of all books with optional category filter
+// @Description Get list of all books with optional category filter. With ids, look up those books instead and get {books, missing_ids}
 // @Tags books
 // @Accept json
 // @Produce json
 // @Param category query string false "Book category filter"
+// @Param ids query string false "Comma-separated book IDs to look up (at most 100)"
+// @Param Authorization header string false "Bearer token (optional)"
 // @Success 200 {object} object{message=string,data=array}
+// @Failure 400 {object} object{message=string}
 // @Failure 500 {object} object{message=string}
 // @Router /books [get]
 func (h *GatewayHandler) GetBooks(c echo.Context) error {
@@ -164,6 +149,7 @@ func (h *GatewayHandler) GetBooks(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param id path int true "Book ID"
+// @Param Authorization header string false "Bearer token (optional)"
 // @Success 200 {object} object{message=string,data=object}
 // @Failure 400 {object} object{message=string}
 // @Failure 404 {object} object{message=string}
@@ -228,30 +214,106 @@ func (h *GatewayHandler) DeleteBook(c echo.Context) error {
 	return proxyRequest(c, h.BookServiceURL+"/books/"+c.Param("id"))
 }
 
-// Transactions
+// Books
+// GetMyBooks godoc
+// @Summary Get the seller's books
+// @Description Get all books of the authenticated seller, optionally filtered by listing status
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param status query string false "Listing status (draft, active, paused, sold_out, archived)"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 500 {object} object{message=string}
+// @Security BearerAuth
+// @Router /books/my [get]
+func (h *GatewayHandler) GetMyBooks(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/my")
+}
 
-// CreateTransaction godoc
-// @Summary Create a new transaction
-// @Description Create a new transaction for purchasing a book
-// @Tags transactions
+// ExportMyBooks godoc
+// @Summary Export the seller's books
+// @Description Download the seller's books as a CSV or XLSX file in the import format
+// @Tags books
+// @Accept json
+// @Produce octet-stream
+// @Param format query string false "File format (csv or xlsx)"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {file} file
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 500 {object} object{message=string}
+// @Security BearerAuth
+// @Router /books/my/export [get]
+func (h *GatewayHandler) ExportMyBooks(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/my/export")
+}
+
+// GetMyAnalytics godoc
+// @Summary Get the seller's analytics
+// @Description Views, wishlist adds, sales, revenue and conversion rate of the seller's books, per book and per period
+// @Tags books
 // @Accept json
 // @Produce json
+// @Param from query string false "First day, YYYY-MM-DD (default 29 days before to)"
+// @Param to query string false "Last day, YYYY-MM-DD (default today)"
+// @Param period query string false "Grouping: day, week or month (default day)"
 // @Param Authorization header string true "Bearer token"
-// @Param request body object{book_id=int,amount=int,name=string} true "Transaction data"
-// @Success 201 {object} object{message=string,data=object{transaction_id=string,payment_url=string}}
+// @Success 200 {object} object{message=string,data=object}
 // @Failure 400 {object} object{message=string}
 // @Failure 401 {object} object{message=string}
 // @Failure 500 {object} object{message=string}
 // @Security BearerAuth
-// @Router /transactions [post]
-func (h *GatewayHandler) CreateTransaction(c echo.Context) error {
-	return proxyRequest(c, h.TransactionServiceURL+"/transactions")
+// @Router /books/my/analytics [get]
+func (h *GatewayHandler) GetMyAnalytics(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/my/analytics")
 }
 
-// GetTransactions godoc
-// @Summary Get user transactions
-// @Description Get all transactions for the authenticated user
-// @Tags transactions
+// ImportBooks godoc
+// @Summary Import books
+// @Description Import books from a CSV or XLSX file; large files are imported in the background
+// @Tags books
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run query bool false "Validate without importing"
+// @Param format query string false "File format (csv or xlsx)"
+// @Param Authorization header string true "Bearer token"
+// @Success 201 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 422 {object} object{message=string,data=object}
+// @Failure 500 {object} object{message=string}
+// @Security BearerAuth
+// @Router /books/import [post]
+func (h *GatewayHandler) ImportBooks(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/import")
+}
+
+// GetImportJob godoc
+// @Summary Get import job
+// @Description Get the progress of an asynchronous book import
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param job_id path int true "Import job ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /books/import/{job_id} [get]
+func (h *GatewayHandler) GetImportJob(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/import/"+c.Param("job_id"))
+}
+
+// GetTrash godoc
+// @Summary Get deleted books
+// @Description Get the seller's deleted books that can still be restored
+// @Tags books
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer token"
@@ -259,64 +321,918 @@ func (h *GatewayHandler) CreateTransaction(c echo.Context) error {
 // @Failure 401 {object} object{message=string}
 // @Failure 500 {object} object{message=string}
 // @Security BearerAuth
-// @Router /transactions [get]
-func (h *GatewayHandler) GetTransactions(c echo.Context) error {
-	return proxyRequest(c, h.TransactionServiceURL+"/transactions")
+// @Router /books/trash [get]
+func (h *GatewayHandler) GetTrash(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/trash")
 }
 
-// UpdateTransactionStatus godoc
-// @Summary Update transaction status
-// @Description Update transaction status (typically used by payment webhook)
-// @Tags transactions
+// RestoreBook godoc
+// @Summary Restore a deleted book
+// @Description Restore one of the seller's deleted books while it is still in the trash
+// @Tags books
 // @Accept json
 // @Produce json
-// @Param trans_id path string true "Transaction ID"
+// @Param id path int true "Book ID"
 // @Param Authorization header string true "Bearer token"
-// @Param request body object{status=string} true "Transaction status update"
 // @Success 200 {object} object{message=string,data=object}
 // @Failure 400 {object} object{message=string}
 // @Failure 401 {object} object{message=string}
 // @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /books/{id}/restore [post]
+func (h *GatewayHandler) RestoreBook(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/"+c.Param("id")+"/restore")
+}
+
+// GetBookHistory godoc
+// @Summary Get book history
+// @Description Get the change history of one of the seller's books, newest first
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /books/{id}/history [get]
+func (h *GatewayHandler) GetBookHistory(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/"+c.Param("id")+"/history")
+}
+
+// GetBookReviews godoc
+// @Summary Get book reviews
+// @Description Get the reviews of a book
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param Authorization header string false "Bearer token (optional)"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 400 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Router /books/{id}/reviews [get]
+func (h *GatewayHandler) GetBookReviews(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/"+c.Param("id")+"/reviews")
+}
+
+// Reviews
+// CreateReview godoc
+// @Summary Create a review
+// @Description Review the seller of a completed purchase
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{transaction_id=int,book_id=int,rating=int,book_rating=int,comment=string} true "Review data"
+// @Success 201 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /reviews [post]
+func (h *GatewayHandler) CreateReview(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/reviews")
+}
+
+// GetReportedReviews godoc
+// @Summary Get reported reviews
+// @Description Get reported reviews with their report counts (admin only)
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
 // @Failure 500 {object} object{message=string}
 // @Security BearerAuth
-// @Router /transactions/{trans_id} [put]
-func (h *GatewayHandler) UpdateTransactionStatus(c echo.Context) error {
-	return proxyRequest(c, h.TransactionServiceURL+"/transactions/"+c.Param("trans_id"))
+// @Router /reviews/reported [get]
+func (h *GatewayHandler) GetReportedReviews(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/reviews/reported")
 }
 
-func proxyRequest(c echo.Context, target string) error {
+// ReplyToReview godoc
+// @Summary Reply to a review
+// @Description Reply to a review of one of the seller's sales
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{reply=string} true "Reply"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /reviews/{id}/reply [post]
+func (h *GatewayHandler) ReplyToReview(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/reviews/"+c.Param("id")+"/reply")
+}
 
-	targetURL := target + "?" + c.QueryParams().Encode()
-	fmt.Println("🔗 Forwarding to:", targetURL)
+// ReportReview godoc
+// @Summary Report a review
+// @Description Report an abusive review
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{reason=string} true "Report reason"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /reviews/{id}/report [post]
+func (h *GatewayHandler) ReportReview(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/reviews/"+c.Param("id")+"/report")
+}
 
-	bodyBuf := new(bytes.Buffer)
-	if _, err := io.Copy(bodyBuf, c.Request().Body); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
+// ModerateReview godoc
+// @Summary Moderate a review
+// @Description Hide or restore a review (admin only)
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Review ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{hidden=bool} true "Moderation decision"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /reviews/{id}/moderation [put]
+func (h *GatewayHandler) ModerateReview(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/reviews/"+c.Param("id")+"/moderation")
+}
 
-	req, err := http.NewRequest(c.Request().Method, targetURL, bodyBuf)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
-	}
+// GetSellerProfile godoc
+// @Summary Get seller profile
+// @Description Get a seller's rating, active listings and recent reviews
+// @Tags reviews
+// @Accept json
+// @Produce json
+// @Param id path int true "Seller ID"
+// @Param Authorization header string false "Bearer token (optional)"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Router /sellers/{id} [get]
+func (h *GatewayHandler) GetSellerProfile(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/sellers/"+c.Param("id"))
+}
 
-	// Forward headers (especially Authorization)
-	req.Header = make(http.Header)
-	for key, values := range c.Request().Header {
-		for _, v := range values {
-			req.Header.Add(key, v)
-		}
-	}
+// Offers
+// MakeOffer godoc
+// @Summary Make an offer
+// @Description Offer a price below the listing price for an active book
+// @Tags offers
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{price=number,quantity=int} true "Offer"
+// @Success 201 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /books/{id}/offers [post]
+func (h *GatewayHandler) MakeOffer(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/"+c.Param("id")+"/offers")
+}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
-	}
-	defer resp.Body.Close()
+// GetAcceptedOffer godoc
+// @Summary Get accepted offer
+// @Description Get the caller's accepted offer on a book
+// @Tags offers
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /books/{id}/offers/accepted [get]
+func (h *GatewayHandler) GetAcceptedOffer(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/books/"+c.Param("id")+"/offers/accepted")
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read response from service")
+// GetOffers godoc
+// @Summary Get offers
+// @Description Get the offers the caller made as a buyer, or received as a seller. Only sellers receive offers
+// @Tags offers
+// @Accept json
+// @Produce json
+// @Param role query string false "buyer (default) or seller"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 500 {object} object{message=string}
+// @Security BearerAuth
+// @Router /offers [get]
+func (h *GatewayHandler) GetOffers(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/offers")
+}
+
+// GetOffer godoc
+// @Summary Get offer
+// @Description Get an offer the caller is the buyer or seller of
+// @Tags offers
+// @Accept json
+// @Produce json
+// @Param id path int true "Offer ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /offers/{id} [get]
+func (h *GatewayHandler) GetOffer(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/offers/"+c.Param("id"))
+}
+
+// CounterOffer godoc
+// @Summary Counter an offer
+// @Description Answer an open offer with a different price
+// @Tags offers
+// @Accept json
+// @Produce json
+// @Param id path int true "Offer ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{price=number} true "Counter price"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /offers/{id}/counter [post]
+func (h *GatewayHandler) CounterOffer(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/offers/"+c.Param("id")+"/counter")
+}
+
+// AcceptOffer godoc
+// @Summary Accept an offer
+// @Description Accept the current price of an open offer
+// @Tags offers
+// @Accept json
+// @Produce json
+// @Param id path int true "Offer ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /offers/{id}/accept [post]
+func (h *GatewayHandler) AcceptOffer(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/offers/"+c.Param("id")+"/accept")
+}
+
+// RejectOffer godoc
+// @Summary Reject an offer
+// @Description Reject an open offer
+// @Tags offers
+// @Accept json
+// @Produce json
+// @Param id path int true "Offer ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /offers/{id}/reject [post]
+func (h *GatewayHandler) RejectOffer(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/offers/"+c.Param("id")+"/reject")
+}
+
+// Categories
+// GetCategories godoc
+// @Summary Get categories
+// @Description Get the category tree with listing counts
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string false "Bearer token (optional)"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 500 {object} object{message=string}
+// @Router /categories [get]
+func (h *GatewayHandler) GetCategories(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/categories")
+}
+
+// CreateCategory godoc
+// @Summary Create a category
+// @Description Create a category, optionally under a parent (admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{name=string,slug=string,parent_id=int} true "Category data"
+// @Success 201 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /categories [post]
+func (h *GatewayHandler) CreateCategory(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/categories")
+}
+
+// UpdateCategory godoc
+// @Summary Update a category
+// @Description Rename, re-slug or move a category (admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{name=string,slug=string,parent_id=int,move_to_root=bool} true "Category update data"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /categories/{id} [put]
+func (h *GatewayHandler) UpdateCategory(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/categories/"+c.Param("id"))
+}
+
+// DeleteCategory godoc
+// @Summary Delete a category
+// @Description Delete a category without subcategories or listings (admin only)
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /categories/{id} [delete]
+func (h *GatewayHandler) DeleteCategory(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/categories/"+c.Param("id"))
+}
+
+// Wishlist
+// GetWishlist godoc
+// @Summary Get wishlist
+// @Description Get the authenticated user's wishlist
+// @Tags wishlist
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 401 {object} object{message=string}
+// @Failure 500 {object} object{message=string}
+// @Security BearerAuth
+// @Router /wishlist [get]
+func (h *GatewayHandler) GetWishlist(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/wishlist")
+}
+
+// AddToWishlist godoc
+// @Summary Add to wishlist
+// @Description Add a book to the wishlist to be alerted about price drops and restocks
+// @Tags wishlist
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{book_id=int} true "Book to add"
+// @Success 201 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /wishlist [post]
+func (h *GatewayHandler) AddToWishlist(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/wishlist")
+}
+
+// RemoveFromWishlist godoc
+// @Summary Remove from wishlist
+// @Description Remove a book from the wishlist
+// @Tags wishlist
+// @Accept json
+// @Produce json
+// @Param book_id path int true "Book ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /wishlist/{book_id} [delete]
+func (h *GatewayHandler) RemoveFromWishlist(c echo.Context) error {
+	return proxyRequest(c, h.BookServiceURL+"/wishlist/"+c.Param("book_id"))
+}
+
+// Transactions
+
+// CreateTransaction godoc
+// @Summary Create a new transaction
+// @Description Create a new transaction for purchasing a book. payment_method is midtrans (the default), wallet or split: wallet pays it all from the buyer's balance at once, and split pays wallet_amount from it and the rest through Midtrans. Returns 402 when the balance does not cover the wallet part
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{book_id=int,qty=int,payment_method=string,wallet_amount=number} true "Transaction data"
+// @Success 201 {object} object{message=string,data=object{transaction_id=int,payment_method=string,status=string,token_url=object{token=string,redirect_url=string}}}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 402 {object} object{message=string}
+// @Failure 500 {object} object{message=string}
+// @Security BearerAuth
+// @Router /transactions [post]
+func (h *GatewayHandler) CreateTransaction(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/transactions")
+}
+
+// GetTransactions godoc
+// @Summary Get user transactions
+// @Description Get all transactions for the authenticated user
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 401 {object} object{message=string}
+// @Failure 500 {object} object{message=string}
+// @Security BearerAuth
+// @Router /transactions [get]
+func (h *GatewayHandler) GetTransactions(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/transactions")
+}
+
+// GetTransactionByID godoc
+// @Summary Get transaction by ID
+// @Description Get one of the authenticated user's transactions
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param trans_id path string true "Transaction ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Security BearerAuth
+// @Router /transactions/{trans_id} [get]
+func (h *GatewayHandler) GetTransactionByID(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/transactions/"+c.Param("trans_id"))
+}
+
+// UpdateTransactionStatus godoc
+// @Summary Update transaction status
+// @Description Move a paid transaction along shipping: the seller sets shipped, then the buyer sets delivered and completed. Returns 409 when the transaction can not move to that status
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param trans_id path string true "Transaction ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{status=string,reason=string} true "Transaction status update"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /transactions/{trans_id} [put]
+func (h *GatewayHandler) UpdateTransactionStatus(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/transactions/"+c.Param("trans_id"))
+}
+
+// GetTransactionTimeline godoc
+// @Summary Get a transaction's timeline
+// @Description Get every status change of a transaction, oldest first, with who made it and why. Open to its buyer and seller
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param trans_id path string true "Transaction ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object{transaction_id=int,status=string,timeline=array}}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /transactions/{trans_id}/timeline [get]
+func (h *GatewayHandler) GetTransactionTimeline(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/transactions/"+c.Param("trans_id")+"/timeline")
+}
+
+// CancelTransaction godoc
+// @Summary Cancel a transaction
+// @Description Cancel a pending transaction before it is paid. The payment is cancelled at Midtrans first; a transaction from a checkout cancels the whole checkout. Open to its buyer
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param trans_id path string true "Transaction ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{reason=string} false "Cancellation reason"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /transactions/{trans_id}/cancel [post]
+func (h *GatewayHandler) CancelTransaction(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/transactions/"+c.Param("trans_id")+"/cancel")
+}
+
+// RefundTransaction godoc
+// @Summary Refund a transaction
+// @Description Refund a paid transaction to the buyer's wallet (method wallet, the default) or their original payment method (method original). The stock is restored and the seller's credit reversed. Open to its buyer until it ships, and to its seller and admins; returns 403 when the buyer asks after shipping and 409 while the transaction is still being fulfilled
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Param trans_id path string true "Transaction ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{method=string,reason=string} false "Refund method and reason"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /transactions/{trans_id}/refund [post]
+func (h *GatewayHandler) RefundTransaction(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/transactions/"+c.Param("trans_id")+"/refund")
+}
+
+// Cart
+
+// GetCart godoc
+// @Summary Get the cart
+// @Description Get the buyer's cart, checked against current prices and stock
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object{items=array,total=number,can_checkout=bool}}
+// @Failure 401 {object} object{message=string}
+// @Failure 500 {object} object{message=string}
+// @Security BearerAuth
+// @Router /cart [get]
+func (h *GatewayHandler) GetCart(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/cart")
+}
+
+// ClearCart godoc
+// @Summary Clear the cart
+// @Description Remove every book from the buyer's cart
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Security BearerAuth
+// @Router /cart [delete]
+func (h *GatewayHandler) ClearCart(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/cart")
+}
+
+// AddCartItem godoc
+// @Summary Add a book to the cart
+// @Description Add qty copies of a book to the cart, on top of any already in it
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{book_id=int,qty=int} true "Book and quantity"
+// @Success 201 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Security BearerAuth
+// @Router /cart/items [post]
+func (h *GatewayHandler) AddCartItem(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/cart/items")
+}
+
+// UpdateCartItem godoc
+// @Summary Change a cart item's quantity
+// @Description Set how many copies of a book are in the cart
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param book_id path string true "Book ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{qty=int} true "New quantity"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /cart/items/{book_id} [put]
+func (h *GatewayHandler) UpdateCartItem(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/cart/items/"+c.Param("book_id"))
+}
+
+// RemoveCartItem godoc
+// @Summary Remove a book from the cart
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param book_id path string true "Book ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /cart/items/{book_id} [delete]
+func (h *GatewayHandler) RemoveCartItem(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/cart/items/"+c.Param("book_id"))
+}
+
+// Checkout godoc
+// @Summary Check out the cart
+// @Description Buy the whole cart with one Midtrans payment, split into one transaction per seller. Returns 409 with the cart when a book became unavailable or its price changed
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 201 {object} object{message=string,data=object{token_url=object,checkout=object}}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 409 {object} object{error=string,data=object}
+// @Security BearerAuth
+// @Router /cart/checkout [post]
+func (h *GatewayHandler) Checkout(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/cart/checkout")
+}
+
+// GetCheckout godoc
+// @Summary Get a checkout
+// @Description Get one of the buyer's checkouts with its per-seller transactions
+// @Tags cart
+// @Accept json
+// @Produce json
+// @Param checkout_id path string true "Checkout ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /checkouts/{checkout_id} [get]
+func (h *GatewayHandler) GetCheckout(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/checkouts/"+c.Param("checkout_id"))
+}
+
+// CreateTopUp godoc
+// @Summary Top up the wallet
+// @Description Add money to the caller's wallet through a Midtrans payment. The balance is credited once the payment is reported paid
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param body body object{amount=number} true "Amount to add"
+// @Success 201 {object} object{message=string,data=object{token_url=object,top_up=object}}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Security BearerAuth
+// @Router /wallet/topups [post]
+func (h *GatewayHandler) CreateTopUp(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/wallet/topups")
+}
+
+// GetTopUp godoc
+// @Summary Get a wallet top-up
+// @Description Get one of the caller's top-ups: pending until paid, paid once the balance was credited
+// @Tags wallet
+// @Accept json
+// @Produce json
+// @Param top_up_id path string true "Top-up ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 401 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /wallet/topups/{top_up_id} [get]
+func (h *GatewayHandler) GetTopUp(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/wallet/topups/"+c.Param("top_up_id"))
+}
+
+// Admin
+
+// GetStuckSagas godoc
+// @Summary List stuck sagas
+// @Description List the post-payment sagas that need resolving by hand: failed ones, compensated ones whose buyer paid for nothing, and ones going for longer than SAGA_STUCK_AFTER. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Security BearerAuth
+// @Router /admin/sagas/stuck [get]
+func (h *GatewayHandler) GetStuckSagas(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/admin/sagas/stuck")
+}
+
+// RetrySaga godoc
+// @Summary Retry a saga
+// @Description Give a saga a fresh set of attempts from where it stopped and run it right away. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param saga_id path string true "Saga ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /admin/sagas/{saga_id}/retry [post]
+func (h *GatewayHandler) RetrySaga(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/admin/sagas/"+c.Param("saga_id")+"/retry")
+}
+
+// ResolveSaga godoc
+// @Summary Resolve a saga by hand
+// @Description Record how a failed or compensated saga was dealt with, taking it off the stuck list. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param saga_id path string true "Saga ID"
+// @Param Authorization header string true "Bearer token"
+// @Param request body object{resolution=string} true "How the saga was resolved"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /admin/sagas/{saga_id}/resolve [post]
+func (h *GatewayHandler) ResolveSaga(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/admin/sagas/"+c.Param("saga_id")+"/resolve")
+}
+
+// GetReconciliationRuns godoc
+// @Summary List reconciliation runs
+// @Description List the latest runs checking payments against the payment provider, newest first, with how many orders each checked, fixed and could not check. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Param limit query int false "How many runs, 20 by default"
+// @Success 200 {object} object{message=string,data=array}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Security BearerAuth
+// @Router /admin/reconciliation/runs [get]
+func (h *GatewayHandler) GetReconciliationRuns(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/admin/reconciliation/runs")
+}
+
+// RunReconciliation godoc
+// @Summary Run a reconciliation
+// @Description Check recent payments against the payment provider now. Statuses the provider moved on to are applied through the normal transitions; other discrepancies are recorded for review. Returns 409 while another run is going. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer token"
+// @Success 201 {object} object{message=string,data=object}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 409 {object} object{message=string}
+// @Security BearerAuth
+// @Router /admin/reconciliation/runs [post]
+func (h *GatewayHandler) RunReconciliation(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/admin/reconciliation/runs")
+}
+
+// GetReconciliationRun godoc
+// @Summary Get a reconciliation run
+// @Description Get a reconciliation run with every discrepancy it found: its kind (status_mismatch, amount_mismatch or missing_at_provider), both sides' status and amount, and whether it was fixed, needs review or could not be fixed. Admin only
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param run_id path string true "Run ID"
+// @Param Authorization header string true "Bearer token"
+// @Success 200 {object} object{message=string,data=object}
+// @Failure 400 {object} object{message=string}
+// @Failure 401 {object} object{message=string}
+// @Failure 403 {object} object{message=string}
+// @Failure 404 {object} object{message=string}
+// @Security BearerAuth
+// @Router /admin/reconciliation/runs/{run_id} [get]
+func (h *GatewayHandler) GetReconciliationRun(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/admin/reconciliation/runs/"+c.Param("run_id"))
+}
+
+// Payments
+
+// MidtransNotification godoc
+// @Summary Midtrans payment notification
+// @Description Endpoint for Midtrans HTTP notifications. Needs no token: the signature_key is checked against the server key, and repeated notifications are acknowledged without changing anything
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param request body object{order_id=string,status_code=string,gross_amount=string,signature_key=string,transaction_status=string,fraud_status=string} true "Midtrans notification"
+// @Success 200 {object} object{message=string,status=bool}
+// @Failure 400 {object} object{error=string}
+// @Failure 403 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /payments/midtrans/notification [post]
+func (h *GatewayHandler) MidtransNotification(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/payments/midtrans/notification")
+}
+
+// SimulatePayment godoc
+// @Summary Simulate a payment
+// @Description Move an order's charge at the fake payment provider to a Midtrans status (pending, capture, settlement, deny, cancel or expire) and apply it as a notification would. Only available when the gateway and transaction-service run with PAYMENT_PROVIDER=fake
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Param status path string true "Payment status"
+// @Success 200 {object} object{message=string,data=object{order_id=string,status=string,provider_status=string,gross_amount=number}}
+// @Failure 400 {object} object{error=string}
+// @Failure 404 {object} object{error=string}
+// @Router /payments/fake/{order_id}/{status} [post]
+func (h *GatewayHandler) SimulatePayment(c echo.Context) error {
+	return proxyRequest(c, h.TransactionServiceURL+"/payments/fake/"+c.Param("order_id")+"/"+c.Param("status"))
+}
+
+func proxyRequest(c echo.Context, target string) error {
+
+	targetURL := target + "?" + c.QueryParams().Encode()
+	fmt.Println("🔗 Forwarding to:", targetURL)
+
+	bodyBuf := new(bytes.Buffer)
+	if _, err := io.Copy(bodyBuf, c.Request().Body); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	req, err := http.NewRequest(c.Request().Method, targetURL, bodyBuf)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	// Forward headers (especially Authorization)
+	req.Header = make(http.Header)
+	for key, values := range c.Request().Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadGateway, err.Error())
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read response from service")
+	}
+
+	for _, header := range []string{"ETag", "Content-Disposition"} {
+		if value := resp.Header.Get(header); value != "" {
+			c.Response().Header().Set(header, value)
+		}
 	}
 
 	return c.Blob(resp.StatusCode, resp.Header.Get("Content-Type"), body)
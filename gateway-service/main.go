@@ -54,22 +54,99 @@ func main() {
 	authGroup.POST("/login", h.Login)
 	authGroup.GET("/users/:id", h.GetUserByID)
 	authGroup.PUT("/users/:id", h.UpdateUser)
-	authGroup.PATCH("/users/:id", h.UpdateBalance)
 	authGroup.POST("/users/verify", h.VerifyUser)
 	authGroup.POST("/users/resend-verification-email", h.ResendVerificationEmail)
 	// Book endpoints
+	// Browsing endpoints are public; book-service checks the token on the
+	// rest, and the Authorization header is forwarded whenever it is sent.
 	bookGroup := e.Group("/books")
 	bookGroup.GET("", h.GetBooks)
 	bookGroup.GET("/:id", h.GetBookByID)
 	bookGroup.POST("", h.CreateBook)
 	bookGroup.PUT("/:id", h.UpdateBook)
 	bookGroup.DELETE("/:id", h.DeleteBook)
+	bookGroup.GET("/my", h.GetMyBooks)
+	bookGroup.GET("/my/export", h.ExportMyBooks)
+	bookGroup.GET("/my/analytics", h.GetMyAnalytics)
+	bookGroup.POST("/import", h.ImportBooks)
+	bookGroup.GET("/import/:job_id", h.GetImportJob)
+	bookGroup.GET("/trash", h.GetTrash)
+	bookGroup.POST("/:id/restore", h.RestoreBook)
+	bookGroup.GET("/:id/history", h.GetBookHistory)
+	bookGroup.GET("/:id/reviews", h.GetBookReviews)
+	bookGroup.POST("/:id/offers", h.MakeOffer)
+	bookGroup.GET("/:id/offers/accepted", h.GetAcceptedOffer)
+
+	// Review endpoints
+	reviewGroup := e.Group("/reviews")
+	reviewGroup.POST("", h.CreateReview)
+	reviewGroup.GET("/reported", h.GetReportedReviews)
+	reviewGroup.POST("/:id/reply", h.ReplyToReview)
+	reviewGroup.POST("/:id/report", h.ReportReview)
+	reviewGroup.PUT("/:id/moderation", h.ModerateReview)
+	e.GET("/sellers/:id", h.GetSellerProfile)
+
+	// Offer endpoints
+	offerGroup := e.Group("/offers")
+	offerGroup.GET("", h.GetOffers)
+	offerGroup.GET("/:id", h.GetOffer)
+	offerGroup.POST("/:id/counter", h.CounterOffer)
+	offerGroup.POST("/:id/accept", h.AcceptOffer)
+	offerGroup.POST("/:id/reject", h.RejectOffer)
+
+	// Category endpoints
+	categoryGroup := e.Group("/categories")
+	categoryGroup.GET("", h.GetCategories)
+	categoryGroup.POST("", h.CreateCategory)
+	categoryGroup.PUT("/:id", h.UpdateCategory)
+	categoryGroup.DELETE("/:id", h.DeleteCategory)
+
+	// Wishlist endpoints
+	wishlistGroup := e.Group("/wishlist")
+	wishlistGroup.GET("", h.GetWishlist)
+	wishlistGroup.POST("", h.AddToWishlist)
+	wishlistGroup.DELETE("/:book_id", h.RemoveFromWishlist)
 
 	// Transaction endpoints
 	transactionGroup := e.Group("/transactions")
 	transactionGroup.POST("", h.CreateTransaction)
 	transactionGroup.GET("", h.GetTransactions)
+	transactionGroup.GET("/:trans_id", h.GetTransactionByID)
 	transactionGroup.PUT("/:trans_id", h.UpdateTransactionStatus)
+	transactionGroup.GET("/:trans_id/timeline", h.GetTransactionTimeline)
+	transactionGroup.POST("/:trans_id/cancel", h.CancelTransaction)
+	transactionGroup.POST("/:trans_id/refund", h.RefundTransaction)
+
+	// Cart endpoints
+	cartGroup := e.Group("/cart")
+	cartGroup.GET("", h.GetCart)
+	cartGroup.DELETE("", h.ClearCart)
+	cartGroup.POST("/items", h.AddCartItem)
+	cartGroup.PUT("/items/:book_id", h.UpdateCartItem)
+	cartGroup.DELETE("/items/:book_id", h.RemoveCartItem)
+	cartGroup.POST("/checkout", h.Checkout)
+	e.GET("/checkouts/:checkout_id", h.GetCheckout)
+
+	// Wallet endpoints
+	e.POST("/wallet/topups", h.CreateTopUp)
+	e.GET("/wallet/topups/:top_up_id", h.GetTopUp)
+
+	// Admin endpoints
+	adminGroup := e.Group("/admin")
+	adminGroup.GET("/sagas/stuck", h.GetStuckSagas)
+	adminGroup.POST("/sagas/:saga_id/retry", h.RetrySaga)
+	adminGroup.POST("/sagas/:saga_id/resolve", h.ResolveSaga)
+	adminGroup.GET("/reconciliation/runs", h.GetReconciliationRuns)
+	adminGroup.POST("/reconciliation/runs", h.RunReconciliation)
+	adminGroup.GET("/reconciliation/runs/:run_id", h.GetReconciliationRun)
+
+	// Payment provider callbacks
+	e.POST("/payments/midtrans/notification", h.MidtransNotification)
+	// Only exposed when the fake provider is in use, as transaction-service
+	// is told with the same variable
+	if os.Getenv("PAYMENT_PROVIDER") == "fake" {
+		e.POST("/payments/fake/:order_id/:status", h.SimulatePayment)
+	}
 
 	// 4. Run server
 	port := os.Getenv("PORT")
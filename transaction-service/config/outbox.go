@@ -0,0 +1,20 @@
+package config
+
+import "time"
+
+// OutboxConfig tunes the relay delivering outbox events. Due events are
+// picked up every PollInterval; a failed delivery is tried again after
+// RetryBase, doubling each time up to RetryMax, for as long as it takes.
+type OutboxConfig struct {
+	PollInterval time.Duration
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+}
+
+func LoadOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		PollInterval: durationFromEnv("OUTBOX_POLL_INTERVAL", 5*time.Second),
+		RetryBase:    durationFromEnv("OUTBOX_RETRY_BASE", 10*time.Second),
+		RetryMax:     durationFromEnv("OUTBOX_RETRY_MAX", 10*time.Minute),
+	}
+}
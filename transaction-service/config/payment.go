@@ -0,0 +1,53 @@
+package config
+
+import (
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+// Payment providers and Midtrans environments PaymentConfig can name.
+const (
+	PaymentMidtrans = "midtrans"
+	PaymentFake     = "fake"
+
+	MidtransSandbox    = "sandbox"
+	MidtransProduction = "production"
+)
+
+// PaymentConfig picks who payments go through. Provider is "midtrans" or
+// "fake", an in-memory provider for running the purchase flow offline.
+// Midtrans is used in its sandbox unless Environment is "production".
+type PaymentConfig struct {
+	Provider    string
+	Environment string
+	ServerKey   string
+	ClientKey   string
+}
+
+func LoadPaymentConfig() PaymentConfig {
+	godotenv.Load()
+	return PaymentConfig{
+		Provider:    choiceFromEnv("PAYMENT_PROVIDER", PaymentMidtrans, PaymentFake),
+		Environment: choiceFromEnv("MIDTRANS_ENV", MidtransSandbox, MidtransProduction),
+		ServerKey:   os.Getenv("MIDTRANS_SERVER_KEYS"),
+		ClientKey:   os.Getenv("MIDTRANS_CLIENT_KEYS"),
+	}
+}
+
+// choiceFromEnv reads one of choices from key, falling back to the first.
+func choiceFromEnv(key string, choices ...string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return choices[0]
+	}
+
+	for _, choice := range choices {
+		if value == choice {
+			return value
+		}
+	}
+	log.Printf("Warning: invalid %s %q, using %s", key, value, choices[0])
+	return choices[0]
+}
@@ -0,0 +1,18 @@
+package config
+
+import "time"
+
+// ReconciliationConfig tunes the job checking payments against the payment
+// provider. It runs every Interval and checks the orders charged within
+// Lookback.
+type ReconciliationConfig struct {
+	Interval time.Duration
+	Lookback time.Duration
+}
+
+func LoadReconciliationConfig() ReconciliationConfig {
+	return ReconciliationConfig{
+		Interval: durationFromEnv("RECONCILE_INTERVAL", 15*time.Minute),
+		Lookback: durationFromEnv("RECONCILE_LOOKBACK", 72*time.Hour),
+	}
+}
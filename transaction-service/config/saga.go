@@ -0,0 +1,58 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SagaConfig tunes how post-payment sagas are retried. A failing step is
+// tried again after RetryBase, doubling each time up to RetryMax, until it
+// has been tried MaxAttempts times. Sagas still going after StuckAfter are
+// listed as stuck.
+type SagaConfig struct {
+	RetryBase    time.Duration
+	RetryMax     time.Duration
+	MaxAttempts  int
+	PollInterval time.Duration
+	StuckAfter   time.Duration
+}
+
+func LoadSagaConfig() SagaConfig {
+	return SagaConfig{
+		RetryBase:    durationFromEnv("SAGA_RETRY_BASE", 30*time.Second),
+		RetryMax:     durationFromEnv("SAGA_RETRY_MAX", 30*time.Minute),
+		MaxAttempts:  intFromEnv("SAGA_MAX_ATTEMPTS", 6),
+		PollInterval: durationFromEnv("SAGA_POLL_INTERVAL", time.Minute),
+		StuckAfter:   durationFromEnv("SAGA_STUCK_AFTER", time.Hour),
+	}
+}
+
+func durationFromEnv(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: invalid %s %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+func intFromEnv(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: invalid %s %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
@@ -1,14 +1,61 @@
 package dto
 
+// CreateTransactionRequest buys a book. PaymentMethod is "midtrans" (the
+// default), "wallet" or "split"; a split pays WalletAmount from the
+// buyer's balance and the rest through Midtrans.
 type CreateTransactionRequest struct {
-	BookID int `json:"book_id" validate:"required"`
-	Qty    int `json:"qty" validate:"required"`
+	BookID        int     `json:"book_id" validate:"required"`
+	Qty           int     `json:"qty" validate:"required"`
+	PaymentMethod string  `json:"payment_method"`
+	WalletAmount  float64 `json:"wallet_amount"`
 }
 
-type WebhookRequest struct {
-	TransactionID uint `json:"transaction_id"`
-	Qty           int  `json:"qty"`
+// MidtransNotification is the body of a Midtrans HTTP notification. Only
+// the fields we act on are read.
+type MidtransNotification struct {
+	OrderID           string `json:"order_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	PaymentType       string `json:"payment_type"`
 }
+
+// UpdateTransactionStatusRequest moves a paid transaction along shipping.
 type UpdateTransactionStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason"`
+}
+
+// CancelTransactionRequest says why a buyer cancels a transaction.
+type CancelTransactionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// RefundTransactionRequest asks for a paid transaction to be refunded, to
+// the buyer's wallet ("wallet", the default) or back to how it was paid
+// ("original").
+type RefundTransactionRequest struct {
+	Method string `json:"method"`
+	Reason string `json:"reason"`
+}
+
+type CartItemRequest struct {
+	BookID int `json:"book_id" validate:"required"`
+	Qty    int `json:"qty" validate:"required"`
+}
+
+type UpdateCartItemRequest struct {
 	Qty int `json:"qty" validate:"required"`
 }
+
+// ResolveSagaRequest says how an admin dealt with a stuck saga.
+type ResolveSagaRequest struct {
+	Resolution string `json:"resolution" validate:"required"`
+}
+
+// TopUpRequest adds Amount to the caller's wallet, paid through Midtrans.
+type TopUpRequest struct {
+	Amount float64 `json:"amount" validate:"required"`
+}
@@ -2,20 +2,66 @@ package dto
 
 import "main/model"
 
-type GetBookByIDResponse struct {
-	Message string       `json:"message"`
-	Data    BookResponse `json:"data"`
+type GetBooksResponse struct {
+	Message string            `json:"message"`
+	Data    BookBatchResponse `json:"data"`
+}
+
+type BookBatchResponse struct {
+	Books      []BookResponse `json:"books"`
+	MissingIDs []uint         `json:"missing_ids"`
 }
 
 type BookResponse struct {
-	ID       uint    `json:"id"`
-	Name     string  `json:"name"`
-	Stock    int     `json:"stock"`
-	Cost     float64 `json:"costs"`
-	SellerID uint    `json:"seller_id"`
+	ID             uint    `json:"id"`
+	Name           string  `json:"name"`
+	Stock          int     `json:"stock"`
+	AvailableStock int     `json:"available_stock"`
+	Cost           float64 `json:"costs"`
+	SellerID       uint    `json:"seller_id"`
+	Status         string  `json:"status"`
 }
 
 type GetUserByIDResponse struct {
 	Message string     `json:"message"`
 	User    model.User `json:"user"`
 }
+
+type GetOfferResponse struct {
+	Message string        `json:"message"`
+	Data    OfferResponse `json:"data"`
+}
+
+type OfferResponse struct {
+	ID       uint    `json:"id"`
+	Price    float64 `json:"price"`
+	Quantity int     `json:"quantity"`
+}
+
+// Problems that keep a cart item from being checked out.
+const (
+	CartIssueUnavailable       = "unavailable"
+	CartIssueInsufficientStock = "insufficient_stock"
+	CartIssuePriceChanged      = "price_changed"
+)
+
+// CartItemResponse is a cart item checked against book-service. UnitPrice
+// is the price the buyer saw when adding the book, CurrentPrice what it
+// costs now.
+type CartItemResponse struct {
+	BookID         int     `json:"book_id"`
+	Name           string  `json:"name"`
+	SellerID       uint    `json:"seller_id"`
+	Qty            int     `json:"qty"`
+	UnitPrice      float64 `json:"unit_price"`
+	CurrentPrice   float64 `json:"current_price"`
+	AvailableStock int     `json:"available_stock"`
+	Subtotal       float64 `json:"subtotal"`
+	Issue          string  `json:"issue,omitempty"`
+}
+
+type CartResponse struct {
+	Items       []CartItemResponse `json:"items"`
+	Total       float64            `json:"total"`
+	CanCheckout bool               `json:"can_checkout"`
+}
@@ -0,0 +1,381 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"main/dto"
+	"main/helper"
+	"main/model"
+	"main/service"
+	"main/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CartHandler struct {
+	cartServ  service.CartService
+	transServ service.TransactionService
+	payment   service.PaymentGateway
+}
+
+func NewCartHandler(cartServ service.CartService, transServ service.TransactionService, payment service.PaymentGateway) *CartHandler {
+	return &CartHandler{cartServ: cartServ, transServ: transServ, payment: payment}
+}
+
+// GetCart returns the buyer's cart checked against the current books in
+// book-service, flagging items that can not be bought as they are.
+func (h *CartHandler) GetCart(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	cart, err := h.loadCart(user_id)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Cart retrieved successfully", cart)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// AddCartItem puts qty copies of a book in the cart, on top of any already
+// there.
+func (h *CartHandler) AddCartItem(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	var req dto.CartItemRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrBadReq
+	}
+	if req.Qty <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "quantity must be greater than 0")
+	}
+
+	items, err := h.cartServ.GetItems(user_id)
+	if err != nil {
+		return err
+	}
+	qty := req.Qty
+	for _, item := range items {
+		if item.Book_ID == req.BookID {
+			qty += item.Qty
+		}
+	}
+
+	book, err := purchasableBook(user_id, req.BookID, qty)
+	if err != nil {
+		return err
+	}
+
+	item, err := h.cartServ.AddItem(model.CartItem{
+		User_ID:    user_id,
+		Book_ID:    req.BookID,
+		Qty:        req.Qty,
+		Unit_Price: book.Cost,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Book added to cart", item)
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *CartHandler) UpdateCartItem(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	book_id, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+
+	var req dto.UpdateCartItemRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrBadReq
+	}
+	if req.Qty <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "quantity must be greater than 0")
+	}
+
+	if _, err := purchasableBook(user_id, book_id, req.Qty); err != nil {
+		return err
+	}
+	if err := h.cartServ.SetQty(user_id, book_id, req.Qty); err != nil {
+		return err
+	}
+
+	cart, err := h.loadCart(user_id)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Cart updated successfully", cart)
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *CartHandler) RemoveCartItem(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	book_id, err := strconv.Atoi(c.Param("book_id"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+
+	if err := h.cartServ.RemoveItem(user_id, book_id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Book removed from cart",
+	})
+}
+
+func (h *CartHandler) ClearCart(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	if err := h.cartServ.Clear(user_id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Cart cleared",
+	})
+}
+
+// Checkout buys the whole cart with one payment. The books are revalidated
+// first: if any is no longer available, or its price changed since it was
+// added, nothing is bought and the cart is returned for the buyer to
+// review, with the new prices saved so a second checkout goes through.
+// Otherwise one transaction is created per seller, all of them under one
+// checkout paid through a single Midtrans payment.
+func (h *CartHandler) Checkout(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+	name := c.Get("name").(string)
+	email := c.Get("email").(string)
+
+	cart, err := h.loadCart(user_id)
+	if err != nil {
+		return err
+	}
+	if len(cart.Items) == 0 {
+		return utils.ErrCartEmpty
+	}
+
+	if !cart.CanCheckout {
+		for _, item := range cart.Items {
+			if item.Issue == dto.CartIssuePriceChanged {
+				if err := h.cartServ.SetPrice(user_id, item.BookID, item.CurrentPrice); err != nil {
+					return err
+				}
+			}
+		}
+		return c.JSON(http.StatusConflict, map[string]interface{}{
+			"error": "cart changed, review it before checking out",
+			"data":  cart,
+		})
+	}
+
+	// One transaction per seller, in the order their books were added
+	checkout := model.Checkout{Amount: cart.Total}
+	orders := map[uint]int{}
+	for _, item := range cart.Items {
+		i, ok := orders[item.SellerID]
+		if !ok {
+			i = len(checkout.Transactions)
+			orders[item.SellerID] = i
+			checkout.Transactions = append(checkout.Transactions, model.Transaction{Seller_ID: int(item.SellerID), Payment_Method: model.PayByMidtrans})
+		}
+		order := &checkout.Transactions[i]
+		order.Amount += item.Subtotal
+		order.Items = append(order.Items, model.NewTransactionItem(item.BookID, item.Name, int(item.SellerID), item.CurrentPrice, item.Qty))
+	}
+	for i := range checkout.Transactions {
+		if len(checkout.Transactions[i].Items) == 1 {
+			checkout.Transactions[i].Book_ID = checkout.Transactions[i].Items[0].Book_ID
+		}
+	}
+
+	checkout, err = h.transServ.CreateCheckout(user_id, checkout)
+	if err != nil {
+		return err
+	}
+
+	// Hold the stock of every book until the checkout can no longer be paid
+	ttl := time.Until(checkout.Expiration_Date) + 30*time.Minute
+	for _, trans := range checkout.Transactions {
+		for _, item := range trans.Items {
+			if err := utils.ReserveStock(uint(item.Book_ID), trans.Transaction_ID, item.Qty, ttl); err != nil {
+				if cancelErr := h.failCheckout(checkout, "stock could not be reserved"); cancelErr != nil {
+					return cancelErr
+				}
+				if err == utils.ErrInsufficientStock {
+					return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+				}
+				return err
+			}
+		}
+	}
+
+	// Charge the whole cart as one payment, under an order ID saved first
+	// so the charge can always be matched to the checkout
+	orderId := fmt.Sprintf("checkout-%d-%d", checkout.Checkout_ID, time.Now().Unix())
+	if err := h.transServ.SetCheckoutOrderID(int(checkout.Checkout_ID), orderId); err != nil {
+		if cancelErr := h.failCheckout(checkout, "order ID could not be saved"); cancelErr != nil {
+			return cancelErr
+		}
+		return err
+	}
+	checkout.Order_ID = orderId
+
+	tokenUrl, err := h.payment.CreateCharge(service.PaymentCharge{
+		Order_ID: orderId,
+		Amount:   checkout.Amount,
+		Name:     name,
+		Email:    email,
+	})
+	if err != nil {
+		if cancelErr := h.failCheckout(checkout, "payment could not be created"); cancelErr != nil {
+			return cancelErr
+		}
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := h.cartServ.Clear(user_id); err != nil {
+		return err
+	}
+
+	res := struct {
+		TokenUrl service.PaymentLink `json:"token_url"`
+		Checkout model.Checkout      `json:"checkout"`
+	}{
+		TokenUrl: tokenUrl,
+		Checkout: checkout,
+	}
+
+	resp := helper.RespHelper("Checkout created successfully", res)
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// GetCheckout returns one of the caller's checkouts with its transactions.
+func (h *CartHandler) GetCheckout(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	checkout_id, err := strconv.Atoi(c.Param("checkout_id"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+
+	checkout, err := h.transServ.GetCheckoutByID(checkout_id)
+	if err != nil {
+		return err
+	}
+	if checkout.User_ID != user_id {
+		return utils.ErrCheckoutNotFound
+	}
+
+	resp := helper.RespHelper("Checkout retrieved successfully", checkout)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// loadCart checks the buyer's cart items against book-service in one batch
+// call.
+func (h *CartHandler) loadCart(user_id int) (dto.CartResponse, error) {
+	items, err := h.cartServ.GetItems(user_id)
+	if err != nil {
+		return dto.CartResponse{}, err
+	}
+
+	cart := dto.CartResponse{Items: []dto.CartItemResponse{}, CanCheckout: true}
+	if len(items) == 0 {
+		return cart, nil
+	}
+
+	ids := make([]uint, len(items))
+	for i, item := range items {
+		ids[i] = uint(item.Book_ID)
+	}
+	books, _, err := utils.GetBooksByIDs(ids)
+	if err != nil {
+		return dto.CartResponse{}, err
+	}
+
+	for _, item := range items {
+		line := dto.CartItemResponse{
+			BookID:    item.Book_ID,
+			Qty:       item.Qty,
+			UnitPrice: item.Unit_Price,
+		}
+
+		book, ok := books[uint(item.Book_ID)]
+		switch {
+		case !ok || book.Status != "active":
+			line.Issue = dto.CartIssueUnavailable
+		case item.Qty > book.AvailableStock:
+			line.Issue = dto.CartIssueInsufficientStock
+		case book.Cost != item.Unit_Price:
+			line.Issue = dto.CartIssuePriceChanged
+		}
+		if ok {
+			line.Name = book.Name
+			line.SellerID = book.SellerID
+			line.CurrentPrice = book.Cost
+			line.AvailableStock = book.AvailableStock
+			line.Subtotal = float64(item.Qty) * book.Cost
+		}
+
+		if line.Issue != "" {
+			cart.CanCheckout = false
+		} else {
+			cart.Total += line.Subtotal
+		}
+		cart.Items = append(cart.Items, line)
+	}
+	return cart, nil
+}
+
+// failCheckout cancels the checkout's transactions, which gives back the
+// stock held for them, and the checkout. Every one of them is tried; the
+// first that could not be cancelled is returned, and the checkout is left
+// pending for the expiry job to end.
+func (h *CartHandler) failCheckout(checkout model.Checkout, reason string) error {
+	var failed error
+	for _, trans := range checkout.Transactions {
+		if _, err := h.transServ.Transition(int(trans.Transaction_ID), model.StatusCancelled, model.ActorSystem, reason); err != nil {
+			log.Printf("Failed to cancel transaction %d of checkout %d: %v", trans.Transaction_ID, checkout.Checkout_ID, err)
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+
+	if _, err := h.transServ.TransitionCheckoutStatus(int(checkout.Checkout_ID), model.StatusPending, model.StatusCancelled); err != nil {
+		log.Printf("Failed to cancel checkout %d: %v", checkout.Checkout_ID, err)
+		return err
+	}
+	return nil
+}
+
+// purchasableBook checks that the buyer can buy qty copies of the book.
+func purchasableBook(user_id int, book_id int, qty int) (dto.BookResponse, error) {
+	book, err := utils.GetBookByID(uint(book_id))
+	if err != nil {
+		if err == utils.ErrBookNotFound {
+			return dto.BookResponse{}, echo.NewHTTPError(http.StatusBadRequest, "book not found")
+		}
+		return dto.BookResponse{}, err
+	}
+	if book.Status != "active" {
+		return dto.BookResponse{}, echo.NewHTTPError(http.StatusBadRequest, "book is not available")
+	}
+	if int(book.SellerID) == user_id {
+		return dto.BookResponse{}, echo.NewHTTPError(http.StatusBadRequest, "you can not buy your own book")
+	}
+	if qty > book.AvailableStock {
+		return dto.BookResponse{}, echo.NewHTTPError(http.StatusBadRequest, "quantity exceeds available stock")
+	}
+	return book, nil
+}
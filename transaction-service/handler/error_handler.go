@@ -19,15 +19,18 @@ func ErrorHandler(err error, c echo.Context) {
 		switch {
 		case err == nil:
 			return
-		case err == utils.ErrUserNotFound:
+		case err == utils.ErrUserNotFound, err == utils.ErrCartItemNotFound, err == utils.ErrCheckoutNotFound, err == utils.ErrTopUpNotFound, err == utils.ErrOrderNotFound, err == utils.ErrSagaNotFound, err == utils.ErrReconciliationRunNotFound:
 			status = http.StatusNotFound
 			message = err.Error()
-		case err == utils.ErrUserForbidden:
+		case err == utils.ErrUserForbidden, err == utils.ErrInvalidSignature, err == utils.ErrRefundNeedsApproval:
 			status = http.StatusForbidden
 			message = err.Error()
-		case err == utils.ErrBadReq:
+		case err == utils.ErrBadReq, err == utils.ErrCartEmpty:
 			status = http.StatusBadRequest
 			message = err.Error()
+		case err == utils.ErrInvalidTransition, err == utils.ErrSagaState, err == utils.ErrRefundNotReady, err == utils.ErrPaymentNotCancellable, err == utils.ErrTopUpNotRefundable, err == utils.ErrReconciliationRunning:
+			status = http.StatusConflict
+			message = err.Error()
 		case err == utils.ErrUnauthorized:
 			status = http.StatusUnauthorized
 			message = err.Error()
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"main/helper"
+	"main/service"
+	"main/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ReconciliationHandler lets admins and finance see how payments compared
+// with the payment provider, and check them again on demand.
+type ReconciliationHandler struct {
+	serv service.ReconciliationService
+}
+
+func NewReconciliationHandler(serv service.ReconciliationService) *ReconciliationHandler {
+	return &ReconciliationHandler{serv: serv}
+}
+
+// GetRuns lists the latest reconciliation runs, newest first. limit caps
+// how many, 20 by default.
+func (h *ReconciliationHandler) GetRuns(c echo.Context) error {
+	limit := 20
+	if l := c.QueryParam("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			return utils.ErrBadReq
+		}
+		limit = n
+	}
+
+	runs, err := h.serv.GetRuns(limit)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Reconciliation runs retrieved successfully", runs)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetRun returns a reconciliation run with the discrepancies it found.
+func (h *ReconciliationHandler) GetRun(c echo.Context) error {
+	run_id, err := strconv.Atoi(c.Param("run_id"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+
+	run, err := h.serv.GetRun(run_id)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Reconciliation run retrieved successfully", run)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RunReconciliation checks payments against the provider right away.
+func (h *ReconciliationHandler) RunReconciliation(c echo.Context) error {
+	run, err := h.serv.Run()
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Reconciliation run completed", run)
+	return c.JSON(http.StatusCreated, resp)
+}
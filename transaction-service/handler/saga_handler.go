@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"main/dto"
+	"main/helper"
+	"main/service"
+	"main/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SagaHandler lets admins see and resolve the post-payment sagas that could
+// not finish on their own.
+type SagaHandler struct {
+	serv service.SagaService
+}
+
+func NewSagaHandler(serv service.SagaService) *SagaHandler {
+	return &SagaHandler{serv: serv}
+}
+
+// GetStuckSagas lists failed sagas, compensated ones whose buyer still has
+// to be refunded, and ones that have been going for too long.
+func (h *SagaHandler) GetStuckSagas(c echo.Context) error {
+	sagas, err := h.serv.GetStuck()
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Stuck sagas retrieved successfully", sagas)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RetrySaga gives a saga a fresh set of attempts and runs it right away.
+func (h *SagaHandler) RetrySaga(c echo.Context) error {
+	saga_id, err := strconv.Atoi(c.Param("saga_id"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+
+	saga, err := h.serv.Retry(saga_id)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Saga retried", saga)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ResolveSaga records how a saga was dealt with by hand.
+func (h *SagaHandler) ResolveSaga(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	saga_id, err := strconv.Atoi(c.Param("saga_id"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+
+	var req dto.ResolveSagaRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrBadReq
+	}
+	if req.Resolution == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "resolution is required")
+	}
+
+	saga, err := h.serv.Resolve(saga_id, user_id, req.Resolution)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Saga resolved", saga)
+	return c.JSON(http.StatusOK, resp)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"fmt"
+	"io"
 	"main/dto"
 	"main/helper"
 	"main/model"
@@ -9,19 +10,18 @@ import (
 	"main/utils"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/veritrans/go-midtrans"
 )
 
 type TransactionHandler struct {
-	serv service.TransactionService
+	serv    service.TransactionService
+	payment service.PaymentGateway
 }
 
-func NewTransactionHandler(serv service.TransactionService) *TransactionHandler {
-	return &TransactionHandler{serv: serv}
+func NewTransactionHandler(serv service.TransactionService, payment service.PaymentGateway) *TransactionHandler {
+	return &TransactionHandler{serv: serv, payment: payment}
 }
 
 func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
@@ -33,32 +33,71 @@ func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		return utils.ErrBadReq
 	}
+	method := model.PaymentMethod(req.PaymentMethod)
+	if method == "" {
+		method = model.PayByMidtrans
+	}
+	if !method.Valid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "payment_method can only be midtrans, wallet or split")
+	}
 
-	// Get token for calling book-service
+	// Offers belong to the buyer, so their token is forwarded for them
 	token := c.Request().Header.Get("Authorization")
 
 	// Fetch book data
-	book, err := utils.GetBookByID(uint(req.BookID), token)
+	book, err := utils.GetBookByID(uint(req.BookID))
 	if err != nil || book.ID == 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "book not found")
 	}
+	if book.Status != "active" {
+		return echo.NewHTTPError(http.StatusBadRequest, "book is not available")
+	}
 	fmt.Println("Book Response:", book)
 
 	// Validate stock
 	if req.Qty <= 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "quantity must be greater than 0")
 	}
-	if req.Qty > book.Stock {
+	if req.Qty > book.AvailableStock {
 		return echo.NewHTTPError(http.StatusBadRequest, "quantity exceeds available stock")
 	}
 
-	// Compute amount
-	amount := float64(req.Qty) * book.Cost
+	// Use the price agreed with the seller, if the buyer has one
+	offer, err := utils.GetAcceptedOffer(uint(req.BookID), token)
+	if err != nil {
+		return err
+	}
+
+	// Snapshot what is bought and at which price
+	items := purchaseItems(book, req.Qty, offer)
+	var amount float64
+	for _, item := range items {
+		amount += item.Subtotal
+	}
+
+	// Work out how much of it comes from the buyer's wallet
+	var walletAmount float64
+	switch method {
+	case model.PayByWallet:
+		walletAmount = amount
+	case model.PayBySplit:
+		if req.WalletAmount <= 0 || req.WalletAmount >= amount {
+			return echo.NewHTTPError(http.StatusBadRequest, "wallet_amount must be more than 0 and less than the amount")
+		}
+		walletAmount = req.WalletAmount
+	}
 
 	// Build transaction model
 	t := model.Transaction{
-		Book_ID: req.BookID,
-		Amount:  amount,
+		Book_ID:        req.BookID,
+		Seller_ID:      int(book.SellerID),
+		Amount:         amount,
+		Payment_Method: method,
+		Wallet_Amount:  walletAmount,
+		Items:          items,
+	}
+	if offer != nil {
+		t.Offer_ID = &offer.ID
 	}
 
 	// Store transaction
@@ -67,28 +106,93 @@ func (h *TransactionHandler) CreateTransaction(c echo.Context) error {
 		return err
 	}
 
-	// Generate midtrans payment link
-	orderId := fmt.Sprintf("%d-%d", trans.Transaction_ID, time.Now().Unix())
-	tokenUrl := utils.MidtransPayment(orderId, int(trans.Amount), name, email)
+	// Hold the stock until the transaction can no longer be paid. Whenever
+	// it is cancelled below, book-service is told to give the stock back.
+	ttl := time.Until(trans.Expiration_Date) + 30*time.Minute
+	if err := utils.ReserveStock(uint(req.BookID), trans.Transaction_ID, req.Qty, ttl); err != nil {
+		h.serv.Transition(int(trans.Transaction_ID), model.StatusCancelled, model.ActorSystem, "stock could not be reserved")
+		if err == utils.ErrInsufficientStock {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return err
+	}
 
-	// Check if midtrans returned a valid token
-	if tokenUrl.Token == "" {
-		return echo.NewHTTPError(http.StatusBadRequest, tokenUrl.ErrorMessages)
+	// Use up the offer so its price cannot be applied twice
+	if offer != nil {
+		if err := utils.RedeemOffer(offer.ID, trans.Transaction_ID, token); err != nil {
+			h.serv.Transition(int(trans.Transaction_ID), model.StatusCancelled, model.ActorSystem, "offer could not be redeemed")
+			if err == utils.ErrOfferUnavailable {
+				return echo.NewHTTPError(http.StatusConflict, err.Error())
+			}
+			return err
+		}
 	}
 
-	// Build response
-	res := struct {
-		TokenUrl       midtrans.SnapResponse `json:"token_url"`
-		Transaction_id int                   `json:"transaction_id"`
-	}{
-		TokenUrl:       tokenUrl,
-		Transaction_id: int(trans.Transaction_ID),
+	// Take the wallet part first, so a buyer who can not cover it is never
+	// sent to pay the rest. If the transaction then ends unpaid, the wallet
+	// part is given back along with the stock.
+	if trans.Wallet_Amount > 0 {
+		if err := utils.DebitBalance(user_id, trans.Wallet_Amount, utils.WalletPaymentKey(trans.Transaction_ID)); err != nil {
+			h.serv.Transition(int(trans.Transaction_ID), model.StatusCancelled, model.ActorSystem, "wallet could not be charged")
+			if err == utils.ErrInsufficientBalance {
+				return echo.NewHTTPError(http.StatusPaymentRequired, err.Error())
+			}
+			return err
+		}
 	}
 
-	resp := helper.RespHelper("Transaction created successfully", res)
+	// Paid in full from the wallet, so there is nothing left to charge
+	if trans.Payment_Method == model.PayByWallet {
+		trans, err = h.serv.Transition(int(trans.Transaction_ID), model.StatusPaid, model.ActorWallet, "paid from wallet")
+		if err != nil {
+			return err
+		}
+		resp := helper.RespHelper("Transaction created successfully", createTransactionResponse{
+			Transaction_id: int(trans.Transaction_ID),
+			Payment_Method: trans.Payment_Method,
+			Status:         trans.Status,
+		})
+		return c.JSON(http.StatusCreated, resp)
+	}
+
+	// Remember the order ID before charging it, so every notification and
+	// reconciliation of the charge can be matched to the transaction
+	orderId := fmt.Sprintf("%d-%d", trans.Transaction_ID, time.Now().Unix())
+	if err := h.serv.SetOrderID(int(trans.Transaction_ID), orderId); err != nil {
+		h.serv.Transition(int(trans.Transaction_ID), model.StatusCancelled, model.ActorSystem, "order ID could not be saved")
+		return err
+	}
+
+	// Charge what the wallet does not cover through the payment provider
+	tokenUrl, err := h.payment.CreateCharge(service.PaymentCharge{
+		Order_ID: orderId,
+		Amount:   trans.GatewayAmount(),
+		Name:     name,
+		Email:    email,
+	})
+	if err != nil {
+		h.serv.Transition(int(trans.Transaction_ID), model.StatusCancelled, model.ActorSystem, "payment could not be created")
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp := helper.RespHelper("Transaction created successfully", createTransactionResponse{
+		TokenUrl:       &tokenUrl,
+		Transaction_id: int(trans.Transaction_ID),
+		Payment_Method: trans.Payment_Method,
+		Status:         trans.Status,
+	})
 	return c.JSON(http.StatusCreated, resp)
 }
 
+// createTransactionResponse is what CreateTransaction answers with. A
+// transaction paid from the wallet is paid already and has no token_url.
+type createTransactionResponse struct {
+	TokenUrl       *service.PaymentLink    `json:"token_url,omitempty"`
+	Transaction_id int                     `json:"transaction_id"`
+	Payment_Method model.PaymentMethod     `json:"payment_method"`
+	Status         model.TransactionStatus `json:"status"`
+}
+
 func (h *TransactionHandler) GetTransaction(c echo.Context) error {
 	user_id := c.Get("user_id").(int)
 	transactions, err := h.serv.GetTransaction(user_id)
@@ -100,93 +204,331 @@ func (h *TransactionHandler) GetTransaction(c echo.Context) error {
 	return c.JSON(http.StatusOK, resp)
 }
 
-func (h *TransactionHandler) UpdateTransactionStatus(c echo.Context) error {
-	transaction_id := c.Param("trans_id")
+// GetTransactionByID returns one of the caller's transactions. Other
+// services use it to check that a user really made a purchase.
+func (h *TransactionHandler) GetTransactionByID(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
 
-	var qty dto.UpdateTransactionStatusRequest
-	if err := c.Bind(&qty); err != nil {
+	trans_id, err := strconv.Atoi(c.Param("trans_id"))
+	if err != nil {
 		return utils.ErrBadReq
 	}
 
-	token := c.Request().Header.Get("Authorization")
+	trans, err := h.serv.GetTransactionByID(trans_id)
+	if err != nil {
+		return err
+	}
+	if trans.User_ID != user_id {
+		return utils.ErrUserForbidden
+	}
 
-	trans_id, err := strconv.Atoi(transaction_id)
+	resp := helper.RespHelper("Transaction retrieved successfully", trans)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// UpdateTransactionStatus moves a paid transaction along shipping: its
+// seller marks it shipped, then its buyer confirms it delivered and
+// completed. Payment follows from Midtrans, and cancelling and refunding
+// have endpoints of their own.
+func (h *TransactionHandler) UpdateTransactionStatus(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	trans_id, err := strconv.Atoi(c.Param("trans_id"))
 	if err != nil {
 		return utils.ErrBadReq
 	}
-	trans, err := h.serv.UpdateTransactionStatus(trans_id)
-	if err != nil {
-		return err
+
+	var req dto.UpdateTransactionStatusRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrBadReq
 	}
 
-	err = utils.UpdateBalance(trans.User_ID, trans.Amount)
+	trans, err := h.serv.GetTransactionByID(trans_id)
 	if err != nil {
 		return err
 	}
-	err = utils.EmailTransaction(trans)
+	seller_id, err := utils.SellerOf(trans)
 	if err != nil {
 		return err
 	}
 
-	err = utils.UpdateStock(trans, qty.Qty, token)
+	var actor model.Actor
+	switch model.TransactionStatus(req.Status) {
+	case model.StatusShipped:
+		actor = model.Seller(user_id)
+		if seller_id != user_id {
+			return utils.ErrUserForbidden
+		}
+	case model.StatusDelivered, model.StatusCompleted:
+		actor = model.Buyer(user_id)
+		if trans.User_ID != user_id {
+			return utils.ErrUserForbidden
+		}
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "status can only be shipped, delivered or completed")
+	}
+
+	trans, err = h.serv.Transition(trans_id, model.TransactionStatus(req.Status), actor, req.Reason)
+	if err != nil {
+		return err
+	}
 
 	resp := helper.RespHelper("Transaction status updated successfully", trans)
 	return c.JSON(http.StatusOK, resp)
 }
-func (h *TransactionHandler) HandleWebhook(c echo.Context) error {
-	// Bind request body to get transaction ID
-	var req dto.WebhookRequest
+
+// CancelTransaction lets a buyer cancel a transaction they have not paid.
+// Its charge is cancelled at the payment provider first, so it can no
+// longer be paid. A transaction from a cart checkout shares its payment
+// with the rest of the checkout, so they are all cancelled together.
+func (h *TransactionHandler) CancelTransaction(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	trans_id, err := strconv.Atoi(c.Param("trans_id"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+
+	var req dto.CancelTransactionRequest
 	if err := c.Bind(&req); err != nil {
 		return utils.ErrBadReq
 	}
+	reason := req.Reason
+	if reason == "" {
+		reason = "cancelled by buyer"
+	}
 
-	// Get transaction by ID using service
-	transactions, err := h.serv.GetTransactionByID(int(req.TransactionID))
+	trans, err := h.serv.GetTransactionByID(trans_id)
 	if err != nil {
-		return utils.ErrBadReq
+		return err
+	}
+	if trans.User_ID != user_id {
+		return utils.ErrUserForbidden
+	}
+	if trans.Status != model.StatusPending {
+		return utils.ErrInvalidTransition
 	}
 
-	if transactions.Status == "success" {
-		return utils.ErrBadReq
+	toCancel := []model.Transaction{trans}
+	order_id := trans.Order_ID
+	var checkout model.Checkout
+	if trans.Checkout_ID != nil {
+		checkout, err = h.serv.GetCheckoutByID(int(*trans.Checkout_ID))
+		if err != nil {
+			return err
+		}
+		toCancel = checkout.Transactions
+		order_id = checkout.Order_ID
 	}
 
-	// get book data using book ID
-	book, err := utils.GetBookByID(uint(transactions.Book_ID), c.Request().Header.Get("Authorization"))
-	if err != nil || book.ID == 0 {
-		return utils.ErrBadReq
+	if order_id != "" {
+		if err := h.payment.Cancel(order_id); err != nil {
+			return err
+		}
 	}
 
-	// change status to success
-	updatedTransaction, err := h.serv.UpdateTransactionStatus(int(req.TransactionID))
+	for _, t := range toCancel {
+		_, err := h.serv.Transition(int(t.Transaction_ID), model.StatusCancelled, model.Buyer(user_id), reason)
+		if err != nil && (err != utils.ErrInvalidTransition || t.Transaction_ID == trans.Transaction_ID) {
+			return err
+		}
+	}
+	if trans.Checkout_ID != nil {
+		if _, err := h.serv.TransitionCheckoutStatus(int(checkout.Checkout_ID), model.StatusPending, model.StatusCancelled); err != nil {
+			return err
+		}
+	}
+
+	trans, err = h.serv.GetTransactionByID(trans_id)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Transaction cancelled successfully", trans)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RefundTransaction refunds a paid transaction for its buyer, its seller or
+// an admin; once it has shipped only the seller or an admin may. The money
+// goes to the buyer's wallet or back through Midtrans, and the
+// transaction's refund saga restores the stock, reverses the seller's
+// credit and emails the buyer.
+func (h *TransactionHandler) RefundTransaction(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+	role, _ := c.Get("role").(string)
+
+	trans_id, err := strconv.Atoi(c.Param("trans_id"))
 	if err != nil {
 		return utils.ErrBadReq
 	}
 
-	// Update stock in book-service
-	rawToken := c.Request().Header.Get("Authorization") // "Bearer ey..."
-	token := strings.TrimPrefix(rawToken, "Bearer ")    // ✅ only the JWT
-	if err := utils.UpdateStock(transactions, req.Qty, token); err != nil {
+	var req dto.RefundTransactionRequest
+	if err := c.Bind(&req); err != nil {
 		return utils.ErrBadReq
 	}
+	method := model.RefundMethod(req.Method)
+	if method == "" {
+		method = model.RefundToWallet
+	}
+	if !method.Valid() {
+		return echo.NewHTTPError(http.StatusBadRequest, "method can only be wallet or original")
+	}
 
-	// Update seller balance
+	trans, err := h.serv.GetTransactionByID(trans_id)
+	if err != nil {
+		return err
+	}
 
-	if err := utils.UpdateBalance(int(book.SellerID), transactions.Amount); err != nil {
+	var actor model.Actor
+	switch {
+	case role == model.RoleAdmin:
+		actor = model.Admin(user_id)
+	case trans.User_ID == user_id:
+		actor = model.Buyer(user_id)
+	default:
+		seller_id, err := utils.SellerOf(trans)
+		if err != nil {
+			return err
+		}
+		if seller_id != user_id {
+			return utils.ErrUserForbidden
+		}
+		actor = model.Seller(user_id)
+	}
+
+	trans, err = h.serv.Refund(trans_id, actor, method, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Transaction refunded successfully", trans)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetTimeline returns a transaction's status changes, oldest first, to its
+// buyer or seller.
+func (h *TransactionHandler) GetTimeline(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	trans_id, err := strconv.Atoi(c.Param("trans_id"))
+	if err != nil {
 		return utils.ErrBadReq
 	}
 
-	if err := utils.EmailTransaction(updatedTransaction); err != nil {
+	trans, err := h.serv.GetTransactionByID(trans_id)
+	if err != nil {
+		return err
+	}
+	if trans.User_ID != user_id {
+		seller_id, err := utils.SellerOf(trans)
+		if err != nil {
+			return err
+		}
+		if seller_id != user_id {
+			return utils.ErrUserForbidden
+		}
+	}
+
+	timeline, err := h.serv.GetTimeline(trans_id)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Transaction timeline retrieved successfully", map[string]interface{}{
+		"transaction_id": trans.Transaction_ID,
+		"status":         trans.Status,
+		"timeline":       timeline,
+	})
+	return c.JSON(http.StatusOK, resp)
+}
+
+// HandleNotification processes the payment provider's HTTP notifications.
+// Anyone can reach it, so a notification is only acted on when the gateway
+// verifies it, by its signature for Midtrans, and its amount is what was
+// charged. Midtrans sends the same notification again until it gets a 2xx,
+// and may send one for a state the order already left: each change is
+// applied only from the state it starts in, so repeats are acknowledged
+// without doing anything.
+func (h *TransactionHandler) HandleNotification(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
 		return utils.ErrBadReq
 	}
+	payment, err := h.payment.VerifyNotification(body)
+	if err != nil {
+		return err
+	}
+	if err := h.serv.ApplyPayment(payment, model.ActorMidtrans); err != nil {
+		return err
+	}
 
-	// Return the transaction data for now
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"message": "Transaction completed successfully",
+		"message": "Notification processed",
 		"status":  true,
-		"data": map[string]interface{}{
-			"transaction": updatedTransaction,
-			"book":        book,
-			"email_sent":  true,
-		},
 	})
 }
+
+// SimulatePayment moves an order's charge at the fake payment provider to
+// a Midtrans status such as settlement, deny or expire, and applies it as
+// a notification would. It is only routed when the fake provider is used.
+func (h *TransactionHandler) SimulatePayment(c echo.Context) error {
+	fake, ok := h.payment.(*service.FakeGateway)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "payments are not simulated")
+	}
+
+	payment, err := fake.Simulate(c.Param("order_id"), c.Param("status"))
+	if err != nil {
+		return err
+	}
+	if err := h.serv.ApplyPayment(payment, model.ActorMidtrans); err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Payment simulated successfully", payment)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// purchaseItems snapshots qty copies of a book. Copies covered by an
+// accepted offer are one item at the offer price; any beyond the offered
+// quantity are a second item at the listing price.
+func purchaseItems(book dto.BookResponse, qty int, offer *dto.OfferResponse) []model.TransactionItem {
+	discounted := 0
+	if offer != nil {
+		discounted = qty
+		if discounted > offer.Quantity {
+			discounted = offer.Quantity
+		}
+	}
+
+	var items []model.TransactionItem
+	if discounted > 0 {
+		items = append(items, model.NewTransactionItem(int(book.ID), book.Name, int(book.SellerID), offer.Price, discounted))
+	}
+	if qty > discounted {
+		items = append(items, model.NewTransactionItem(int(book.ID), book.Name, int(book.SellerID), book.Cost, qty-discounted))
+	}
+	return items
+}
+
+// GetDailySales returns successful sales per book and day between the from
+// and to dates (YYYY-MM-DD, both inclusive). book-service uses it for
+// seller analytics.
+func (h *TransactionHandler) GetDailySales(c echo.Context) error {
+	from, err := time.Parse("2006-01-02", c.QueryParam("from"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+	to, err := time.Parse("2006-01-02", c.QueryParam("to"))
+	if err != nil || to.Before(from) {
+		return utils.ErrBadReq
+	}
+
+	sales, err := h.serv.GetDailySales(from, to)
+	if err != nil {
+		return err
+	}
+
+	resp := helper.RespHelper("Daily sales retrieved successfully", sales)
+	return c.JSON(http.StatusOK, resp)
+}
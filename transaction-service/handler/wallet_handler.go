@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"main/dto"
+	"main/helper"
+	"main/model"
+	"main/service"
+	"main/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WalletHandler struct {
+	transServ service.TransactionService
+	payment   service.PaymentGateway
+}
+
+func NewWalletHandler(transServ service.TransactionService, payment service.PaymentGateway) *WalletHandler {
+	return &WalletHandler{transServ: transServ, payment: payment}
+}
+
+// CreateTopUp starts a top-up of the caller's wallet and returns the link
+// to pay it. The balance is credited once Midtrans reports it paid.
+func (h *WalletHandler) CreateTopUp(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+	name := c.Get("name").(string)
+	email := c.Get("email").(string)
+
+	var req dto.TopUpRequest
+	if err := c.Bind(&req); err != nil {
+		return utils.ErrBadReq
+	}
+	if req.Amount <= 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "amount must be greater than 0")
+	}
+
+	topUp, err := h.transServ.CreateTopUp(user_id, req.Amount)
+	if err != nil {
+		return err
+	}
+
+	// Saved before charging so the charge can always be matched to it
+	orderId := fmt.Sprintf("topup-%d-%d", topUp.TopUp_ID, time.Now().Unix())
+	if err := h.transServ.SetTopUpOrderID(int(topUp.TopUp_ID), orderId); err != nil {
+		h.cancelTopUp(topUp)
+		return err
+	}
+	topUp.Order_ID = orderId
+
+	tokenUrl, err := h.payment.CreateCharge(service.PaymentCharge{
+		Order_ID: orderId,
+		Amount:   topUp.Amount,
+		Name:     name,
+		Email:    email,
+	})
+	if err != nil {
+		h.cancelTopUp(topUp)
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	res := struct {
+		TokenUrl service.PaymentLink `json:"token_url"`
+		TopUp    model.TopUp         `json:"top_up"`
+	}{
+		TokenUrl: tokenUrl,
+		TopUp:    topUp,
+	}
+
+	resp := helper.RespHelper("Top-up created successfully", res)
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// GetTopUp returns one of the caller's top-ups.
+func (h *WalletHandler) GetTopUp(c echo.Context) error {
+	user_id := c.Get("user_id").(int)
+
+	top_up_id, err := strconv.Atoi(c.Param("top_up_id"))
+	if err != nil {
+		return utils.ErrBadReq
+	}
+
+	topUp, err := h.transServ.GetTopUpByID(top_up_id)
+	if err != nil {
+		return err
+	}
+	if topUp.User_ID != user_id {
+		return utils.ErrTopUpNotFound
+	}
+
+	resp := helper.RespHelper("Top-up retrieved successfully", topUp)
+	return c.JSON(http.StatusOK, resp)
+}
+
+// cancelTopUp ends a top-up that could not be charged.
+func (h *WalletHandler) cancelTopUp(topUp model.TopUp) {
+	if _, err := h.transServ.TransitionTopUpStatus(int(topUp.TopUp_ID), model.StatusPending, model.StatusCancelled); err != nil {
+		log.Printf("Failed to cancel top-up %d: %v", topUp.TopUp_ID, err)
+	}
+}
@@ -3,34 +3,80 @@ package job
 import (
 	"log"
 	"main/model"
+	"main/service"
+	"main/utils"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-func UpdateStatus(db *gorm.DB) {
+func UpdateStatus(db *gorm.DB, serv service.TransactionService) {
 	expirationThreshold := time.Now().Add(-30 * time.Minute) //30 minute expiration threshold
 
-	// Step 1: Update expired pending transactions to "fail"
-	updateResult := db.Model(&model.Transaction{}).
-		Where("status = ? AND expiration_date < ?", "pending", expirationThreshold).
-		Update("status", "fail")
-
-	if updateResult.Error != nil {
-		log.Println("Failed to update expired transactions:", updateResult.Error)
+	var expired []model.Transaction
+	if err := db.Where("status = ? AND expiration_date < ?", model.StatusPending, expirationThreshold).
+		Find(&expired).Error; err != nil {
+		log.Println("Failed to load expired transactions:", err)
 		return
 	}
 
-	log.Println(updateResult.RowsAffected, "transactions marked as 'fail'")
+	// Step 1: Expire pending transactions; their reserved stock is given
+	// back through the outbox
+	var failed int
+	for _, t := range expired {
+		if _, err := serv.Transition(int(t.Transaction_ID), model.StatusExpired, model.ActorSystem, "not paid in time"); err != nil {
+			log.Println("Failed to expire transaction", t.Transaction_ID, ":", err)
+			continue
+		}
+		failed++
+	}
+
+	log.Println(failed, "transactions marked as 'expired'")
+
+	// Checkouts whose transactions have just expired have failed with them
+	if err := db.Model(&model.Checkout{}).
+		Where("status = ? AND expiration_date < ?", model.StatusPending, expirationThreshold).
+		Update("status", model.StatusExpired).Error; err != nil {
+		log.Println("Failed to update expired checkouts:", err)
+	}
 
-	// Step 2: Soft-delete the failed + expired transactions
-	deleteResult := db.Where("status = ? AND expiration_date < ?", "fail", expirationThreshold).
+	// Step 2: Soft-delete the cancelled + expired transactions
+	deleteResult := db.Where("status IN ? AND expiration_date < ?", []model.TransactionStatus{model.StatusCancelled, model.StatusExpired}, expirationThreshold).
 		Delete(&model.Transaction{})
 
 	if deleteResult.Error != nil {
-		log.Println("Failed to delete failed expired transactions:", deleteResult.Error)
+		log.Println("Failed to delete unpaid expired transactions:", deleteResult.Error)
 		return
 	}
 
-	log.Println(deleteResult.RowsAffected, "failed expired transactions deleted")
+	log.Println(deleteResult.RowsAffected, "unpaid expired transactions deleted")
+}
+
+// RunSagas runs the post-payment sagas that are due to try again.
+func RunSagas(serv service.SagaService) {
+	if n := serv.RunDue(); n > 0 {
+		log.Println(n, "sagas run")
+	}
+}
+
+// RelayOutbox delivers the outbox events that are due.
+func RelayOutbox(serv service.OutboxService) {
+	if n := serv.RelayDue(); n > 0 {
+		log.Println(n, "outbox events delivered")
+	}
+}
+
+// Reconcile checks recent payments against the payment provider.
+func Reconcile(serv service.ReconciliationService) {
+	run, err := serv.Run()
+	if err == utils.ErrReconciliationRunning {
+		return
+	}
+	if err != nil {
+		log.Println("Failed to reconcile payments:", err)
+		return
+	}
+	if len(run.Discrepancies) > 0 || run.Unchecked > 0 {
+		log.Println("Reconciliation run", run.ID, "checked", run.Checked, "orders:", len(run.Discrepancies), "discrepancies,", run.Fixed, "fixed,", run.Unchecked, "unchecked")
+	}
 }
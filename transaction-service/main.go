@@ -1,6 +1,7 @@
 package main
 
 import (
+	"log"
 	"main/config"
 	"main/handler"
 	"main/job"
@@ -19,19 +20,61 @@ func main() {
 	db := config.DBInit()
 	godotenv.Load()
 	// Run migrations
-	db.AutoMigrate(&model.Transaction{})
+	db.AutoMigrate(&model.Transaction{}, &model.TransactionItem{}, &model.TransactionStatusHistory{}, &model.Checkout{}, &model.TopUp{}, &model.CartItem{}, &model.Saga{}, &model.SagaStep{}, &model.OutboxEvent{}, &model.Refund{}, &model.ReconciliationRun{}, &model.PaymentDiscrepancy{})
+	if err := repository.MigrateLegacyStatuses(db); err != nil {
+		log.Println("Failed to migrate transaction statuses:", err)
+	}
+	if err := repository.MigrateSagaKinds(db); err != nil {
+		log.Println("Failed to migrate saga kinds:", err)
+	}
+
+	transRepo := repository.NewTransactionRepository(db)
+	sagaRepo := repository.NewSagaRepository(db)
+	transService := service.NewTransactionService(transRepo, sagaRepo)
+	paymentConfig := config.LoadPaymentConfig()
+	paymentGateway := service.NewPaymentGateway(paymentConfig)
+	sagaConfig := config.LoadSagaConfig()
+	sagaActions := map[model.SagaKind]map[string]service.SagaAction{
+		model.SagaFulfilment:  service.FulfilmentActions(),
+		model.SagaRefund:      service.RefundActions(transRepo, paymentGateway),
+		model.SagaLatePayment: service.LatePaymentActions(transRepo, paymentGateway),
+	}
+	sagaService := service.NewSagaService(sagaRepo, transRepo, sagaActions, sagaConfig)
+	outboxConfig := config.LoadOutboxConfig()
+	outboxService := service.NewOutboxService(repository.NewOutboxRepository(db), service.OutboxConsumers(transRepo, sagaService), outboxConfig)
+
+	reconciliationConfig := config.LoadReconciliationConfig()
+	reconciliationService := service.NewReconciliationService(repository.NewReconciliationRepository(db), transService, paymentGateway, reconciliationConfig)
 
 	c := cron.New()
 
+	// Payments whose notification was lost are caught up on before the
+	// transactions waiting for them are expired
 	c.AddFunc("0 0 * * *", func() {
-		job.UpdateStatus(db)
+		job.Reconcile(reconciliationService)
+		job.UpdateStatus(db, transService)
+	})
+
+	c.AddFunc("@every "+reconciliationConfig.Interval.String(), func() {
+		job.Reconcile(reconciliationService)
+	})
+
+	c.AddFunc("@every "+sagaConfig.PollInterval.String(), func() {
+		job.RunSagas(sagaService)
+	})
+
+	c.AddFunc("@every "+outboxConfig.PollInterval.String(), func() {
+		job.RelayOutbox(outboxService)
 	})
 
 	c.Start()
 
-	transRepo := repository.NewTransactionRepository(db)
-	transService := service.NewTransactionService(transRepo)
-	transHandler := handler.NewTransactionHandler(transService)
+	transHandler := handler.NewTransactionHandler(transService, paymentGateway)
+	sagaHandler := handler.NewSagaHandler(sagaService)
+	reconciliationHandler := handler.NewReconciliationHandler(reconciliationService)
+	cartService := service.NewCartService(repository.NewCartRepository(db))
+	cartHandler := handler.NewCartHandler(cartService, transService, paymentGateway)
+	walletHandler := handler.NewWalletHandler(transService, paymentGateway)
 
 	e := echo.New()
 	e.HTTPErrorHandler = handler.ErrorHandler
@@ -39,8 +82,49 @@ func main() {
 	transGroup.Use(middleware.AuthMiddleware)
 	transGroup.POST("", transHandler.CreateTransaction)
 	transGroup.GET("", transHandler.GetTransaction)
+	transGroup.GET("/:trans_id", transHandler.GetTransactionByID)
 	transGroup.PUT("/:trans_id", transHandler.UpdateTransactionStatus)
-	transGroup.POST("/webhook", transHandler.HandleWebhook)
+	transGroup.GET("/:trans_id/timeline", transHandler.GetTimeline)
+	transGroup.POST("/:trans_id/cancel", transHandler.CancelTransaction)
+	transGroup.POST("/:trans_id/refund", transHandler.RefundTransaction)
+
+	// Midtrans notifications carry no JWT; they are verified by signature
+	e.POST("/payments/midtrans/notification", transHandler.HandleNotification)
+	if paymentConfig.Provider == config.PaymentFake {
+		log.Println("Payments go through the fake provider")
+		e.POST("/payments/fake/:order_id/:status", transHandler.SimulatePayment)
+	}
+
+	cartGroup := e.Group("/cart")
+	cartGroup.Use(middleware.AuthMiddleware)
+	cartGroup.GET("", cartHandler.GetCart)
+	cartGroup.DELETE("", cartHandler.ClearCart)
+	cartGroup.POST("/items", cartHandler.AddCartItem)
+	cartGroup.PUT("/items/:book_id", cartHandler.UpdateCartItem)
+	cartGroup.DELETE("/items/:book_id", cartHandler.RemoveCartItem)
+	cartGroup.POST("/checkout", cartHandler.Checkout)
+
+	checkoutGroup := e.Group("/checkouts")
+	checkoutGroup.Use(middleware.AuthMiddleware)
+	checkoutGroup.GET("/:checkout_id", cartHandler.GetCheckout)
+
+	walletGroup := e.Group("/wallet")
+	walletGroup.Use(middleware.AuthMiddleware)
+	walletGroup.POST("/topups", walletHandler.CreateTopUp)
+	walletGroup.GET("/topups/:top_up_id", walletHandler.GetTopUp)
+
+	adminGroup := e.Group("/admin")
+	adminGroup.Use(middleware.AuthMiddleware, middleware.AdminMiddleware)
+	adminGroup.GET("/sagas/stuck", sagaHandler.GetStuckSagas)
+	adminGroup.POST("/sagas/:saga_id/retry", sagaHandler.RetrySaga)
+	adminGroup.POST("/sagas/:saga_id/resolve", sagaHandler.ResolveSaga)
+	adminGroup.GET("/reconciliation/runs", reconciliationHandler.GetRuns)
+	adminGroup.POST("/reconciliation/runs", reconciliationHandler.RunReconciliation)
+	adminGroup.GET("/reconciliation/runs/:run_id", reconciliationHandler.GetRun)
+
+	internal := e.Group("/internal")
+	internal.Use(middleware.ServiceAuthMiddleware)
+	internal.GET("/sales/daily", transHandler.GetDailySales)
 
 	port := os.Getenv("PORT")
 	if port == "" {
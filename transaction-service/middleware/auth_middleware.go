@@ -41,6 +41,18 @@ func AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 		c.Set("user_id", int(claims["user_id"].(float64)))
 		c.Set("name", claims["full_name"].(string))
 		c.Set("email", claims["email"].(string))
+		role, _ := claims["role"].(string)
+		c.Set("role", role)
+		return next(c)
+	}
+}
+
+// AdminMiddleware lets only admins through. It runs after AuthMiddleware.
+func AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if role, _ := c.Get("role").(string); role != "admin" {
+			return echo.NewHTTPError(http.StatusForbidden, "admin only")
+		}
 		return next(c)
 	}
 }
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// ServiceAuthMiddleware guards the internal endpoints other services call.
+// It accepts only service tokens signed with INTERNAL_SERVICE_SECRET for
+// the transaction-service audience, never user tokens.
+func ServiceAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		secret := os.Getenv("INTERNAL_SERVICE_SECRET")
+		if secret == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid, service token required")
+		}
+
+		tokenString := strings.TrimPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+		claims := jwt.MapClaims{}
+		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		},
+			jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+			jwt.WithAudience("transaction-service"),
+			jwt.WithExpirationRequired(),
+		)
+		if err != nil || !token.Valid {
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid, service token required")
+		}
+
+		service, _ := claims["service"].(string)
+		if service == "" {
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid, service token required")
+		}
+
+		c.Set("service", service)
+		return next(c)
+	}
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// CartItem is a book waiting in a buyer's cart. Unit_Price is the price the
+// buyer last saw, so checkout can tell them when it has changed.
+type CartItem struct {
+	ID         uint      `gorm:"primaryKey;autoincrement" json:"id"`
+	User_ID    int       `gorm:"not null;uniqueIndex:idx_cart_user_book" json:"user_id"`
+	Book_ID    int       `gorm:"not null;uniqueIndex:idx_cart_user_book" json:"book_id"`
+	Qty        int       `gorm:"not null" json:"qty"`
+	Unit_Price float64   `gorm:"not null" json:"unit_price"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
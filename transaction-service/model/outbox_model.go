@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Event types written to the outbox.
+const (
+	EventTransactionStatusChanged = "transaction.status_changed"
+)
+
+// Consumers the outbox delivers events to. LocalConsumer is
+// transaction-service itself; the others are reached over HTTP.
+const (
+	LocalConsumer = "transaction-service"
+	BookConsumer  = "book-service"
+)
+
+// OutboxEvent is an event waiting to be delivered to one consumer. It is
+// written in the same database transaction as the change it describes, so
+// the change and the event are kept or lost together. The relay delivers
+// it at least once; Event_ID is the same for every consumer of an event
+// and is what consumers recognise repeats by.
+type OutboxEvent struct {
+	ID              uint       `gorm:"primaryKey;autoincrement" json:"id"`
+	Event_ID        string     `gorm:"not null;uniqueIndex:idx_outbox_event_consumer" json:"event_id"`
+	Consumer        string     `gorm:"not null;uniqueIndex:idx_outbox_event_consumer" json:"consumer"`
+	Type            string     `gorm:"not null" json:"type"`
+	Payload         string     `gorm:"type:text;not null" json:"payload"`
+	Attempts        int        `json:"attempts"`
+	Next_Attempt_At *time.Time `gorm:"index" json:"next_attempt_at,omitempty"`
+	Last_Error      string     `json:"last_error,omitempty"`
+	Delivered_At    *time.Time `gorm:"index" json:"delivered_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// TransactionStatusChanged is the payload of a
+// transaction.status_changed event.
+type TransactionStatusChanged struct {
+	Transaction_ID uint              `json:"transaction_id"`
+	User_ID        int               `json:"user_id"`
+	Seller_ID      int               `json:"seller_id"`
+	Amount         float64           `json:"amount"`
+	Wallet_Amount  float64           `json:"wallet_amount,omitempty"`
+	From_Status    TransactionStatus `json:"from_status,omitempty"`
+	To_Status      TransactionStatus `json:"to_status"`
+	Actor          string            `json:"actor"`
+	Reason         string            `json:"reason,omitempty"`
+	Occurred_At    time.Time         `json:"occurred_at"`
+}
+
+// statusConsumers lists who is told about a transaction reaching each
+// status: a paid or refunded transaction starts its saga here, and one that
+// will never be paid has book-service give back the stock held for it and
+// gets back what was paid from the buyer's wallet here.
+var statusConsumers = map[TransactionStatus][]string{
+	StatusPaid:      {LocalConsumer},
+	StatusRefunded:  {LocalConsumer},
+	StatusCancelled: {BookConsumer, LocalConsumer},
+	StatusExpired:   {BookConsumer, LocalConsumer},
+}
+
+// StatusChangedEvents returns the outbox events for a transaction moving as
+// the history entry says, one per consumer that cares. A transaction never
+// reaches the same status twice, so the transaction and its new status are
+// enough to identify the event.
+func StatusChangedEvents(trans Transaction, entry TransactionStatusHistory) ([]OutboxEvent, error) {
+	consumers := statusConsumers[entry.To_Status]
+	if len(consumers) == 0 {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(TransactionStatusChanged{
+		Transaction_ID: trans.Transaction_ID,
+		User_ID:        trans.User_ID,
+		Seller_ID:      trans.Seller_ID,
+		Amount:         trans.Amount,
+		Wallet_Amount:  trans.Wallet_Amount,
+		From_Status:    entry.From_Status,
+		To_Status:      entry.To_Status,
+		Actor:          entry.Actor,
+		Reason:         entry.Reason,
+		Occurred_At:    entry.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	event_id := fmt.Sprintf("transaction-%d-%s", trans.Transaction_ID, entry.To_Status)
+	events := make([]OutboxEvent, 0, len(consumers))
+	for _, consumer := range consumers {
+		events = append(events, OutboxEvent{
+			Event_ID:        event_id,
+			Consumer:        consumer,
+			Type:            EventTransactionStatusChanged,
+			Payload:         string(payload),
+			Next_Attempt_At: &entry.CreatedAt,
+		})
+	}
+	return events, nil
+}
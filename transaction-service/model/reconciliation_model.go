@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+// DiscrepancyKind is how a payment disagreed with the provider.
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyStatus is a payment whose status differs from the
+	// provider's, such as a paid order whose notification was lost.
+	DiscrepancyStatus DiscrepancyKind = "status_mismatch"
+	// DiscrepancyAmount is a payment the provider took a different amount
+	// for than was charged.
+	DiscrepancyAmount DiscrepancyKind = "amount_mismatch"
+	// DiscrepancyMissing is a paid order the provider does not know.
+	DiscrepancyMissing DiscrepancyKind = "missing_at_provider"
+)
+
+// How a discrepancy was dealt with. Fixed ones were moved to the
+// provider's status through the normal transitions; the others need
+// someone in finance to look at them.
+const (
+	ResolutionFixed     = "fixed"
+	ResolutionReview    = "needs_review"
+	ResolutionFixFailed = "fix_failed"
+)
+
+// OpenResolutions are those of discrepancies still left for finance.
+var OpenResolutions = []string{ResolutionReview, ResolutionFixFailed}
+
+// ReconciliationRun is one pass checking recent payments against the
+// payment provider, with every discrepancy it found.
+type ReconciliationRun struct {
+	ID            uint                 `gorm:"primaryKey;autoincrement" json:"id"`
+	Started_At    time.Time            `gorm:"not null;index" json:"started_at"`
+	Finished_At   time.Time            `json:"finished_at"`
+	Checked       int                  `json:"checked"`
+	Fixed         int                  `json:"fixed"`
+	Unchecked     int                  `json:"unchecked"`
+	Discrepancies []PaymentDiscrepancy `gorm:"foreignKey:Run_ID" json:"discrepancies,omitempty"`
+}
+
+// PaymentDiscrepancy is an order whose payment did not match the provider
+// when a run checked it. Transaction_ID, Checkout_ID or TopUp_ID says
+// what was charged under Order_ID.
+type PaymentDiscrepancy struct {
+	ID              uint              `gorm:"primaryKey;autoincrement" json:"id"`
+	Run_ID          uint              `gorm:"not null;index" json:"run_id"`
+	Order_ID        string            `gorm:"not null;index" json:"order_id"`
+	Transaction_ID  *uint             `json:"transaction_id,omitempty"`
+	Checkout_ID     *uint             `json:"checkout_id,omitempty"`
+	TopUp_ID        *uint             `json:"top_up_id,omitempty"`
+	Kind            DiscrepancyKind   `gorm:"not null" json:"kind"`
+	Local_Status    TransactionStatus `gorm:"not null" json:"local_status"`
+	Provider_Status string            `json:"provider_status"`
+	Expected_Amount float64           `json:"expected_amount"`
+	Provider_Amount float64           `json:"provider_amount"`
+	Resolution      string            `gorm:"not null" json:"resolution"`
+	Note            string            `json:"note,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// RefundMethod is where a refund's money goes.
+type RefundMethod string
+
+const (
+	// RefundToWallet credits the buyer's balance in auth-service.
+	RefundToWallet RefundMethod = "wallet"
+	// RefundToOriginal returns the payment through the payment provider.
+	RefundToOriginal RefundMethod = "original"
+)
+
+func (m RefundMethod) Valid() bool {
+	return m == RefundToWallet || m == RefundToOriginal
+}
+
+// Refund records a transaction being refunded. It is written with the move
+// to refunded, and the transaction's refund saga carries it out. What the
+// saga has to undo is decided when the refund is made, from what the
+// transaction's fulfilment got done: Restore_Stock if its stock was
+// deducted and Reverse_Credit if its seller was credited. At_Provider is
+// set when the payment provider refunded the payment itself, so there is
+// no money left to send back.
+type Refund struct {
+	ID             uint         `gorm:"primaryKey;autoincrement" json:"id"`
+	Transaction_ID uint         `gorm:"not null;uniqueIndex" json:"transaction_id"`
+	Method         RefundMethod `gorm:"not null" json:"method"`
+	Amount         float64      `gorm:"not null" json:"amount"`
+	Reason         string       `json:"reason,omitempty"`
+	Requested_By   string       `gorm:"not null" json:"requested_by"`
+	Requester_ID   int          `json:"requester_id,omitempty"`
+	Restore_Stock  bool         `json:"restore_stock"`
+	Reverse_Credit bool         `json:"reverse_credit"`
+	At_Provider    bool         `json:"at_provider"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
@@ -0,0 +1,123 @@
+package model
+
+import "time"
+
+// SagaStatus is where a saga is. A running saga works through its steps;
+// when one of them can not be done it compensates the steps already done
+// in reverse order. A saga that can neither finish nor compensate fails
+// and waits for someone to resolve it.
+type SagaStatus string
+
+const (
+	SagaRunning      SagaStatus = "running"
+	SagaCompleted    SagaStatus = "completed"
+	SagaCompensating SagaStatus = "compensating"
+	SagaCompensated  SagaStatus = "compensated"
+	SagaFailed       SagaStatus = "failed"
+)
+
+// SagaKind is what a saga carries out. A transaction has at most one saga
+// of each kind.
+type SagaKind string
+
+const (
+	SagaFulfilment  SagaKind = "fulfilment"
+	SagaRefund      SagaKind = "refund"
+	SagaLatePayment SagaKind = "late_payment"
+)
+
+type SagaStepStatus string
+
+const (
+	StepPending     SagaStepStatus = "pending"
+	StepDone        SagaStepStatus = "done"
+	StepFailed      SagaStepStatus = "failed"
+	StepCompensated SagaStepStatus = "compensated"
+)
+
+// Steps of the saga run once a transaction is paid, in this order.
+const (
+	StepConfirmStock = "confirm_stock"
+	StepCreditSeller = "credit_seller"
+	StepSendReceipt  = "send_receipt"
+)
+
+// Steps of the saga run once a transaction is refunded, in this order.
+const (
+	StepRefundPayment     = "refund_payment"
+	StepRestoreStock      = "restore_stock"
+	StepReverseCredit     = "reverse_credit"
+	StepSendRefundReceipt = "send_refund_receipt"
+)
+
+// Steps of the saga run when a transaction is paid after it was cancelled
+// or expired.
+const (
+	StepReturnLatePayment = "return_late_payment"
+)
+
+// SagaSteps are the steps of each kind of saga, in the order they run.
+var SagaSteps = map[SagaKind][]string{
+	SagaFulfilment:  {StepConfirmStock, StepCreditSeller, StepSendReceipt},
+	SagaRefund:      {StepRefundPayment, StepRestoreStock, StepReverseCredit, StepSendRefundReceipt},
+	SagaLatePayment: {StepReturnLatePayment},
+}
+
+// NewSaga creates a running saga of the given kind for a transaction, with
+// all its steps pending, due to run at now.
+func NewSaga(transaction_id uint, kind SagaKind, now time.Time) Saga {
+	saga := Saga{
+		Transaction_ID:  transaction_id,
+		Kind:            kind,
+		Status:          SagaRunning,
+		Next_Attempt_At: &now,
+	}
+	for i, name := range SagaSteps[kind] {
+		saga.Steps = append(saga.Steps, SagaStep{Name: name, Position: i, Status: StepPending})
+	}
+	return saga
+}
+
+// HasDone reports whether the saga's step of that name is done and has not
+// been compensated.
+func (s Saga) HasDone(name string) bool {
+	for _, step := range s.Steps {
+		if step.Name == name {
+			return step.Status == StepDone
+		}
+	}
+	return false
+}
+
+// Saga carries out what has to happen in other services once a transaction
+// is paid or refunded, keeping each step's progress so it survives failures
+// and restarts. Next_Attempt_At is when it is next due to run; while it runs
+// it is pushed ahead so no one else picks the saga up.
+type Saga struct {
+	ID              uint       `gorm:"primaryKey;autoincrement" json:"id"`
+	Transaction_ID  uint       `gorm:"not null;uniqueIndex:idx_saga_transaction_kind" json:"transaction_id"`
+	Kind            SagaKind   `gorm:"not null;default:fulfilment;uniqueIndex:idx_saga_transaction_kind" json:"kind"`
+	Status          SagaStatus `gorm:"not null;index" json:"status"`
+	Next_Attempt_At *time.Time `gorm:"index" json:"next_attempt_at,omitempty"`
+	Last_Error      string     `json:"last_error,omitempty"`
+	Resolution      string     `json:"resolution,omitempty"`
+	Resolved_By     int        `json:"resolved_by,omitempty"`
+	Resolved_At     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Steps           []SagaStep `gorm:"foreignKey:Saga_ID" json:"steps"`
+}
+
+// SagaStep is one step of a saga. Attempts counts the tries of whatever
+// the step is doing now: the step itself while the saga runs, and undoing
+// it while the saga compensates.
+type SagaStep struct {
+	ID         uint           `gorm:"primaryKey;autoincrement" json:"id"`
+	Saga_ID    uint           `gorm:"not null;index" json:"saga_id"`
+	Name       string         `gorm:"not null" json:"name"`
+	Position   int            `gorm:"not null" json:"position"`
+	Status     SagaStepStatus `gorm:"not null" json:"status"`
+	Attempts   int            `json:"attempts"`
+	Last_Error string         `json:"last_error,omitempty"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
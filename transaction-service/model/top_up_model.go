@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// TopUp is money a user adds to their wallet, paid through the payment
+// provider. It stays pending until the provider reports it paid, and is
+// only marked paid once the user's balance has been credited.
+type TopUp struct {
+	TopUp_ID  uint              `gorm:"primaryKey;autoincrement" json:"top_up_id"`
+	User_ID   int               `gorm:"not null;index" json:"user_id"`
+	Amount    float64           `gorm:"not null" json:"amount"`
+	Status    TransactionStatus `gorm:"not null;index" json:"status"`
+	Order_ID  string            `json:"order_id,omitempty"`
+	CreatedAt time.Time         `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
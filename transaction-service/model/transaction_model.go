@@ -6,15 +6,94 @@ import (
 	"gorm.io/gorm"
 )
 
+// PaymentMethod is how a transaction is paid for.
+type PaymentMethod string
+
+const (
+	// PayByMidtrans charges the whole amount through Midtrans.
+	PayByMidtrans PaymentMethod = "midtrans"
+	// PayByWallet takes the whole amount off the buyer's balance.
+	PayByWallet PaymentMethod = "wallet"
+	// PayBySplit takes Wallet_Amount off the buyer's balance and charges
+	// the rest through Midtrans.
+	PayBySplit PaymentMethod = "split"
+)
+
+func (m PaymentMethod) Valid() bool {
+	switch m {
+	case PayByMidtrans, PayByWallet, PayBySplit:
+		return true
+	}
+	return false
+}
+
+// Transaction is an order from one seller. Orders placed through a cart
+// checkout share a Checkout_ID and are paid together; Book_ID is only set
+// when the order holds a single book, and Items lists every book in it.
+// Wallet_Amount is the part of Amount paid from the buyer's balance; the
+// rest is charged through Midtrans.
 type Transaction struct {
-	Transaction_ID  uint           `gorm:"primaryKey;autoincrement" json:"transaction_id"`
-	Amount          float64        `gorm:"not null" json:"amount"`
-	CreatedAt       time.Time      `gorm:"not null" json:"created_at"`
-	User_ID         int            `gorm:"not null" json:"user_id"`
-	Status          string         `gorm:"not null" json:"status"`
-	Book_ID         int            `gorm:"not null" json:"book_id"`
-	Expiration_Date time.Time      `gorm:"not null" json:"expiration_date"`
-	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+	Transaction_ID  uint              `gorm:"primaryKey;autoincrement" json:"transaction_id"`
+	Amount          float64           `gorm:"not null" json:"amount"`
+	CreatedAt       time.Time         `gorm:"not null" json:"created_at"`
+	User_ID         int               `gorm:"not null" json:"user_id"`
+	Seller_ID       int               `json:"seller_id"`
+	Status          TransactionStatus `gorm:"not null" json:"status"`
+	Payment_Method  PaymentMethod     `gorm:"not null;default:midtrans" json:"payment_method"`
+	Wallet_Amount   float64           `gorm:"not null;default:0" json:"wallet_amount"`
+	Book_ID         int               `gorm:"not null" json:"book_id"`
+	Offer_ID        *uint             `json:"offer_id,omitempty"`
+	Checkout_ID     *uint             `gorm:"index" json:"checkout_id,omitempty"`
+	Order_ID        string            `json:"order_id,omitempty"`
+	Expiration_Date time.Time         `gorm:"not null" json:"expiration_date"`
+	Items           []TransactionItem `gorm:"foreignKey:Transaction_ID;references:Transaction_ID" json:"items,omitempty"`
+	Refund          *Refund           `gorm:"foreignKey:Transaction_ID;references:Transaction_ID" json:"refund,omitempty"`
+	DeletedAt       gorm.DeletedAt    `json:"-" gorm:"index"`
+}
+
+// GatewayAmount is the part of the transaction charged through Midtrans.
+func (t Transaction) GatewayAmount() float64 {
+	return t.Amount - t.Wallet_Amount
+}
+
+// TransactionItem is one book in an order, as it was when the order was
+// placed. The title, seller and price are copied from book-service, so
+// receipts and history stay right after the listing is edited or deleted,
+// and the quantity is what stock is deducted by.
+type TransactionItem struct {
+	ID             uint    `gorm:"primaryKey;autoincrement" json:"id"`
+	Transaction_ID uint    `gorm:"not null;index" json:"transaction_id"`
+	Book_ID        int     `gorm:"not null" json:"book_id"`
+	Title          string  `gorm:"not null" json:"title"`
+	Seller_ID      int     `gorm:"not null" json:"seller_id"`
+	Unit_Price     float64 `gorm:"not null" json:"unit_price"`
+	Qty            int     `gorm:"not null" json:"qty"`
+	Subtotal       float64 `gorm:"not null" json:"subtotal"`
+}
+
+// NewTransactionItem snapshots qty copies of a book at unitPrice.
+func NewTransactionItem(book_id int, title string, seller_id int, unitPrice float64, qty int) TransactionItem {
+	return TransactionItem{
+		Book_ID:    book_id,
+		Title:      title,
+		Seller_ID:  seller_id,
+		Unit_Price: unitPrice,
+		Qty:        qty,
+		Subtotal:   unitPrice * float64(qty),
+	}
+}
+
+// Checkout is a single payment covering a buyer's whole cart. It is split
+// into one transaction per seller.
+type Checkout struct {
+	Checkout_ID     uint              `gorm:"primaryKey;autoincrement" json:"checkout_id"`
+	User_ID         int               `gorm:"not null;index" json:"user_id"`
+	Amount          float64           `gorm:"not null" json:"amount"`
+	Status          TransactionStatus `gorm:"not null" json:"status"`
+	Order_ID        string            `json:"order_id"`
+	CreatedAt       time.Time         `gorm:"not null" json:"created_at"`
+	Expiration_Date time.Time         `gorm:"not null" json:"expiration_date"`
+	Transactions    []Transaction     `gorm:"foreignKey:Checkout_ID;references:Checkout_ID" json:"transactions,omitempty"`
 }
 
 type User struct {
@@ -28,3 +107,11 @@ type User struct {
 	CreatedAt  time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
 	IsVerified bool      `gorm:"default:false"`
 }
+
+// DailySales sums a book's paid transactions on one day (UTC).
+type DailySales struct {
+	BookID  int       `json:"book_id"`
+	Day     time.Time `json:"day"`
+	Sales   int64     `json:"sales"`
+	Revenue float64   `json:"revenue"`
+}
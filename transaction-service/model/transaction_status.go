@@ -0,0 +1,151 @@
+package model
+
+import "time"
+
+// TransactionStatus is where a transaction is in its life. A transaction
+// starts pending and, once paid, moves through shipping to completed. It
+// can end early as cancelled or expired while unpaid, or refunded after
+// payment.
+type TransactionStatus string
+
+const (
+	StatusPending   TransactionStatus = "pending"
+	StatusPaid      TransactionStatus = "paid"
+	StatusShipped   TransactionStatus = "shipped"
+	StatusDelivered TransactionStatus = "delivered"
+	StatusCompleted TransactionStatus = "completed"
+	StatusCancelled TransactionStatus = "cancelled"
+	StatusExpired   TransactionStatus = "expired"
+	StatusRefunded  TransactionStatus = "refunded"
+)
+
+// statusTransitions lists the statuses each status can move to. Statuses
+// missing from it are final.
+var statusTransitions = map[TransactionStatus][]TransactionStatus{
+	StatusPending:   {StatusPaid, StatusCancelled, StatusExpired},
+	StatusPaid:      {StatusShipped, StatusRefunded},
+	StatusShipped:   {StatusDelivered, StatusRefunded},
+	StatusDelivered: {StatusCompleted, StatusRefunded},
+}
+
+// CanTransitionTo reports whether a transaction in status s may move to
+// status to.
+func (s TransactionStatus) CanTransitionTo(to TransactionStatus) bool {
+	for _, next := range statusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// PaidStatuses are the statuses of transactions the buyer has paid for and
+// not been refunded.
+var PaidStatuses = []TransactionStatus{StatusPaid, StatusShipped, StatusDelivered, StatusCompleted}
+
+// Actor is who changed a transaction's status. ID is the user's ID for
+// buyers and sellers and 0 otherwise.
+type Actor struct {
+	ID   int
+	Role string
+}
+
+const (
+	RoleBuyer  = "buyer"
+	RoleSeller = "seller"
+	RoleAdmin  = "admin"
+)
+
+var (
+	ActorMidtrans       = Actor{Role: "midtrans"}
+	ActorReconciliation = Actor{Role: "reconciliation"}
+	ActorWallet         = Actor{Role: "wallet"}
+	ActorSystem         = Actor{Role: "system"}
+)
+
+// transitionActors lists who may move a transaction to each status: its
+// seller ships it, its buyer confirms it arrived, the payment provider (or
+// reconciliation, relaying it) and the wallet pay for it, and unpaid ones
+// end by the buyer cancelling or the system giving up on them. Who may
+// refund depends on how far it got, in refundActors.
+var transitionActors = map[TransactionStatus][]string{
+	StatusPaid:      {ActorMidtrans.Role, ActorReconciliation.Role, ActorWallet.Role},
+	StatusShipped:   {RoleSeller},
+	StatusDelivered: {RoleBuyer},
+	StatusCompleted: {RoleBuyer},
+	StatusCancelled: {RoleBuyer, ActorSystem.Role, ActorMidtrans.Role, ActorReconciliation.Role},
+	StatusExpired:   {ActorSystem.Role, ActorMidtrans.Role, ActorReconciliation.Role},
+}
+
+// refundActors lists who may refund a transaction in each status. Its buyer
+// can only while it has not shipped; after that the refund is its seller's
+// or an admin's to approve, by making it. A refund at the provider is
+// recorded whenever it happens, and the system refunds a transaction whose
+// fulfilment had to be undone.
+var refundActors = map[TransactionStatus][]string{
+	StatusPaid:      {RoleBuyer, RoleSeller, RoleAdmin, ActorMidtrans.Role, ActorReconciliation.Role, ActorSystem.Role},
+	StatusShipped:   {RoleSeller, RoleAdmin, ActorMidtrans.Role, ActorReconciliation.Role, ActorSystem.Role},
+	StatusDelivered: {RoleSeller, RoleAdmin, ActorMidtrans.Role, ActorReconciliation.Role, ActorSystem.Role},
+}
+
+// MayMove reports whether the actor may move a transaction from status
+// from to status to. That a buyer or seller is the transaction's own is
+// left to the caller.
+func (a Actor) MayMove(from TransactionStatus, to TransactionStatus) bool {
+	if !from.CanTransitionTo(to) {
+		return false
+	}
+	roles := transitionActors[to]
+	if to == StatusRefunded {
+		roles = refundActors[from]
+	}
+	for _, role := range roles {
+		if role == a.Role {
+			return true
+		}
+	}
+	return false
+}
+
+// AtProvider reports whether the actor relays what happened at the
+// payment provider, either from its notifications or by asking it.
+func (a Actor) AtProvider() bool {
+	return a == ActorMidtrans || a == ActorReconciliation
+}
+
+func Buyer(user_id int) Actor {
+	return Actor{ID: user_id, Role: RoleBuyer}
+}
+
+func Seller(user_id int) Actor {
+	return Actor{ID: user_id, Role: RoleSeller}
+}
+
+func Admin(user_id int) Actor {
+	return Actor{ID: user_id, Role: RoleAdmin}
+}
+
+// TransactionStatusHistory records one status change of a transaction.
+// From_Status is empty for the entry written when it is created.
+type TransactionStatusHistory struct {
+	ID             uint              `gorm:"primaryKey;autoincrement" json:"id"`
+	Transaction_ID uint              `gorm:"not null;index" json:"transaction_id"`
+	From_Status    TransactionStatus `json:"from_status,omitempty"`
+	To_Status      TransactionStatus `gorm:"not null" json:"to_status"`
+	Actor          string            `gorm:"not null" json:"actor"`
+	Actor_ID       int               `json:"actor_id,omitempty"`
+	Reason         string            `json:"reason,omitempty"`
+	CreatedAt      time.Time         `gorm:"not null" json:"created_at"`
+}
+
+// NewStatusHistory records a transaction moving from one status to another.
+func NewStatusHistory(transaction_id uint, from TransactionStatus, to TransactionStatus, actor Actor, reason string) TransactionStatusHistory {
+	return TransactionStatusHistory{
+		Transaction_ID: transaction_id,
+		From_Status:    from,
+		To_Status:      to,
+		Actor:          actor.Role,
+		Actor_ID:       actor.ID,
+		Reason:         reason,
+	}
+}
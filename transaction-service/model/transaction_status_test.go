@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var allStatuses = []TransactionStatus{
+	StatusPending, StatusPaid, StatusShipped, StatusDelivered,
+	StatusCompleted, StatusCancelled, StatusExpired, StatusRefunded,
+}
+
+func TestCanTransitionTo(t *testing.T) {
+	allowed := map[TransactionStatus][]TransactionStatus{
+		StatusPending:   {StatusPaid, StatusCancelled, StatusExpired},
+		StatusPaid:      {StatusShipped, StatusRefunded},
+		StatusShipped:   {StatusDelivered, StatusRefunded},
+		StatusDelivered: {StatusCompleted, StatusRefunded},
+	}
+
+	for _, from := range allStatuses {
+		for _, to := range allStatuses {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+			assert.Equal(t, want, from.CanTransitionTo(to), "%s -> %s", from, to)
+		}
+	}
+}
+
+func TestFinalStatuses(t *testing.T) {
+	for _, final := range []TransactionStatus{StatusCompleted, StatusCancelled, StatusExpired, StatusRefunded} {
+		for _, to := range allStatuses {
+			assert.False(t, final.CanTransitionTo(to), "%s is final but moves to %s", final, to)
+		}
+	}
+}
+
+func TestMayMove(t *testing.T) {
+	buyer := Buyer(1)
+	seller := Seller(2)
+	admin := Admin(3)
+	everyone := []Actor{buyer, seller, admin, ActorMidtrans, ActorReconciliation, ActorWallet, ActorSystem}
+
+	tests := []struct {
+		name  string
+		from  TransactionStatus
+		to    TransactionStatus
+		allow []Actor
+	}{
+		{"pay", StatusPending, StatusPaid, []Actor{ActorMidtrans, ActorReconciliation, ActorWallet}},
+		{"cancel unpaid", StatusPending, StatusCancelled, []Actor{buyer, ActorSystem, ActorMidtrans, ActorReconciliation}},
+		{"expire unpaid", StatusPending, StatusExpired, []Actor{ActorSystem, ActorMidtrans, ActorReconciliation}},
+		{"ship", StatusPaid, StatusShipped, []Actor{seller}},
+		{"confirm delivery", StatusShipped, StatusDelivered, []Actor{buyer}},
+		{"complete", StatusDelivered, StatusCompleted, []Actor{buyer}},
+		{"refund before shipping", StatusPaid, StatusRefunded, []Actor{buyer, seller, admin, ActorMidtrans, ActorReconciliation, ActorSystem}},
+		{"refund shipped", StatusShipped, StatusRefunded, []Actor{seller, admin, ActorMidtrans, ActorReconciliation, ActorSystem}},
+		{"refund delivered", StatusDelivered, StatusRefunded, []Actor{seller, admin, ActorMidtrans, ActorReconciliation, ActorSystem}},
+		{"cancel paid", StatusPaid, StatusCancelled, nil},
+		{"pay again", StatusPaid, StatusPaid, nil},
+		{"skip shipping", StatusPaid, StatusDelivered, nil},
+		{"reopen completed", StatusCompleted, StatusPaid, nil},
+		{"pay cancelled", StatusCancelled, StatusPaid, nil},
+		{"pay expired", StatusExpired, StatusPaid, nil},
+		{"refund pending", StatusPending, StatusRefunded, nil},
+		{"refund completed", StatusCompleted, StatusRefunded, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, a := range everyone {
+				want := false
+				for _, allowed := range tt.allow {
+					if a == allowed {
+						want = true
+					}
+				}
+				assert.Equal(t, want, a.MayMove(tt.from, tt.to), "%s: %s -> %s", a.Role, tt.from, tt.to)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"main/model"
+	"main/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CartRepository interface {
+	GetItems(user_id int) ([]model.CartItem, error)
+	AddItem(item model.CartItem) (model.CartItem, error)
+	SetQty(user_id int, book_id int, qty int) error
+	SetPrice(user_id int, book_id int, price float64) error
+	RemoveItem(user_id int, book_id int) error
+	Clear(user_id int) error
+}
+
+type cartRepository struct {
+	db *gorm.DB
+}
+
+func NewCartRepository(db *gorm.DB) CartRepository {
+	return &cartRepository{db: db}
+}
+
+func (r *cartRepository) GetItems(user_id int) ([]model.CartItem, error) {
+	var items []model.CartItem
+	err := r.db.Where("user_id = ?", user_id).Order("created_at").Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// AddItem puts a book in the cart. Adding a book that is already there
+// adds to its quantity and refreshes its price.
+func (r *cartRepository) AddItem(item model.CartItem) (model.CartItem, error) {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "book_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"qty":        gorm.Expr("cart_items.qty + EXCLUDED.qty"),
+			"unit_price": gorm.Expr("EXCLUDED.unit_price"),
+			"updated_at": gorm.Expr("EXCLUDED.updated_at"),
+		}),
+	}).Create(&item).Error
+	if err != nil {
+		return model.CartItem{}, err
+	}
+
+	var saved model.CartItem
+	if err := r.db.Where("user_id = ? AND book_id = ?", item.User_ID, item.Book_ID).First(&saved).Error; err != nil {
+		return model.CartItem{}, err
+	}
+	return saved, nil
+}
+
+func (r *cartRepository) SetQty(user_id int, book_id int, qty int) error {
+	return r.updateItem(user_id, book_id, "qty", qty)
+}
+
+func (r *cartRepository) SetPrice(user_id int, book_id int, price float64) error {
+	return r.updateItem(user_id, book_id, "unit_price", price)
+}
+
+func (r *cartRepository) updateItem(user_id int, book_id int, column string, value interface{}) error {
+	result := r.db.Model(&model.CartItem{}).Where("user_id = ? AND book_id = ?", user_id, book_id).Update(column, value)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (r *cartRepository) RemoveItem(user_id int, book_id int) error {
+	result := r.db.Where("user_id = ? AND book_id = ?", user_id, book_id).Delete(&model.CartItem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return utils.ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (r *cartRepository) Clear(user_id int) error {
+	return r.db.Where("user_id = ?", user_id).Delete(&model.CartItem{}).Error
+}
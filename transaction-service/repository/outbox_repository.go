@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"main/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	GetDue(now time.Time, limit int) ([]model.OutboxEvent, error)
+	Claim(event_id uint, now time.Time, until time.Time) (bool, error)
+	MarkDelivered(event_id uint, at time.Time) error
+	Reschedule(event_id uint, attempts int, next time.Time, last_error string) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// GetDue returns up to limit undelivered events that are due, oldest
+// first.
+func (r *outboxRepository) GetDue(now time.Time, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := r.db.Where("delivered_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Claim takes the event for delivering until the given time, and reports
+// whether it got it, so two relays never deliver the same event at once.
+// An event whose relay died mid-delivery becomes due again when the claim
+// runs out.
+func (r *outboxRepository) Claim(event_id uint, now time.Time, until time.Time) (bool, error) {
+	res := r.db.Model(&model.OutboxEvent{}).
+		Where("id = ? AND delivered_at IS NULL AND next_attempt_at <= ?", event_id, now).
+		Update("next_attempt_at", until)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *outboxRepository) MarkDelivered(event_id uint, at time.Time) error {
+	return r.db.Model(&model.OutboxEvent{}).Where("id = ?", event_id).Updates(map[string]interface{}{
+		"delivered_at":    at,
+		"next_attempt_at": nil,
+		"last_error":      "",
+	}).Error
+}
+
+// Reschedule records a failed delivery and when to try again.
+func (r *outboxRepository) Reschedule(event_id uint, attempts int, next time.Time, last_error string) error {
+	return r.db.Model(&model.OutboxEvent{}).Where("id = ?", event_id).Updates(map[string]interface{}{
+		"attempts":        attempts,
+		"next_attempt_at": next,
+		"last_error":      last_error,
+	}).Error
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"main/model"
+	"main/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReconciliationRepository interface {
+	GetTransactionsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.Transaction, error)
+	GetCheckoutsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.Checkout, error)
+	GetTopUpsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.TopUp, error)
+	HasOpenDiscrepancy(order_id string, kind model.DiscrepancyKind) (bool, error)
+	CreateRun(run model.ReconciliationRun) (model.ReconciliationRun, error)
+	GetRuns(limit int) ([]model.ReconciliationRun, error)
+	GetRun(run_id int) (model.ReconciliationRun, error)
+}
+
+type reconciliationRepository struct {
+	db *gorm.DB
+}
+
+func NewReconciliationRepository(db *gorm.DB) ReconciliationRepository {
+	return &reconciliationRepository{db: db}
+}
+
+// GetTransactionsToReconcile returns the transactions created since then
+// in one of statuses that were charged on their own at the provider.
+// Expired and cancelled transactions are soft-deleted soon after they end,
+// but are still returned: those are the ones a lost payment ends up in.
+// Transactions paid fully from the wallet have no order ID, as nothing was
+// charged at the provider to compare them with; split payments have one
+// for the part that was. Transactions from a checkout were charged under
+// the checkout's order ID, so they are reconciled through it.
+func (r *reconciliationRepository) GetTransactionsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.Transaction, error) {
+	var trans []model.Transaction
+	err := r.db.Unscoped().
+		Where("order_id <> '' AND checkout_id IS NULL AND created_at >= ? AND status IN ?", since, statuses).
+		Order("transaction_id").
+		Find(&trans).Error
+	if err != nil {
+		return nil, err
+	}
+	return trans, nil
+}
+
+// GetCheckoutsToReconcile returns the checkouts created since then in one
+// of statuses that were charged at the provider.
+func (r *reconciliationRepository) GetCheckoutsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.Checkout, error) {
+	var checkouts []model.Checkout
+	err := r.db.Where("order_id <> '' AND created_at >= ? AND status IN ?", since, statuses).
+		Order("checkout_id").
+		Find(&checkouts).Error
+	if err != nil {
+		return nil, err
+	}
+	return checkouts, nil
+}
+
+// GetTopUpsToReconcile returns the wallet top-ups created since then in
+// one of statuses that were charged at the provider.
+func (r *reconciliationRepository) GetTopUpsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.TopUp, error) {
+	var topUps []model.TopUp
+	err := r.db.Where("order_id <> '' AND created_at >= ? AND status IN ?", since, statuses).
+		Order("top_up_id").
+		Find(&topUps).Error
+	if err != nil {
+		return nil, err
+	}
+	return topUps, nil
+}
+
+// HasOpenDiscrepancy reports whether an earlier run already left a
+// discrepancy of that kind for the order to finance.
+func (r *reconciliationRepository) HasOpenDiscrepancy(order_id string, kind model.DiscrepancyKind) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.PaymentDiscrepancy{}).
+		Where("order_id = ? AND kind = ? AND resolution IN ?", order_id, kind, model.OpenResolutions).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// CreateRun saves a run with its discrepancies.
+func (r *reconciliationRepository) CreateRun(run model.ReconciliationRun) (model.ReconciliationRun, error) {
+	if err := r.db.Create(&run).Error; err != nil {
+		return model.ReconciliationRun{}, err
+	}
+	return run, nil
+}
+
+// GetRuns returns the latest runs, newest first, without their
+// discrepancies.
+func (r *reconciliationRepository) GetRuns(limit int) ([]model.ReconciliationRun, error) {
+	var runs []model.ReconciliationRun
+	err := r.db.Order("started_at DESC").Limit(limit).Find(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *reconciliationRepository) GetRun(run_id int) (model.ReconciliationRun, error) {
+	var run model.ReconciliationRun
+	err := r.db.Preload("Discrepancies", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&run, run_id).Error
+	if err != nil {
+		return model.ReconciliationRun{}, utils.ErrReconciliationRunNotFound
+	}
+	return run, nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"main/model"
+	"main/utils"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SagaRepository interface {
+	Create(saga model.Saga) (model.Saga, error)
+	GetByID(saga_id int) (model.Saga, error)
+	GetByTransaction(transaction_id int, kind model.SagaKind) (model.Saga, error)
+	Claim(saga_id int, now time.Time, until time.Time) (bool, error)
+	Save(saga model.Saga) error
+	GetDue(now time.Time, limit int) ([]model.Saga, error)
+	GetStuck(before time.Time) ([]model.Saga, error)
+}
+
+type sagaRepository struct {
+	db *gorm.DB
+}
+
+func NewSagaRepository(db *gorm.DB) SagaRepository {
+	return &sagaRepository{db: db}
+}
+
+// Create stores the saga with its steps. A transaction has at most one
+// saga of each kind, so if it already has one that saga is returned
+// instead.
+func (r *sagaRepository) Create(saga model.Saga) (model.Saga, error) {
+	if err := r.db.Create(&saga).Error; err != nil {
+		if existing, getErr := r.GetByTransaction(int(saga.Transaction_ID), saga.Kind); getErr == nil {
+			return existing, nil
+		}
+		return model.Saga{}, err
+	}
+	return saga, nil
+}
+
+func (r *sagaRepository) GetByID(saga_id int) (model.Saga, error) {
+	var saga model.Saga
+	err := r.db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).First(&saga, saga_id).Error
+	if err != nil {
+		return model.Saga{}, utils.ErrSagaNotFound
+	}
+	return saga, nil
+}
+
+func (r *sagaRepository) GetByTransaction(transaction_id int, kind model.SagaKind) (model.Saga, error) {
+	var saga model.Saga
+	err := r.db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).Where("transaction_id = ? AND kind = ?", transaction_id, kind).First(&saga).Error
+	if err != nil {
+		return model.Saga{}, utils.ErrSagaNotFound
+	}
+	return saga, nil
+}
+
+// Claim takes the saga for running until the given time, and reports
+// whether it got it. Only a due saga that is running or compensating can
+// be claimed, so two workers never run the same saga at once.
+func (r *sagaRepository) Claim(saga_id int, now time.Time, until time.Time) (bool, error) {
+	res := r.db.Model(&model.Saga{}).
+		Where("id = ? AND status IN ? AND next_attempt_at <= ?", saga_id, []model.SagaStatus{model.SagaRunning, model.SagaCompensating}, now).
+		Update("next_attempt_at", until)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Save stores the saga and the progress of its steps.
+func (r *sagaRepository) Save(saga model.Saga) error {
+	return r.db.Session(&gorm.Session{FullSaveAssociations: true}).Save(&saga).Error
+}
+
+// GetDue returns up to limit sagas that are due to run, oldest due first.
+func (r *sagaRepository) GetDue(now time.Time, limit int) ([]model.Saga, error) {
+	var sagas []model.Saga
+	err := r.db.Where("status IN ? AND next_attempt_at <= ?", []model.SagaStatus{model.SagaRunning, model.SagaCompensating}, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&sagas).Error
+	if err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
+
+// GetStuck returns the unresolved sagas someone has to look at: failed
+// ones, compensated ones whose buyer paid for nothing, and ones still
+// going that started before the given time.
+func (r *sagaRepository) GetStuck(before time.Time) ([]model.Saga, error) {
+	var sagas []model.Saga
+	err := r.db.Preload("Steps", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position")
+	}).
+		Where("resolved_at IS NULL").
+		Where(r.db.Where("status IN ?", []model.SagaStatus{model.SagaFailed, model.SagaCompensated}).
+			Or("status IN ? AND created_at < ?", []model.SagaStatus{model.SagaRunning, model.SagaCompensating}, before)).
+		Order("created_at").
+		Find(&sagas).Error
+	if err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
+
+// MigrateSagaKinds drops the index that allowed a transaction only one
+// saga, from before sagas had kinds. Sagas created then are fulfilment
+// sagas, the kind's default.
+func MigrateSagaKinds(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&model.Saga{}, "idx_sagas_transaction_id") {
+		return db.Migrator().DropIndex(&model.Saga{}, "idx_sagas_transaction_id")
+	}
+	return nil
+}
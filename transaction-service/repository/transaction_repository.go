@@ -11,8 +11,22 @@ import (
 type TransactionRepository interface {
 	CreateTransaction(user_id int, t model.Transaction) (model.Transaction, error)
 	GetTransaction(user_id int) ([]model.Transaction, error)
-	UpdateTransactionStatus(transaction_id int) (model.Transaction, error)
 	GetTransactionByID(transaction_id int) (model.Transaction, error)
+	GetTransactionWithDeleted(transaction_id int) (model.Transaction, error)
+	TransitionStatus(trans model.Transaction, entry model.TransactionStatusHistory) (bool, error)
+	RefundTransaction(trans model.Transaction, entry model.TransactionStatusHistory, refund model.Refund) (bool, error)
+	GetRefund(transaction_id int) (model.Refund, error)
+	GetStatusHistory(transaction_id int) ([]model.TransactionStatusHistory, error)
+	SetOrderID(transaction_id int, order_id string) error
+	GetDailySales(from time.Time, to time.Time) ([]model.DailySales, error)
+	CreateCheckout(user_id int, checkout model.Checkout) (model.Checkout, error)
+	GetCheckoutByID(checkout_id int) (model.Checkout, error)
+	TransitionCheckoutStatus(checkout_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error)
+	SetCheckoutOrderID(checkout_id int, order_id string) error
+	CreateTopUp(topUp model.TopUp) (model.TopUp, error)
+	GetTopUpByID(top_up_id int) (model.TopUp, error)
+	TransitionTopUpStatus(top_up_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error)
+	SetTopUpOrderID(top_up_id int, order_id string) error
 }
 
 type transactionRepository struct {
@@ -27,9 +41,16 @@ func (r *transactionRepository) CreateTransaction(user_id int, t model.Transacti
 	t.User_ID = user_id
 	t.CreatedAt = time.Now()
 	t.Expiration_Date = time.Now().Add(4 * time.Hour)
-	t.Status = "pending"
+	t.Status = model.StatusPending
 
-	if err := r.db.Create(&t).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&t).Error; err != nil {
+			return err
+		}
+		entry := model.NewStatusHistory(t.Transaction_ID, "", model.StatusPending, model.Buyer(user_id), "order placed")
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
 		return model.Transaction{}, err
 	}
 	return t, nil
@@ -37,7 +58,7 @@ func (r *transactionRepository) CreateTransaction(user_id int, t model.Transacti
 
 func (r *transactionRepository) GetTransaction(user_id int) ([]model.Transaction, error) {
 	var trans []model.Transaction
-	err := r.db.Where("user_id = ?", user_id).Find(&trans).Error
+	err := r.db.Preload("Items").Preload("Refund").Where("user_id = ?", user_id).Find(&trans).Error
 
 	if err != nil {
 		return nil, utils.ErrUserNotFound
@@ -45,24 +66,246 @@ func (r *transactionRepository) GetTransaction(user_id int) ([]model.Transaction
 	return trans, nil
 }
 
-func (r *transactionRepository) UpdateTransactionStatus(transaction_id int) (model.Transaction, error) {
+func (r *transactionRepository) GetTransactionByID(transaction_id int) (model.Transaction, error) {
 	var t model.Transaction
-	err := r.db.Model(&t).Where("transaction_id = ?", transaction_id).Update("status", "success").Error
-	if err != nil {
-		return model.Transaction{}, utils.ErrBadReq
-	}
-
-	if err := r.db.First(&t, transaction_id).Error; err != nil {
+	if err := r.db.Preload("Items").Preload("Refund").First(&t, transaction_id).Error; err != nil {
 		return model.Transaction{}, utils.ErrUserNotFound
 	}
-
 	return t, nil
 }
 
-func (r *transactionRepository) GetTransactionByID(transaction_id int) (model.Transaction, error) {
+// GetTransactionWithDeleted is GetTransactionByID, but also finds cancelled
+// and expired transactions that have been soft-deleted, which a payment
+// can still come in for.
+func (r *transactionRepository) GetTransactionWithDeleted(transaction_id int) (model.Transaction, error) {
 	var t model.Transaction
-	if err := r.db.First(&t, transaction_id).Error; err != nil {
+	if err := r.db.Unscoped().Preload("Items").Preload("Refund").First(&t, transaction_id).Error; err != nil {
 		return model.Transaction{}, utils.ErrUserNotFound
 	}
 	return t, nil
 }
+
+// TransitionStatus moves the transaction from its current status to the
+// entry's status, recording the entry in its history and the events other
+// services are told about in the outbox, and reports whether it did.
+// Nothing changes if the transaction is no longer in the status it was
+// read in, so of two callers racing to change the same transaction exactly
+// one wins.
+func (r *transactionRepository) TransitionStatus(trans model.Transaction, entry model.TransactionStatusHistory) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		changed, err = transition(tx, trans, entry)
+		return err
+	})
+	if err != nil {
+		return false, utils.ErrBadReq
+	}
+	return changed, nil
+}
+
+// RefundTransaction moves the transaction to refunded like
+// TransitionStatus, storing the refund along with it.
+func (r *transactionRepository) RefundTransaction(trans model.Transaction, entry model.TransactionStatusHistory, refund model.Refund) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		changed, err = transition(tx, trans, entry)
+		if err != nil || !changed {
+			return err
+		}
+		return tx.Create(&refund).Error
+	})
+	if err != nil {
+		return false, utils.ErrBadReq
+	}
+	return changed, nil
+}
+
+func transition(tx *gorm.DB, trans model.Transaction, entry model.TransactionStatusHistory) (bool, error) {
+	res := tx.Model(&model.Transaction{}).
+		Where("transaction_id = ? AND status = ?", trans.Transaction_ID, trans.Status).
+		Update("status", entry.To_Status)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return false, err
+	}
+
+	events, err := model.StatusChangedEvents(trans, entry)
+	if err != nil {
+		return false, err
+	}
+	if len(events) == 0 {
+		return true, nil
+	}
+	return true, tx.Create(&events).Error
+}
+
+func (r *transactionRepository) GetRefund(transaction_id int) (model.Refund, error) {
+	var refund model.Refund
+	if err := r.db.Where("transaction_id = ?", transaction_id).First(&refund).Error; err != nil {
+		return model.Refund{}, err
+	}
+	return refund, nil
+}
+
+// GetStatusHistory returns the transaction's status changes, oldest first.
+func (r *transactionRepository) GetStatusHistory(transaction_id int) ([]model.TransactionStatusHistory, error) {
+	var history []model.TransactionStatusHistory
+	err := r.db.Where("transaction_id = ?", transaction_id).Order("created_at, id").Find(&history).Error
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r *transactionRepository) SetOrderID(transaction_id int, order_id string) error {
+	err := r.db.Model(&model.Transaction{}).Where("transaction_id = ?", transaction_id).Update("order_id", order_id).Error
+	if err != nil {
+		return utils.ErrBadReq
+	}
+	return nil
+}
+
+// GetDailySales sums paid transactions per book and day for the days
+// from through to, both inclusive.
+func (r *transactionRepository) GetDailySales(from time.Time, to time.Time) ([]model.DailySales, error) {
+	var sales []model.DailySales
+	err := r.db.Model(&model.Transaction{}).
+		Select("book_id, DATE(created_at AT TIME ZONE 'UTC') AS day, COUNT(*) AS sales, COALESCE(SUM(amount), 0) AS revenue").
+		Where("status IN ? AND created_at >= ? AND created_at < ?", model.PaidStatuses, from, to.AddDate(0, 0, 1)).
+		Group("book_id, day").
+		Order("day, book_id").
+		Scan(&sales).Error
+	if err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+// CreateCheckout stores the checkout together with its per-seller
+// transactions, their items and their first history entries in one
+// database transaction.
+func (r *transactionRepository) CreateCheckout(user_id int, checkout model.Checkout) (model.Checkout, error) {
+	now := time.Now()
+	checkout.User_ID = user_id
+	checkout.CreatedAt = now
+	checkout.Expiration_Date = now.Add(4 * time.Hour)
+	checkout.Status = model.StatusPending
+	for i := range checkout.Transactions {
+		checkout.Transactions[i].User_ID = user_id
+		checkout.Transactions[i].CreatedAt = now
+		checkout.Transactions[i].Expiration_Date = checkout.Expiration_Date
+		checkout.Transactions[i].Status = model.StatusPending
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&checkout).Error; err != nil {
+			return err
+		}
+		for _, t := range checkout.Transactions {
+			entry := model.NewStatusHistory(t.Transaction_ID, "", model.StatusPending, model.Buyer(user_id), "order placed at checkout")
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return model.Checkout{}, err
+	}
+	return checkout, nil
+}
+
+// GetCheckoutByID returns the checkout with its transactions, including
+// ones soft-deleted after the checkout was cancelled or expired.
+func (r *transactionRepository) GetCheckoutByID(checkout_id int) (model.Checkout, error) {
+	var checkout model.Checkout
+	err := r.db.Preload("Transactions", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Preload("Transactions.Items").First(&checkout, checkout_id).Error
+	if err != nil {
+		return model.Checkout{}, utils.ErrCheckoutNotFound
+	}
+	return checkout, nil
+}
+
+// TransitionCheckoutStatus moves the checkout from status from to status
+// to, and reports whether it did. Checkouts keep no history of their own;
+// their transactions do.
+func (r *transactionRepository) TransitionCheckoutStatus(checkout_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error) {
+	res := r.db.Model(&model.Checkout{}).
+		Where("checkout_id = ? AND status = ?", checkout_id, from).
+		Update("status", to)
+	if res.Error != nil {
+		return false, utils.ErrBadReq
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *transactionRepository) SetCheckoutOrderID(checkout_id int, order_id string) error {
+	err := r.db.Model(&model.Checkout{}).Where("checkout_id = ?", checkout_id).Update("order_id", order_id).Error
+	if err != nil {
+		return utils.ErrBadReq
+	}
+	return nil
+}
+
+// MigrateLegacyStatuses renames the statuses written before transactions
+// had a state machine: "success" became paid and "fail" cancelled.
+func MigrateLegacyStatuses(db *gorm.DB) error {
+	renames := map[string]model.TransactionStatus{
+		"success": model.StatusPaid,
+		"fail":    model.StatusCancelled,
+	}
+	for old, status := range renames {
+		if err := db.Model(&model.Transaction{}).Where("status = ?", old).Update("status", status).Error; err != nil {
+			return err
+		}
+		if err := db.Model(&model.Checkout{}).Where("status = ?", old).Update("status", status).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *transactionRepository) CreateTopUp(topUp model.TopUp) (model.TopUp, error) {
+	topUp.Status = model.StatusPending
+	if err := r.db.Create(&topUp).Error; err != nil {
+		return model.TopUp{}, err
+	}
+	return topUp, nil
+}
+
+func (r *transactionRepository) GetTopUpByID(top_up_id int) (model.TopUp, error) {
+	var topUp model.TopUp
+	if err := r.db.First(&topUp, top_up_id).Error; err != nil {
+		return model.TopUp{}, utils.ErrTopUpNotFound
+	}
+	return topUp, nil
+}
+
+// TransitionTopUpStatus moves the top-up from status from to status to,
+// and reports whether it did.
+func (r *transactionRepository) TransitionTopUpStatus(top_up_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error) {
+	res := r.db.Model(&model.TopUp{}).
+		Where("top_up_id = ? AND status = ?", top_up_id, from).
+		Update("status", to)
+	if res.Error != nil {
+		return false, utils.ErrBadReq
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (r *transactionRepository) SetTopUpOrderID(top_up_id int, order_id string) error {
+	err := r.db.Model(&model.TopUp{}).Where("top_up_id = ?", top_up_id).Update("order_id", order_id).Error
+	if err != nil {
+		return utils.ErrBadReq
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"main/model"
+	"main/repository"
+)
+
+type CartService interface {
+	GetItems(user_id int) ([]model.CartItem, error)
+	AddItem(item model.CartItem) (model.CartItem, error)
+	SetQty(user_id int, book_id int, qty int) error
+	SetPrice(user_id int, book_id int, price float64) error
+	RemoveItem(user_id int, book_id int) error
+	Clear(user_id int) error
+}
+
+type cartService struct {
+	repo repository.CartRepository
+}
+
+func NewCartService(repo repository.CartRepository) CartService {
+	return &cartService{repo: repo}
+}
+
+func (s *cartService) GetItems(user_id int) ([]model.CartItem, error) {
+	return s.repo.GetItems(user_id)
+}
+
+func (s *cartService) AddItem(item model.CartItem) (model.CartItem, error) {
+	return s.repo.AddItem(item)
+}
+
+func (s *cartService) SetQty(user_id int, book_id int, qty int) error {
+	return s.repo.SetQty(user_id, book_id, qty)
+}
+
+func (s *cartService) SetPrice(user_id int, book_id int, price float64) error {
+	return s.repo.SetPrice(user_id, book_id, price)
+}
+
+func (s *cartService) RemoveItem(user_id int, book_id int) error {
+	return s.repo.RemoveItem(user_id, book_id)
+}
+
+func (s *cartService) Clear(user_id int) error {
+	return s.repo.Clear(user_id)
+}
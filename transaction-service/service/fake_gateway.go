@@ -0,0 +1,150 @@
+package service
+
+import (
+	"encoding/json"
+	"main/utils"
+	"sync"
+)
+
+// FakeGateway is an in-memory payment provider for running the purchase
+// flow without Midtrans. Charges stay pending until Simulate moves them,
+// using Midtrans' names for payment statuses. Its notifications carry only
+// an order ID: the status is whatever the fake holds for the order, so
+// they can not claim a payment that was not simulated.
+type FakeGateway struct {
+	mu      sync.Mutex
+	charges map[string]*fakeCharge
+}
+
+type fakeCharge struct {
+	status   string
+	amount   float64
+	refunded float64
+	refunds  map[string]bool
+}
+
+// fakeStatuses are the statuses Simulate can move a charge to.
+var fakeStatuses = map[string]bool{
+	"pending":    true,
+	"capture":    true,
+	"settlement": true,
+	"deny":       true,
+	"cancel":     true,
+	"expire":     true,
+}
+
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{charges: map[string]*fakeCharge{}}
+}
+
+func (g *FakeGateway) CreateCharge(charge PaymentCharge) (PaymentLink, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.charges[charge.Order_ID] = &fakeCharge{
+		status:  "pending",
+		amount:  float64(int(charge.Amount)),
+		refunds: map[string]bool{},
+	}
+	return PaymentLink{
+		Token:        "fake-" + charge.Order_ID,
+		Redirect_URL: "/payments/fake/" + charge.Order_ID,
+	}, nil
+}
+
+func (g *FakeGateway) GetStatus(order_id string) (PaymentStatus, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[order_id]
+	if !ok {
+		return PaymentStatus{}, utils.ErrOrderNotFound
+	}
+	return charge.paymentStatus(order_id), nil
+}
+
+func (g *FakeGateway) Cancel(order_id string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[order_id]
+	if !ok {
+		return nil
+	}
+	switch charge.status {
+	case "pending":
+		charge.status = "cancel"
+	case "capture", "settlement", "refund", "partial_refund":
+		return utils.ErrPaymentNotCancellable
+	}
+	return nil
+}
+
+func (g *FakeGateway) Refund(order_id string, refund_key string, amount float64, reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[order_id]
+	if !ok {
+		return utils.ErrOrderNotFound
+	}
+	if charge.refunds[refund_key] {
+		return nil
+	}
+	switch charge.status {
+	case "capture", "settlement", "partial_refund":
+	default:
+		return utils.ErrInvalidTransition
+	}
+	if charge.refunded+amount > charge.amount {
+		return utils.ErrBadReq
+	}
+
+	charge.refunds[refund_key] = true
+	charge.refunded += amount
+	charge.status = "partial_refund"
+	if charge.refunded == charge.amount {
+		charge.status = "refund"
+	}
+	return nil
+}
+
+// VerifyNotification reads a notification of the form {"order_id": ...}
+// and reports the status the fake holds for that order.
+func (g *FakeGateway) VerifyNotification(body []byte) (PaymentStatus, error) {
+	var n struct {
+		OrderID string `json:"order_id"`
+	}
+	if err := json.Unmarshal(body, &n); err != nil || n.OrderID == "" {
+		return PaymentStatus{}, utils.ErrBadReq
+	}
+	return g.GetStatus(n.OrderID)
+}
+
+// Simulate moves an order's charge to status as if the buyer had paid,
+// been refused or let it expire, and returns the payment status a
+// notification of it would report.
+func (g *FakeGateway) Simulate(order_id string, status string) (PaymentStatus, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	charge, ok := g.charges[order_id]
+	if !ok {
+		return PaymentStatus{}, utils.ErrOrderNotFound
+	}
+	if !fakeStatuses[status] {
+		return PaymentStatus{}, utils.ErrBadReq
+	}
+
+	charge.status = status
+	return charge.paymentStatus(order_id), nil
+}
+
+func (c *fakeCharge) paymentStatus(order_id string) PaymentStatus {
+	return PaymentStatus{
+		Order_ID:        order_id,
+		Status:          utils.NotificationStatus(c.status, ""),
+		Provider_Status: c.status,
+		Gross_Amount:    c.amount,
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"main/config"
+	"main/dto"
+	"main/utils"
+	"strconv"
+
+	"github.com/veritrans/go-midtrans"
+)
+
+type midtransGateway struct {
+	client midtrans.Client
+}
+
+// NewMidtransGateway pays through Midtrans, in the environment the config
+// names.
+func NewMidtransGateway(cfg config.PaymentConfig) PaymentGateway {
+	client := midtrans.NewClient()
+	client.ServerKey = cfg.ServerKey
+	client.ClientKey = cfg.ClientKey
+	client.APIEnvType = midtrans.Sandbox
+	if cfg.Environment == config.MidtransProduction {
+		client.APIEnvType = midtrans.Production
+	}
+	return &midtransGateway{client: client}
+}
+
+func (g *midtransGateway) CreateCharge(charge PaymentCharge) (PaymentLink, error) {
+	snap := midtrans.SnapGateway{Client: g.client}
+	resp, err := snap.GetToken(&midtrans.SnapReq{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  charge.Order_ID,
+			GrossAmt: int64(charge.Amount),
+		},
+		CustomerDetail: &midtrans.CustDetail{
+			FName: charge.Name,
+			Email: charge.Email,
+		},
+	})
+	if err != nil {
+		return PaymentLink{}, err
+	}
+	if resp.Token == "" {
+		return PaymentLink{}, fmt.Errorf("midtrans returned no token (status %s)", resp.StatusCode)
+	}
+	return PaymentLink{Token: resp.Token, Redirect_URL: resp.RedirectURL}, nil
+}
+
+func (g *midtransGateway) GetStatus(order_id string) (PaymentStatus, error) {
+	core := midtrans.CoreGateway{Client: g.client}
+	resp, err := core.Status(order_id)
+	if err != nil {
+		return PaymentStatus{}, err
+	}
+	if resp.StatusCode == "404" {
+		return PaymentStatus{}, utils.ErrOrderNotFound
+	}
+	if resp.TransactionStatus == "" {
+		return PaymentStatus{}, fmt.Errorf("midtrans status returned %s: %s", resp.StatusCode, resp.StatusMessage)
+	}
+
+	gross, err := strconv.ParseFloat(resp.GrossAmount, 64)
+	if err != nil {
+		return PaymentStatus{}, fmt.Errorf("midtrans status returned gross amount %q", resp.GrossAmount)
+	}
+	return PaymentStatus{
+		Order_ID:        order_id,
+		Status:          utils.NotificationStatus(resp.TransactionStatus, resp.FraudStatus),
+		Provider_Status: resp.TransactionStatus,
+		Gross_Amount:    gross,
+	}, nil
+}
+
+// Cancel cancels the Midtrans charge of an order. An order the buyer never
+// started paying is unknown to Midtrans and has nothing to cancel.
+func (g *midtransGateway) Cancel(order_id string) error {
+	core := midtrans.CoreGateway{Client: g.client}
+	resp, err := core.Cancel(order_id)
+	if err != nil {
+		return err
+	}
+
+	switch resp.StatusCode {
+	case "200", "404":
+		return nil
+	case "412":
+		return utils.ErrPaymentNotCancellable
+	}
+	return fmt.Errorf("midtrans cancel returned %s: %s", resp.StatusCode, resp.StatusMessage)
+}
+
+func (g *midtransGateway) Refund(order_id string, refund_key string, amount float64, reason string) error {
+	core := midtrans.CoreGateway{Client: g.client}
+	resp, err := core.Refund(order_id, &midtrans.RefundReq{
+		RefundKey: refund_key,
+		Amount:    int64(amount),
+		Reason:    reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != "200" {
+		return fmt.Errorf("midtrans refund returned %s: %s", resp.StatusCode, resp.StatusMessage)
+	}
+	return nil
+}
+
+// VerifyNotification checks a Midtrans HTTP notification's signature
+// against our server key.
+func (g *midtransGateway) VerifyNotification(body []byte) (PaymentStatus, error) {
+	var n dto.MidtransNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return PaymentStatus{}, utils.ErrBadReq
+	}
+	if !utils.VerifyNotificationSignature(n, g.client.ServerKey) {
+		return PaymentStatus{}, utils.ErrInvalidSignature
+	}
+
+	gross, err := strconv.ParseFloat(n.GrossAmount, 64)
+	if err != nil {
+		return PaymentStatus{}, utils.ErrBadReq
+	}
+	return PaymentStatus{
+		Order_ID:        n.OrderID,
+		Status:          utils.NotificationStatus(n.TransactionStatus, n.FraudStatus),
+		Provider_Status: n.TransactionStatus,
+		Gross_Amount:    gross,
+	}, nil
+}
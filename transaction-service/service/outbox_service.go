@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"main/config"
+	"main/model"
+	"main/repository"
+	"main/utils"
+	"time"
+)
+
+// outboxLease is how long a claimed event is kept from other relays while
+// it is delivered.
+const outboxLease = time.Minute
+
+// OutboxConsumer handles one outbox event for a consumer. It may be called
+// more than once for the same event, and must return nil only once the
+// event has been handled.
+type OutboxConsumer func(event model.OutboxEvent) error
+
+// OutboxConsumers are the consumers the outbox delivers to: paid and
+// refunded transactions start their saga here, unpaid ones that end give
+// back their wallet part, and book-service is told over HTTP.
+func OutboxConsumers(transRepo repository.TransactionRepository, sagaServ SagaService) map[string]OutboxConsumer {
+	return map[string]OutboxConsumer{
+		model.LocalConsumer: func(event model.OutboxEvent) error {
+			var changed model.TransactionStatusChanged
+			if err := json.Unmarshal([]byte(event.Payload), &changed); err != nil {
+				return err
+			}
+			var kind model.SagaKind
+			switch changed.To_Status {
+			case model.StatusPaid:
+				kind = model.SagaFulfilment
+			case model.StatusRefunded:
+				kind = model.SagaRefund
+			case model.StatusCancelled, model.StatusExpired:
+				return returnWalletPayment(changed)
+			default:
+				return nil
+			}
+
+			trans, err := transRepo.GetTransactionByID(int(changed.Transaction_ID))
+			if err != nil {
+				return err
+			}
+			_, err = sagaServ.Start(kind, trans)
+			return err
+		},
+		model.BookConsumer: utils.DeliverToBookService,
+	}
+}
+
+// returnWalletPayment credits the buyer of a transaction that ended unpaid
+// with what was taken from their wallet for it. Whether that debit went
+// through is not always known, so it is made again with the same key first:
+// auth-service applies a key once, so this takes nothing more if it did,
+// and fails for lack of balance or takes the money now if it did not.
+func returnWalletPayment(changed model.TransactionStatusChanged) error {
+	if changed.Wallet_Amount <= 0 {
+		return nil
+	}
+
+	err := utils.DebitBalance(changed.User_ID, changed.Wallet_Amount, utils.WalletPaymentKey(changed.Transaction_ID))
+	if err == utils.ErrInsufficientBalance {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return utils.UpdateBalance(changed.User_ID, changed.Wallet_Amount, fmt.Sprintf("transaction-%d-wallet-return", changed.Transaction_ID))
+}
+
+type OutboxService interface {
+	RelayDue() int
+}
+
+type outboxService struct {
+	repo      repository.OutboxRepository
+	consumers map[string]OutboxConsumer
+	cfg       config.OutboxConfig
+	now       func() time.Time
+}
+
+func NewOutboxService(repo repository.OutboxRepository, consumers map[string]OutboxConsumer, cfg config.OutboxConfig) OutboxService {
+	return &outboxService{
+		repo:      repo,
+		consumers: consumers,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// RelayDue delivers every event that is due and returns how many it
+// delivered. An event that can not be delivered is tried again after a
+// backoff until it is; events are not delivered in any particular order.
+func (s *outboxService) RelayDue() int {
+	events, err := s.repo.GetDue(s.now(), 100)
+	if err != nil {
+		log.Println("Failed to load due outbox events:", err)
+		return 0
+	}
+
+	delivered := 0
+	for _, event := range events {
+		now := s.now()
+		claimed, err := s.repo.Claim(event.ID, now, now.Add(outboxLease))
+		if err != nil {
+			log.Println("Failed to claim outbox event", event.ID, ":", err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if err := s.deliver(event); err != nil {
+			attempts := event.Attempts + 1
+			next := s.now().Add(retryBackoff(s.cfg.RetryBase, s.cfg.RetryMax, attempts))
+			log.Println("Failed to deliver", event.Event_ID, "to", event.Consumer, ":", err)
+			if err := s.repo.Reschedule(event.ID, attempts, next, err.Error()); err != nil {
+				log.Println("Failed to reschedule outbox event", event.ID, ":", err)
+			}
+			continue
+		}
+
+		if err := s.repo.MarkDelivered(event.ID, s.now()); err != nil {
+			log.Println("Failed to mark outbox event", event.ID, "delivered:", err)
+			continue
+		}
+		delivered++
+	}
+	return delivered
+}
+
+func (s *outboxService) deliver(event model.OutboxEvent) error {
+	consumer, ok := s.consumers[event.Consumer]
+	if !ok {
+		return fmt.Errorf("no consumer %q", event.Consumer)
+	}
+	return consumer(event)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"main/config"
+	"main/model"
+)
+
+// PaymentGateway is the payment provider buyers pay through. Orders are
+// known to it by the order ID they were charged under.
+type PaymentGateway interface {
+	// CreateCharge starts charging an order and returns where the buyer
+	// pays it.
+	CreateCharge(charge PaymentCharge) (PaymentLink, error)
+	// GetStatus asks the provider how an order's payment stands. An order
+	// the buyer never started paying is utils.ErrOrderNotFound.
+	GetStatus(order_id string) (PaymentStatus, error)
+	// Cancel stops an order from being paid. It is
+	// utils.ErrPaymentNotCancellable once the order has been paid.
+	Cancel(order_id string) error
+	// Refund pays amount of an order back. A refund key is only refunded
+	// once, so the request can be repeated.
+	Refund(order_id string, refund_key string, amount float64, reason string) error
+	// VerifyNotification checks that a notification body really came from
+	// the provider and reads the payment status it reports.
+	VerifyNotification(body []byte) (PaymentStatus, error)
+}
+
+// PaymentCharge is an order to be charged.
+type PaymentCharge struct {
+	Order_ID string
+	Amount   float64
+	Name     string
+	Email    string
+}
+
+// PaymentLink is where a buyer pays a charge.
+type PaymentLink struct {
+	Token        string `json:"token"`
+	Redirect_URL string `json:"redirect_url"`
+}
+
+// PaymentStatus is how an order's payment stands at the provider. Status
+// is the transaction status it maps to, or "" when there is nothing to act
+// on; Provider_Status is what the provider called it.
+type PaymentStatus struct {
+	Order_ID        string                  `json:"order_id"`
+	Status          model.TransactionStatus `json:"status"`
+	Provider_Status string                  `json:"provider_status"`
+	Gross_Amount    float64                 `json:"gross_amount"`
+}
+
+// AmountMatches reports whether the provider was paid what was charged for
+// amount. Charges are made in whole rupiah.
+func (p PaymentStatus) AmountMatches(amount float64) bool {
+	return p.Gross_Amount == float64(int(amount))
+}
+
+// NewPaymentGateway returns the gateway the config picks.
+func NewPaymentGateway(cfg config.PaymentConfig) PaymentGateway {
+	if cfg.Provider == config.PaymentFake {
+		return NewFakeGateway()
+	}
+	return NewMidtransGateway(cfg)
+}
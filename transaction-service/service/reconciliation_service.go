@@ -0,0 +1,237 @@
+package service
+
+import (
+	"log"
+	"main/config"
+	"main/model"
+	"main/repository"
+	"main/utils"
+	"sync"
+	"time"
+)
+
+// reconciledStatuses are the statuses of orders checked against the
+// provider: ones it may have been paid for without us hearing, ones we
+// think were paid, and ones that ended before a payment came in.
+var reconciledStatuses = append([]model.TransactionStatus{
+	model.StatusPending,
+	model.StatusCancelled,
+	model.StatusExpired,
+}, model.PaidStatuses...)
+
+// reconciledTopUpStatuses are those of top-ups checked against the
+// provider. Only those charged successfully are: a top-up is only
+// cancelled when its charge could not be created.
+var reconciledTopUpStatuses = []model.TransactionStatus{
+	model.StatusPending,
+	model.StatusPaid,
+}
+
+type ReconciliationService interface {
+	Run() (model.ReconciliationRun, error)
+	GetRuns(limit int) ([]model.ReconciliationRun, error)
+	GetRun(run_id int) (model.ReconciliationRun, error)
+}
+
+type reconciliationService struct {
+	repo      repository.ReconciliationRepository
+	transServ TransactionService
+	payment   PaymentGateway
+	cfg       config.ReconciliationConfig
+	now       func() time.Time
+	running   sync.Mutex
+}
+
+func NewReconciliationService(repo repository.ReconciliationRepository, transServ TransactionService, payment PaymentGateway, cfg config.ReconciliationConfig) ReconciliationService {
+	return &reconciliationService{
+		repo:      repo,
+		transServ: transServ,
+		payment:   payment,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// reconciledOrder is a transaction, checkout or top-up as charged at the
+// provider.
+type reconciledOrder struct {
+	order_id       string
+	transaction_id *uint
+	checkout_id    *uint
+	top_up_id      *uint
+	status         model.TransactionStatus
+	amount         float64
+}
+
+// Run checks every order charged within the lookback against the payment
+// provider and saves what it found as a run. A status the provider moved
+// on to, say because its notification was lost, is applied through the
+// normal transitions, and a payment for an order that has already expired
+// or been cancelled is sent back; other moves they do not allow, and
+// amounts that differ, are left for finance to review. An order already
+// left for review with the same kind of discrepancy is not recorded again.
+// Only one run goes at a time; starting another fails with
+// utils.ErrReconciliationRunning.
+func (s *reconciliationService) Run() (model.ReconciliationRun, error) {
+	if !s.running.TryLock() {
+		return model.ReconciliationRun{}, utils.ErrReconciliationRunning
+	}
+	defer s.running.Unlock()
+
+	run := model.ReconciliationRun{Started_At: s.now()}
+	since := run.Started_At.Add(-s.cfg.Lookback)
+
+	trans, err := s.repo.GetTransactionsToReconcile(since, reconciledStatuses)
+	if err != nil {
+		return model.ReconciliationRun{}, err
+	}
+	checkouts, err := s.repo.GetCheckoutsToReconcile(since, reconciledStatuses)
+	if err != nil {
+		return model.ReconciliationRun{}, err
+	}
+
+	topUps, err := s.repo.GetTopUpsToReconcile(since, reconciledTopUpStatuses)
+	if err != nil {
+		return model.ReconciliationRun{}, err
+	}
+
+	orders := make([]reconciledOrder, 0, len(trans)+len(checkouts)+len(topUps))
+	for _, t := range trans {
+		transaction_id := t.Transaction_ID
+		orders = append(orders, reconciledOrder{t.Order_ID, &transaction_id, nil, nil, t.Status, t.GatewayAmount()})
+	}
+	for _, c := range checkouts {
+		checkout_id := c.Checkout_ID
+		orders = append(orders, reconciledOrder{c.Order_ID, nil, &checkout_id, nil, c.Status, c.Amount})
+	}
+	for _, t := range topUps {
+		top_up_id := t.TopUp_ID
+		orders = append(orders, reconciledOrder{t.Order_ID, nil, nil, &top_up_id, t.Status, t.Amount})
+	}
+
+	for _, order := range orders {
+		run.Checked++
+		discrepancy, err := s.reconcile(order)
+		if err != nil {
+			log.Println("Failed to reconcile order", order.order_id, ":", err)
+			run.Unchecked++
+			continue
+		}
+		if discrepancy == nil {
+			continue
+		}
+		if discrepancy.Resolution == model.ResolutionFixed {
+			run.Fixed++
+		} else if s.alreadyOpen(*discrepancy) {
+			continue
+		}
+		run.Discrepancies = append(run.Discrepancies, *discrepancy)
+	}
+
+	run.Finished_At = s.now()
+	return s.repo.CreateRun(run)
+}
+
+// reconcile compares an order with the provider, and returns the
+// discrepancy, if any, with how it was dealt with.
+func (s *reconciliationService) reconcile(order reconciledOrder) (*model.PaymentDiscrepancy, error) {
+	discrepancy := &model.PaymentDiscrepancy{
+		Order_ID:        order.order_id,
+		Transaction_ID:  order.transaction_id,
+		Checkout_ID:     order.checkout_id,
+		TopUp_ID:        order.top_up_id,
+		Local_Status:    order.status,
+		Expected_Amount: order.amount,
+	}
+
+	payment, err := s.payment.GetStatus(order.order_id)
+	if err == utils.ErrOrderNotFound {
+		// The buyer never started paying, which is only wrong if we
+		// think they did
+		if !isPaid(order.status) {
+			return nil, nil
+		}
+		discrepancy.Kind = model.DiscrepancyMissing
+		discrepancy.Resolution = model.ResolutionReview
+		discrepancy.Note = "paid here but unknown to the provider"
+		return discrepancy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	discrepancy.Provider_Status = payment.Provider_Status
+	discrepancy.Provider_Amount = payment.Gross_Amount
+
+	if !payment.AmountMatches(order.amount) {
+		discrepancy.Kind = model.DiscrepancyAmount
+		discrepancy.Resolution = model.ResolutionReview
+		return discrepancy, nil
+	}
+	if agrees(order.status, payment.Status) {
+		return nil, nil
+	}
+
+	discrepancy.Kind = model.DiscrepancyStatus
+	latePayment := payment.Status == model.StatusPaid && endedUnpaid(order.status)
+	if !order.status.CanTransitionTo(payment.Status) && !latePayment {
+		discrepancy.Resolution = model.ResolutionReview
+		return discrepancy, nil
+	}
+
+	if err := s.transServ.ApplyPayment(payment, model.ActorReconciliation); err != nil {
+		discrepancy.Resolution = model.ResolutionFixFailed
+		discrepancy.Note = err.Error()
+		return discrepancy, nil
+	}
+	discrepancy.Resolution = model.ResolutionFixed
+	if latePayment {
+		discrepancy.Note = "paid after the order " + string(order.status) + "; the payment is being returned"
+	}
+	return discrepancy, nil
+}
+
+// alreadyOpen reports whether an earlier run left the same discrepancy
+// open. If that can not be checked it is recorded again, as a duplicate is
+// better than one finance never sees.
+func (s *reconciliationService) alreadyOpen(discrepancy model.PaymentDiscrepancy) bool {
+	open, err := s.repo.HasOpenDiscrepancy(discrepancy.Order_ID, discrepancy.Kind)
+	if err != nil {
+		log.Println("Failed to look up open discrepancies for order", discrepancy.Order_ID, ":", err)
+		return false
+	}
+	return open
+}
+
+// agrees reports whether our status for an order is consistent with the
+// status the provider reports. A paid order may have moved on along
+// shipping, a provider status we do not act on says nothing, and an order
+// that ended unpaid may have ended differently, or still be pending, at
+// the provider.
+func agrees(local model.TransactionStatus, provider model.TransactionStatus) bool {
+	switch {
+	case provider == "", local == provider:
+		return true
+	case provider == model.StatusPaid:
+		return isPaid(local)
+	case provider == model.StatusPending, provider == model.StatusCancelled, provider == model.StatusExpired:
+		return local == model.StatusCancelled || local == model.StatusExpired
+	}
+	return false
+}
+
+func isPaid(status model.TransactionStatus) bool {
+	for _, paid := range model.PaidStatuses {
+		if status == paid {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *reconciliationService) GetRuns(limit int) ([]model.ReconciliationRun, error) {
+	return s.repo.GetRuns(limit)
+}
+
+func (s *reconciliationService) GetRun(run_id int) (model.ReconciliationRun, error) {
+	return s.repo.GetRun(run_id)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"main/config"
+	"main/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReconciliationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReconciliationRepository) GetTransactionsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.Transaction, error) {
+	args := m.Called(since, statuses)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) GetCheckoutsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.Checkout, error) {
+	args := m.Called(since, statuses)
+	return args.Get(0).([]model.Checkout), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) GetTopUpsToReconcile(since time.Time, statuses []model.TransactionStatus) ([]model.TopUp, error) {
+	args := m.Called(since, statuses)
+	return args.Get(0).([]model.TopUp), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) HasOpenDiscrepancy(order_id string, kind model.DiscrepancyKind) (bool, error) {
+	args := m.Called(order_id, kind)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) CreateRun(run model.ReconciliationRun) (model.ReconciliationRun, error) {
+	args := m.Called(run)
+	return args.Get(0).(model.ReconciliationRun), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) GetRuns(limit int) ([]model.ReconciliationRun, error) {
+	args := m.Called(limit)
+	return args.Get(0).([]model.ReconciliationRun), args.Error(1)
+}
+
+func (m *MockReconciliationRepository) GetRun(run_id int) (model.ReconciliationRun, error) {
+	args := m.Called(run_id)
+	return args.Get(0).(model.ReconciliationRun), args.Error(1)
+}
+
+// setupReconciliation returns a reconciliation service checking trans
+// against a fake provider the transaction was charged at for charged.
+func setupReconciliation(trans model.Transaction, charged float64) (*MockReconciliationRepository, ReconciliationService) {
+	repo := new(MockReconciliationRepository)
+	gateway := NewFakeGateway()
+	gateway.CreateCharge(PaymentCharge{Order_ID: trans.Order_ID, Amount: charged})
+	gateway.Simulate(trans.Order_ID, "settlement")
+
+	repo.On("GetTransactionsToReconcile", mock.Anything, mock.Anything).Return([]model.Transaction{trans}, nil)
+	repo.On("GetCheckoutsToReconcile", mock.Anything, mock.Anything).Return([]model.Checkout{}, nil)
+	repo.On("GetTopUpsToReconcile", mock.Anything, mock.Anything).Return([]model.TopUp{}, nil)
+	repo.On("CreateRun", mock.Anything).Return(model.ReconciliationRun{}, nil)
+
+	transServ := NewTransactionService(new(MockTransactionRepository), new(MockSagaRepository))
+	return repo, NewReconciliationService(repo, transServ, gateway, config.ReconciliationConfig{Lookback: 72 * time.Hour})
+}
+
+func savedRun(repo *MockReconciliationRepository) model.ReconciliationRun {
+	for _, call := range repo.Calls {
+		if call.Method == "CreateRun" {
+			return call.Arguments.Get(0).(model.ReconciliationRun)
+		}
+	}
+	return model.ReconciliationRun{}
+}
+
+func TestReconcile_AmountMismatch(t *testing.T) {
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPaid, Order_ID: "10-1700000000"}
+	repo, service := setupReconciliation(trans, 50000)
+	repo.On("HasOpenDiscrepancy", "10-1700000000", model.DiscrepancyAmount).Return(false, nil)
+
+	_, err := service.Run()
+
+	assert.NoError(t, err)
+	run := savedRun(repo)
+	assert.Equal(t, 1, run.Checked)
+	assert.Len(t, run.Discrepancies, 1)
+	assert.Equal(t, model.DiscrepancyAmount, run.Discrepancies[0].Kind)
+	assert.Equal(t, model.ResolutionReview, run.Discrepancies[0].Resolution)
+}
+
+func TestReconcile_AlreadyOpen(t *testing.T) {
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPaid, Order_ID: "10-1700000000"}
+	repo, service := setupReconciliation(trans, 50000)
+	repo.On("HasOpenDiscrepancy", "10-1700000000", model.DiscrepancyAmount).Return(true, nil)
+
+	_, err := service.Run()
+
+	assert.NoError(t, err)
+	run := savedRun(repo)
+	assert.Equal(t, 1, run.Checked)
+	assert.Empty(t, run.Discrepancies)
+}
+
+func TestReconcile_OpenLookupFails(t *testing.T) {
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPaid, Order_ID: "10-1700000000"}
+	repo, service := setupReconciliation(trans, 50000)
+	repo.On("HasOpenDiscrepancy", "10-1700000000", model.DiscrepancyAmount).Return(false, errors.New("db down"))
+
+	_, err := service.Run()
+
+	assert.NoError(t, err)
+	assert.Len(t, savedRun(repo).Discrepancies, 1)
+}
+
+func TestReconcile_Agrees(t *testing.T) {
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusShipped, Order_ID: "10-1700000000"}
+	repo, service := setupReconciliation(trans, 75000)
+
+	_, err := service.Run()
+
+	assert.NoError(t, err)
+	assert.Empty(t, savedRun(repo).Discrepancies)
+	repo.AssertNotCalled(t, "HasOpenDiscrepancy", mock.Anything, mock.Anything)
+}
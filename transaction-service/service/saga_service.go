@@ -0,0 +1,466 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"main/config"
+	"main/model"
+	"main/repository"
+	"main/utils"
+	"time"
+)
+
+// sagaLease is how long a claimed saga is kept from other workers while
+// it runs.
+const sagaLease = 2 * time.Minute
+
+// SagaAction is what a saga step does and how it is undone. When a step
+// with an Undo can not be done, the steps already done are undone in
+// reverse order. A step without one, like sending a receipt, is not worth
+// undoing everything else for: when it can not be done the saga fails for
+// someone to look at instead.
+type SagaAction struct {
+	Do   func(trans model.Transaction) error
+	Undo func(trans model.Transaction) error
+}
+
+// FulfilmentActions are the steps run once a transaction is paid: deduct
+// its stock, credit its seller and email the buyer a receipt. A step can
+// be run again after it already took effect, when its reply was lost, so
+// every call carries a key the other service recognises repeats by.
+func FulfilmentActions() map[string]SagaAction {
+	return map[string]SagaAction{
+		model.StepConfirmStock: {
+			Do: utils.ConfirmStock,
+			Undo: func(trans model.Transaction) error {
+				if len(trans.Items) == 0 {
+					return nil
+				}
+				return utils.RestockStock(fmt.Sprintf("transaction-%d-restock", trans.Transaction_ID), trans.Items)
+			},
+		},
+		model.StepCreditSeller: {
+			Do: func(trans model.Transaction) error {
+				seller_id, err := utils.SellerOf(trans)
+				if err != nil {
+					return err
+				}
+				return utils.UpdateBalance(seller_id, trans.Amount, fmt.Sprintf("transaction-%d-credit", trans.Transaction_ID))
+			},
+			Undo: func(trans model.Transaction) error {
+				seller_id, err := utils.SellerOf(trans)
+				if err != nil {
+					return err
+				}
+				return utils.DebitBalance(seller_id, trans.Amount, fmt.Sprintf("transaction-%d-credit-reversal", trans.Transaction_ID))
+			},
+		},
+		model.StepSendReceipt: {
+			Do: utils.EmailTransaction,
+		},
+	}
+}
+
+// RefundActions are the steps run once a transaction is refunded: send the
+// money back, restore the stock and reverse the seller's credit when its
+// fulfilment got that far, and email the buyer. Once money is on its way
+// back there is nothing to undo, so a step that keeps failing fails the
+// saga for an admin to look at.
+func RefundActions(transRepo repository.TransactionRepository, payment PaymentGateway) map[string]SagaAction {
+	return map[string]SagaAction{
+		model.StepRefundPayment: {
+			Do: func(trans model.Transaction) error {
+				refund, err := transRepo.GetRefund(int(trans.Transaction_ID))
+				if err != nil {
+					return err
+				}
+				key := fmt.Sprintf("transaction-%d-refund", trans.Transaction_ID)
+				if refund.Method == model.RefundToWallet {
+					return utils.UpdateBalance(trans.User_ID, refund.Amount, key)
+				}
+
+				// Back to how it was paid: the wallet part to the wallet,
+				// and the rest through Midtrans unless it was refunded there
+				if trans.Wallet_Amount > 0 {
+					if err := utils.UpdateBalance(trans.User_ID, trans.Wallet_Amount, key+"-wallet"); err != nil {
+						return err
+					}
+				}
+				if refund.At_Provider || trans.GatewayAmount() <= 0 {
+					return nil
+				}
+
+				order_id, err := chargedOrderID(transRepo, trans)
+				if err != nil {
+					return err
+				}
+				return payment.Refund(order_id, key, trans.GatewayAmount(), refund.Reason)
+			},
+		},
+		model.StepRestoreStock: {
+			Do: func(trans model.Transaction) error {
+				refund, err := transRepo.GetRefund(int(trans.Transaction_ID))
+				if err != nil || !refund.Restore_Stock || len(trans.Items) == 0 {
+					return err
+				}
+				return utils.RestockStock(fmt.Sprintf("transaction-%d-refund-restock", trans.Transaction_ID), trans.Items)
+			},
+		},
+		model.StepReverseCredit: {
+			Do: func(trans model.Transaction) error {
+				refund, err := transRepo.GetRefund(int(trans.Transaction_ID))
+				if err != nil || !refund.Reverse_Credit {
+					return err
+				}
+				seller_id, err := utils.SellerOf(trans)
+				if err != nil {
+					return err
+				}
+				return utils.DebitBalance(seller_id, trans.Amount, fmt.Sprintf("transaction-%d-refund-debit", trans.Transaction_ID))
+			},
+		},
+		model.StepSendRefundReceipt: {
+			Do: func(trans model.Transaction) error {
+				refund, err := transRepo.GetRefund(int(trans.Transaction_ID))
+				if err != nil {
+					return err
+				}
+				return utils.EmailRefund(trans, refund)
+			},
+		},
+	}
+}
+
+// LatePaymentActions are the steps run when a transaction is paid after it
+// was cancelled or expired: the payment is sent back through the provider.
+// The wallet part, if any, was returned when the transaction ended, and its
+// stock was released, so there is nothing else to undo.
+func LatePaymentActions(transRepo repository.TransactionRepository, payment PaymentGateway) map[string]SagaAction {
+	return map[string]SagaAction{
+		model.StepReturnLatePayment: {
+			Do: func(trans model.Transaction) error {
+				if trans.GatewayAmount() <= 0 {
+					return nil
+				}
+				order_id, err := chargedOrderID(transRepo, trans)
+				if err != nil {
+					return err
+				}
+				key := fmt.Sprintf("transaction-%d-late-payment", trans.Transaction_ID)
+				return payment.Refund(order_id, key, trans.GatewayAmount(), "paid after the order "+string(trans.Status))
+			},
+		},
+	}
+}
+
+// chargedOrderID is the provider order the transaction was paid under:
+// its checkout's when it was bought in one.
+func chargedOrderID(transRepo repository.TransactionRepository, trans model.Transaction) (string, error) {
+	if trans.Checkout_ID == nil {
+		return trans.Order_ID, nil
+	}
+	checkout, err := transRepo.GetCheckoutByID(int(*trans.Checkout_ID))
+	if err != nil {
+		return "", err
+	}
+	return checkout.Order_ID, nil
+}
+
+type SagaService interface {
+	Start(kind model.SagaKind, trans model.Transaction) (model.Saga, error)
+	GetByTransaction(transaction_id int, kind model.SagaKind) (model.Saga, error)
+	Run(saga_id int) (model.Saga, error)
+	RunDue() int
+	GetStuck() ([]model.Saga, error)
+	Retry(saga_id int) (model.Saga, error)
+	Resolve(saga_id int, admin_id int, resolution string) (model.Saga, error)
+}
+
+type sagaService struct {
+	repo      repository.SagaRepository
+	transRepo repository.TransactionRepository
+	actions   map[model.SagaKind]map[string]SagaAction
+	cfg       config.SagaConfig
+	now       func() time.Time
+}
+
+// NewSagaService creates the service running sagas, with the actions of
+// the steps of each kind of saga.
+func NewSagaService(repo repository.SagaRepository, transRepo repository.TransactionRepository, actions map[model.SagaKind]map[string]SagaAction, cfg config.SagaConfig) SagaService {
+	return &sagaService{
+		repo:      repo,
+		transRepo: transRepo,
+		actions:   actions,
+		cfg:       cfg,
+		now:       time.Now,
+	}
+}
+
+// Start creates the transaction's saga of the given kind and runs it as far
+// as it gets. Starting it again only runs it if it is due.
+func (s *sagaService) Start(kind model.SagaKind, trans model.Transaction) (model.Saga, error) {
+	saga, err := s.repo.Create(model.NewSaga(trans.Transaction_ID, kind, s.now()))
+	if err != nil {
+		return model.Saga{}, err
+	}
+	return s.Run(int(saga.ID))
+}
+
+func (s *sagaService) GetByTransaction(transaction_id int, kind model.SagaKind) (model.Saga, error) {
+	return s.repo.GetByTransaction(transaction_id, kind)
+}
+
+// Run claims the saga and takes it as far as it can go now. A saga that is
+// not due, or that another worker is running, is returned as it is.
+func (s *sagaService) Run(saga_id int) (model.Saga, error) {
+	now := s.now()
+	claimed, err := s.repo.Claim(saga_id, now, now.Add(sagaLease))
+	if err != nil {
+		return model.Saga{}, err
+	}
+	saga, err := s.repo.GetByID(saga_id)
+	if err != nil || !claimed {
+		return saga, err
+	}
+
+	trans, err := s.transRepo.GetTransactionWithDeleted(int(saga.Transaction_ID))
+	if err != nil {
+		next := now.Add(s.cfg.RetryBase)
+		saga.Next_Attempt_At = &next
+		saga.Last_Error = err.Error()
+	} else {
+		s.advance(&saga, trans)
+	}
+
+	if err := s.repo.Save(saga); err != nil {
+		return model.Saga{}, err
+	}
+	return saga, nil
+}
+
+// advance runs the saga's remaining steps in order, or undoes its done
+// steps in reverse order while it compensates, stopping at the first one
+// that has to be tried again later. A fulfilment is only compensated once
+// its transaction has been refunded as well, so the buyer is not left
+// without the book or their money.
+func (s *sagaService) advance(saga *model.Saga, trans model.Transaction) {
+	actions := s.actions[saga.Kind]
+	if saga.Status == model.SagaRunning {
+		for i := range saga.Steps {
+			step := &saga.Steps[i]
+			if step.Status == model.StepDone {
+				continue
+			}
+
+			action := actions[step.Name]
+			done, exhausted := s.try(saga, step, action.Do, trans)
+			if !done {
+				if !exhausted {
+					return
+				}
+				step.Status = model.StepFailed
+				if action.Undo == nil {
+					s.finish(saga, model.SagaFailed)
+					return
+				}
+				s.startCompensating(saga)
+				break
+			}
+			step.Status = model.StepDone
+		}
+		if saga.Status == model.SagaRunning {
+			saga.Last_Error = ""
+			s.finish(saga, model.SagaCompleted)
+			return
+		}
+	}
+
+	if saga.Status == model.SagaCompensating {
+		for i := len(saga.Steps) - 1; i >= 0; i-- {
+			step := &saga.Steps[i]
+			action := actions[step.Name]
+			if step.Status != model.StepDone || action.Undo == nil {
+				continue
+			}
+
+			done, exhausted := s.try(saga, step, action.Undo, trans)
+			if !done {
+				if exhausted {
+					s.finish(saga, model.SagaFailed)
+				}
+				return
+			}
+			step.Status = model.StepCompensated
+		}
+		if saga.Kind == model.SagaFulfilment {
+			if err := s.refundBuyer(trans); err != nil {
+				saga.Last_Error = "refund: " + err.Error()
+				next := s.now().Add(s.cfg.RetryBase)
+				saga.Next_Attempt_At = &next
+				return
+			}
+		}
+		s.finish(saga, model.SagaCompensated)
+	}
+}
+
+// refundBuyer refunds a transaction whose fulfilment was undone, back to
+// how it was paid. Its refund saga sends the money back and emails the
+// buyer; the stock and the seller's credit were already undone with the
+// fulfilment, so the refund leaves them be. A transaction refunded
+// already, say on an earlier run, is left as it is.
+func (s *sagaService) refundBuyer(trans model.Transaction) error {
+	if !model.ActorSystem.MayMove(trans.Status, model.StatusRefunded) {
+		return nil
+	}
+
+	reason := "the order could not be fulfilled"
+	refund := model.Refund{
+		Transaction_ID: trans.Transaction_ID,
+		Method:         model.RefundToOriginal,
+		Amount:         trans.Amount,
+		Reason:         reason,
+		Requested_By:   model.ActorSystem.Role,
+	}
+	entry := model.NewStatusHistory(trans.Transaction_ID, trans.Status, model.StatusRefunded, model.ActorSystem, reason)
+	changed, err := s.transRepo.RefundTransaction(trans, entry, refund)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		// Moved on since it was read, so look again on the next run
+		return utils.ErrInvalidTransition
+	}
+	return nil
+}
+
+// try runs fn for the step once. When it fails the saga is scheduled to
+// try again after a backoff, unless the step has used up its attempts.
+func (s *sagaService) try(saga *model.Saga, step *model.SagaStep, fn func(model.Transaction) error, trans model.Transaction) (done bool, exhausted bool) {
+	step.Attempts++
+	if err := fn(trans); err != nil {
+		step.Last_Error = err.Error()
+		saga.Last_Error = step.Name + ": " + err.Error()
+		if step.Attempts >= s.cfg.MaxAttempts {
+			return false, true
+		}
+		next := s.now().Add(s.backoff(step.Attempts))
+		saga.Next_Attempt_At = &next
+		return false, false
+	}
+
+	step.Last_Error = ""
+	return true, false
+}
+
+// backoff doubles the wait after each failed attempt, up to RetryMax.
+func (s *sagaService) backoff(attempts int) time.Duration {
+	return retryBackoff(s.cfg.RetryBase, s.cfg.RetryMax, attempts)
+}
+
+// retryBackoff is how long to wait after the given number of failed
+// attempts: base after the first, doubling each time up to max.
+func retryBackoff(base time.Duration, max time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// startCompensating switches the saga to undoing its done steps, each with
+// a fresh set of attempts.
+func (s *sagaService) startCompensating(saga *model.Saga) {
+	saga.Status = model.SagaCompensating
+	for i := range saga.Steps {
+		if saga.Steps[i].Status == model.StepDone {
+			saga.Steps[i].Attempts = 0
+		}
+	}
+}
+
+func (s *sagaService) finish(saga *model.Saga, status model.SagaStatus) {
+	saga.Status = status
+	saga.Next_Attempt_At = nil
+}
+
+// RunDue runs every saga that is due and returns how many it ran.
+func (s *sagaService) RunDue() int {
+	sagas, err := s.repo.GetDue(s.now(), 100)
+	if err != nil {
+		log.Println("Failed to load due sagas:", err)
+		return 0
+	}
+
+	for _, saga := range sagas {
+		if _, err := s.Run(int(saga.ID)); err != nil {
+			log.Println("Failed to run saga", saga.ID, ":", err)
+		}
+	}
+	return len(sagas)
+}
+
+// GetStuck lists the sagas that need someone to resolve them.
+func (s *sagaService) GetStuck() ([]model.Saga, error) {
+	return s.repo.GetStuck(s.now().Add(-s.cfg.StuckAfter))
+}
+
+// Retry gives a failed saga a fresh set of attempts from where it stopped:
+// the failed step if it failed while running, or the undoing of its done
+// steps if it failed while compensating. A saga still going is just made
+// due now.
+func (s *sagaService) Retry(saga_id int) (model.Saga, error) {
+	saga, err := s.repo.GetByID(saga_id)
+	if err != nil {
+		return model.Saga{}, err
+	}
+
+	switch saga.Status {
+	case model.SagaRunning, model.SagaCompensating:
+	case model.SagaFailed:
+		saga.Status = model.SagaCompensating
+		for i := range saga.Steps {
+			if saga.Steps[i].Status == model.StepFailed {
+				saga.Status = model.SagaRunning
+				saga.Steps[i].Status = model.StepPending
+				saga.Steps[i].Attempts = 0
+			}
+		}
+		if saga.Status == model.SagaCompensating {
+			s.startCompensating(&saga)
+		}
+	default:
+		return model.Saga{}, utils.ErrSagaState
+	}
+
+	now := s.now()
+	saga.Next_Attempt_At = &now
+	if err := s.repo.Save(saga); err != nil {
+		return model.Saga{}, err
+	}
+	return s.Run(saga_id)
+}
+
+// Resolve records that someone dealt with a failed or compensated saga by
+// hand, taking it off the stuck list.
+func (s *sagaService) Resolve(saga_id int, admin_id int, resolution string) (model.Saga, error) {
+	saga, err := s.repo.GetByID(saga_id)
+	if err != nil {
+		return model.Saga{}, err
+	}
+	if saga.Resolved_At != nil || (saga.Status != model.SagaFailed && saga.Status != model.SagaCompensated) {
+		return model.Saga{}, utils.ErrSagaState
+	}
+
+	now := s.now()
+	saga.Resolution = resolution
+	saga.Resolved_By = admin_id
+	saga.Resolved_At = &now
+	if err := s.repo.Save(saga); err != nil {
+		return model.Saga{}, err
+	}
+	return saga, nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"main/config"
+	"main/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupFulfilment returns a saga service running a fulfilment whose seller
+// can not be credited, so the stock it confirmed is given back.
+func setupFulfilment(trans model.Transaction) (*MockTransactionRepository, *MockSagaRepository, SagaService) {
+	repo := new(MockTransactionRepository)
+	sagaRepo := new(MockSagaRepository)
+	actions := map[model.SagaKind]map[string]SagaAction{
+		model.SagaFulfilment: {
+			model.StepConfirmStock: {
+				Do:   func(model.Transaction) error { return nil },
+				Undo: func(model.Transaction) error { return nil },
+			},
+			model.StepCreditSeller: {
+				Do:   func(model.Transaction) error { return errors.New("auth-service down") },
+				Undo: func(model.Transaction) error { return nil },
+			},
+			model.StepSendReceipt: {
+				Do: func(model.Transaction) error { return nil },
+			},
+		},
+	}
+
+	saga := model.NewSaga(trans.Transaction_ID, model.SagaFulfilment, time.Now())
+	saga.ID = 1
+	sagaRepo.On("Claim", 1, mock.Anything, mock.Anything).Return(true, nil)
+	sagaRepo.On("GetByID", 1).Return(saga, nil)
+	sagaRepo.On("Save", mock.Anything).Return(nil)
+	repo.On("GetTransactionWithDeleted", int(trans.Transaction_ID)).Return(trans, nil)
+
+	cfg := config.SagaConfig{RetryBase: time.Second, RetryMax: time.Minute, MaxAttempts: 1}
+	return repo, sagaRepo, NewSagaService(sagaRepo, repo, actions, cfg)
+}
+
+func TestFulfilmentCompensated_RefundsBuyer(t *testing.T) {
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPaid}
+	repo, _, service := setupFulfilment(trans)
+	repo.On("RefundTransaction", trans, movesTo(model.StatusRefunded), mock.MatchedBy(func(refund model.Refund) bool {
+		return refund.Method == model.RefundToOriginal && refund.Amount == 75000 &&
+			!refund.Restore_Stock && !refund.Reverse_Credit
+	})).Return(true, nil)
+
+	saga, err := service.Run(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.SagaCompensated, saga.Status)
+	assert.Equal(t, model.StepCompensated, saga.Steps[0].Status)
+	repo.AssertExpectations(t)
+}
+
+func TestFulfilmentCompensated_RefundRetried(t *testing.T) {
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPaid}
+	repo, _, service := setupFulfilment(trans)
+	repo.On("RefundTransaction", trans, mock.Anything, mock.Anything).Return(false, errors.New("db down"))
+
+	saga, err := service.Run(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.SagaCompensating, saga.Status)
+	assert.NotNil(t, saga.Next_Attempt_At)
+	assert.Contains(t, saga.Last_Error, "refund")
+}
+
+func TestFulfilmentCompensated_AlreadyRefunded(t *testing.T) {
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusRefunded}
+	repo, _, service := setupFulfilment(trans)
+
+	saga, err := service.Run(1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.SagaCompensated, saga.Status)
+	repo.AssertNotCalled(t, "RefundTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
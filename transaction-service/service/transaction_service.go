@@ -1,23 +1,43 @@
 package service
 
 import (
+	"fmt"
+	"log"
 	"main/model"
 	"main/repository"
+	"main/utils"
+	"time"
 )
 
 type TransactionService interface {
 	CreateTransaction(user_id int, t model.Transaction) (model.Transaction, error)
 	GetTransaction(user_id int) ([]model.Transaction, error)
-	UpdateTransactionStatus(transaction_id int) (model.Transaction, error)
 	GetTransactionByID(transaction_id int) (model.Transaction, error)
+	Transition(transaction_id int, to model.TransactionStatus, actor model.Actor, reason string) (model.Transaction, error)
+	Refund(transaction_id int, actor model.Actor, method model.RefundMethod, reason string) (model.Transaction, error)
+	ApplyPayment(payment PaymentStatus, actor model.Actor) error
+	GetTimeline(transaction_id int) ([]model.TransactionStatusHistory, error)
+	SetOrderID(transaction_id int, order_id string) error
+	GetDailySales(from time.Time, to time.Time) ([]model.DailySales, error)
+	CreateCheckout(user_id int, checkout model.Checkout) (model.Checkout, error)
+	GetCheckoutByID(checkout_id int) (model.Checkout, error)
+	TransitionCheckoutStatus(checkout_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error)
+	SetCheckoutOrderID(checkout_id int, order_id string) error
+	CreateTopUp(user_id int, amount float64) (model.TopUp, error)
+	GetTopUpByID(top_up_id int) (model.TopUp, error)
+	TransitionTopUpStatus(top_up_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error)
+	SetTopUpOrderID(top_up_id int, order_id string) error
 }
 
 type transactionService struct {
-	repo repository.TransactionRepository
+	repo     repository.TransactionRepository
+	sagaRepo repository.SagaRepository
+	// credit adds to a user's balance in auth-service; replaced in tests.
+	credit func(user_id int, amount float64, idempotency_key string) error
 }
 
-func NewTransactionService(repo repository.TransactionRepository) TransactionService {
-	return &transactionService{repo: repo}
+func NewTransactionService(repo repository.TransactionRepository, sagaRepo repository.SagaRepository) TransactionService {
+	return &transactionService{repo: repo, sagaRepo: sagaRepo, credit: utils.UpdateBalance}
 }
 
 func (s *transactionService) CreateTransaction(user_id int, t model.Transaction) (model.Transaction, error) {
@@ -36,18 +56,285 @@ func (s *transactionService) GetTransaction(user_id int) ([]model.Transaction, e
 	return trans, nil
 }
 
-func (s *transactionService) UpdateTransactionStatus(transaction_id int) (model.Transaction, error) {
-	trans, err := s.repo.UpdateTransactionStatus(transaction_id)
+func (s *transactionService) GetTransactionByID(transaction_id int) (model.Transaction, error) {
+	trans, err := s.repo.GetTransactionByID(transaction_id)
 	if err != nil {
 		return model.Transaction{}, err
 	}
 	return trans, nil
 }
 
-func (s *transactionService) GetTransactionByID(transaction_id int) (model.Transaction, error) {
+// Transition moves a transaction to status to on behalf of actor. Only the
+// moves the state machine allows are made; any other, including one to the
+// status it is already in, fails with ErrInvalidTransition, as does losing
+// a race with another change to the same transaction. A move the actor may
+// not make fails with ErrUserForbidden.
+func (s *transactionService) Transition(transaction_id int, to model.TransactionStatus, actor model.Actor, reason string) (model.Transaction, error) {
+	trans, err := s.repo.GetTransactionByID(transaction_id)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if !trans.Status.CanTransitionTo(to) {
+		return model.Transaction{}, utils.ErrInvalidTransition
+	}
+	if !actor.MayMove(trans.Status, to) {
+		return model.Transaction{}, utils.ErrUserForbidden
+	}
+
+	entry := model.NewStatusHistory(trans.Transaction_ID, trans.Status, to, actor, reason)
+	changed, err := s.repo.TransitionStatus(trans, entry)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if !changed {
+		return model.Transaction{}, utils.ErrInvalidTransition
+	}
+
+	trans.Status = to
+	return trans, nil
+}
+
+// Refund moves a paid transaction to refunded on behalf of actor, to send
+// its money back by method; its refund saga then does so. Its buyer can
+// only refund it before it ships, failing with ErrRefundNeedsApproval
+// after; from then on its seller or an admin refunds it. A payment still
+// being processed can not be refunded yet, as what the refund undoes is
+// taken from what the transaction's fulfilment saga got done. A refund
+// Midtrans reports was made at the provider, so only the rest is undone.
+func (s *transactionService) Refund(transaction_id int, actor model.Actor, method model.RefundMethod, reason string) (model.Transaction, error) {
 	trans, err := s.repo.GetTransactionByID(transaction_id)
 	if err != nil {
 		return model.Transaction{}, err
 	}
+	if !trans.Status.CanTransitionTo(model.StatusRefunded) {
+		return model.Transaction{}, utils.ErrInvalidTransition
+	}
+	if !actor.MayMove(trans.Status, model.StatusRefunded) {
+		if actor.Role == model.RoleBuyer {
+			return model.Transaction{}, utils.ErrRefundNeedsApproval
+		}
+		return model.Transaction{}, utils.ErrUserForbidden
+	}
+
+	fulfilment, err := s.sagaRepo.GetByTransaction(transaction_id, model.SagaFulfilment)
+	if err != nil {
+		return model.Transaction{}, utils.ErrRefundNotReady
+	}
+	switch {
+	case fulfilment.Status == model.SagaCompleted, fulfilment.Status == model.SagaCompensated:
+	case fulfilment.Status == model.SagaFailed && fulfilment.Resolved_At != nil:
+	default:
+		return model.Transaction{}, utils.ErrRefundNotReady
+	}
+
+	refund := model.Refund{
+		Transaction_ID: trans.Transaction_ID,
+		Method:         method,
+		Amount:         trans.Amount,
+		Reason:         reason,
+		Requested_By:   actor.Role,
+		Requester_ID:   actor.ID,
+		Restore_Stock:  fulfilment.HasDone(model.StepConfirmStock),
+		Reverse_Credit: fulfilment.HasDone(model.StepCreditSeller),
+		At_Provider:    actor.AtProvider(),
+	}
+	entry := model.NewStatusHistory(trans.Transaction_ID, trans.Status, model.StatusRefunded, actor, reason)
+	changed, err := s.repo.RefundTransaction(trans, entry, refund)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+	if !changed {
+		return model.Transaction{}, utils.ErrInvalidTransition
+	}
+
+	trans.Status = model.StatusRefunded
+	trans.Refund = &refund
 	return trans, nil
 }
+
+// ApplyPayment applies a payment status the provider reported, through
+// actor, to the transaction, checkout or top-up it was charged for. Moves the
+// state machine does not allow, such as repeats, are ignored. What follows
+// from the move is written to the outbox with it: once paid, the
+// transaction's saga deducts the stock, credits the seller and sends the
+// receipt, and an unpaid transaction that ends gives its stock back. A
+// refund made at the provider is recorded as one, so the rest of it is
+// undone too; until the payment has been processed it fails with
+// utils.ErrRefundNotReady, to be applied again later.
+func (s *transactionService) ApplyPayment(payment PaymentStatus, actor model.Actor) error {
+	kind, id, err := utils.ParseOrderID(payment.Order_ID)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case utils.OrderTopUp:
+		topUp, err := s.repo.GetTopUpByID(id)
+		if err != nil || topUp.Order_ID != payment.Order_ID {
+			return utils.ErrOrderNotFound
+		}
+		if !payment.AmountMatches(topUp.Amount) {
+			return utils.ErrBadReq
+		}
+		return s.applyTopUpStatus(topUp, payment.Status)
+	case utils.OrderCheckout:
+		checkout, err := s.repo.GetCheckoutByID(id)
+		if err != nil || checkout.Order_ID != payment.Order_ID {
+			return utils.ErrOrderNotFound
+		}
+		if !payment.AmountMatches(checkout.Amount) {
+			return utils.ErrBadReq
+		}
+		return s.applyCheckoutStatus(checkout, payment.Status, actor, payment.Provider_Status)
+	}
+
+	trans, err := s.repo.GetTransactionWithDeleted(id)
+	if err != nil || (trans.Order_ID != "" && trans.Order_ID != payment.Order_ID) {
+		return utils.ErrOrderNotFound
+	}
+	if !payment.AmountMatches(trans.GatewayAmount()) {
+		return utils.ErrBadReq
+	}
+	return s.applyStatus(trans, payment.Status, actor, payment.Provider_Status)
+}
+
+// applyTopUpStatus credits a pending top-up once it is paid, or ends it
+// if the payment failed. The balance is credited before the top-up is
+// marked paid, under a key of its own, so a notification retried after a
+// failure credits it exactly once. Anything else, such as a repeat, is
+// ignored, except a refund made at the provider: the money may already
+// have been spent from the wallet, so it is left to finance.
+func (s *transactionService) applyTopUpStatus(topUp model.TopUp, status model.TransactionStatus) error {
+	if topUp.Status != model.StatusPending {
+		if status == model.StatusRefunded && topUp.Status == model.StatusPaid {
+			return utils.ErrTopUpNotRefundable
+		}
+		return nil
+	}
+
+	switch status {
+	case model.StatusPaid:
+		key := fmt.Sprintf("topup-%d-credit", topUp.TopUp_ID)
+		if err := s.credit(topUp.User_ID, topUp.Amount, key); err != nil {
+			return err
+		}
+	case model.StatusCancelled, model.StatusExpired:
+	default:
+		return nil
+	}
+	_, err := s.repo.TransitionTopUpStatus(int(topUp.TopUp_ID), model.StatusPending, status)
+	return err
+}
+
+func (s *transactionService) applyStatus(trans model.Transaction, status model.TransactionStatus, actor model.Actor, provider_status string) error {
+	if status == "" || status == model.StatusPending {
+		return nil
+	}
+	if status == model.StatusPaid && endedUnpaid(trans.Status) {
+		return s.returnLatePayment(trans)
+	}
+
+	var err error
+	reason := actor.Role + ": " + provider_status
+	if status == model.StatusRefunded {
+		_, err = s.Refund(int(trans.Transaction_ID), actor, model.RefundToOriginal, reason)
+	} else {
+		_, err = s.Transition(int(trans.Transaction_ID), status, actor, reason)
+	}
+	if err == utils.ErrInvalidTransition {
+		// It may have ended since it was read
+		if status == model.StatusPaid {
+			current, getErr := s.repo.GetTransactionWithDeleted(int(trans.Transaction_ID))
+			if getErr == nil && endedUnpaid(current.Status) {
+				return s.returnLatePayment(current)
+			}
+		}
+		return nil
+	}
+	return err
+}
+
+// returnLatePayment starts the saga sending back a payment that came in
+// after the transaction was cancelled or expired; the saga job runs it.
+// A repeated notification finds the saga already there and starts nothing.
+func (s *transactionService) returnLatePayment(trans model.Transaction) error {
+	saga, err := s.sagaRepo.Create(model.NewSaga(trans.Transaction_ID, model.SagaLatePayment, time.Now()))
+	if err != nil {
+		return err
+	}
+	log.Printf("Transaction %d was paid after it %s; returning the payment in saga %d", trans.Transaction_ID, trans.Status, saga.ID)
+	return nil
+}
+
+func endedUnpaid(status model.TransactionStatus) bool {
+	return status == model.StatusCancelled || status == model.StatusExpired
+}
+
+// applyCheckoutStatus moves every transaction in a checkout, and then the
+// checkout itself, to the status reported for its single payment. The
+// checkout moves last so that, if applying a transaction fails, the
+// checkout is left where it was and a retried notification finishes the
+// job; a checkout that already reached the status has its transactions
+// applied again, which changes nothing once they are all there.
+func (s *transactionService) applyCheckoutStatus(checkout model.Checkout, status model.TransactionStatus, actor model.Actor, provider_status string) error {
+	latePayment := status == model.StatusPaid && endedUnpaid(checkout.Status)
+	if checkout.Status != status && !checkout.Status.CanTransitionTo(status) && !latePayment {
+		return nil
+	}
+
+	for _, trans := range checkout.Transactions {
+		if err := s.applyStatus(trans, status, actor, provider_status); err != nil {
+			return err
+		}
+	}
+
+	if checkout.Status == status || latePayment {
+		return nil
+	}
+	_, err := s.repo.TransitionCheckoutStatus(int(checkout.Checkout_ID), checkout.Status, status)
+	return err
+}
+
+func (s *transactionService) GetTimeline(transaction_id int) ([]model.TransactionStatusHistory, error) {
+	return s.repo.GetStatusHistory(transaction_id)
+}
+
+func (s *transactionService) SetOrderID(transaction_id int, order_id string) error {
+	return s.repo.SetOrderID(transaction_id, order_id)
+}
+
+func (s *transactionService) GetDailySales(from time.Time, to time.Time) ([]model.DailySales, error) {
+	return s.repo.GetDailySales(from, to)
+}
+
+func (s *transactionService) CreateCheckout(user_id int, checkout model.Checkout) (model.Checkout, error) {
+	return s.repo.CreateCheckout(user_id, checkout)
+}
+
+func (s *transactionService) GetCheckoutByID(checkout_id int) (model.Checkout, error) {
+	return s.repo.GetCheckoutByID(checkout_id)
+}
+
+func (s *transactionService) TransitionCheckoutStatus(checkout_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error) {
+	return s.repo.TransitionCheckoutStatus(checkout_id, from, to)
+}
+
+func (s *transactionService) SetCheckoutOrderID(checkout_id int, order_id string) error {
+	return s.repo.SetCheckoutOrderID(checkout_id, order_id)
+}
+
+func (s *transactionService) CreateTopUp(user_id int, amount float64) (model.TopUp, error) {
+	return s.repo.CreateTopUp(model.TopUp{User_ID: user_id, Amount: amount})
+}
+
+func (s *transactionService) GetTopUpByID(top_up_id int) (model.TopUp, error) {
+	return s.repo.GetTopUpByID(top_up_id)
+}
+
+func (s *transactionService) TransitionTopUpStatus(top_up_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error) {
+	return s.repo.TransitionTopUpStatus(top_up_id, from, to)
+}
+
+func (s *transactionService) SetTopUpOrderID(top_up_id int, order_id string) error {
+	return s.repo.SetTopUpOrderID(top_up_id, order_id)
+}
@@ -0,0 +1,492 @@
+package service
+
+import (
+	"errors"
+	"main/model"
+	"main/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTransactionRepository struct {
+	mock.Mock
+}
+
+func (m *MockTransactionRepository) CreateTransaction(user_id int, t model.Transaction) (model.Transaction, error) {
+	args := m.Called(user_id, t)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetTransaction(user_id int) ([]model.Transaction, error) {
+	args := m.Called(user_id)
+	return args.Get(0).([]model.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetTransactionByID(transaction_id int) (model.Transaction, error) {
+	args := m.Called(transaction_id)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetTransactionWithDeleted(transaction_id int) (model.Transaction, error) {
+	args := m.Called(transaction_id)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+func (m *MockTransactionRepository) TransitionStatus(trans model.Transaction, entry model.TransactionStatusHistory) (bool, error) {
+	args := m.Called(trans, entry)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTransactionRepository) RefundTransaction(trans model.Transaction, entry model.TransactionStatusHistory, refund model.Refund) (bool, error) {
+	args := m.Called(trans, entry, refund)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetRefund(transaction_id int) (model.Refund, error) {
+	args := m.Called(transaction_id)
+	return args.Get(0).(model.Refund), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetStatusHistory(transaction_id int) ([]model.TransactionStatusHistory, error) {
+	args := m.Called(transaction_id)
+	return args.Get(0).([]model.TransactionStatusHistory), args.Error(1)
+}
+
+func (m *MockTransactionRepository) SetOrderID(transaction_id int, order_id string) error {
+	args := m.Called(transaction_id, order_id)
+	return args.Error(0)
+}
+
+func (m *MockTransactionRepository) GetDailySales(from time.Time, to time.Time) ([]model.DailySales, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]model.DailySales), args.Error(1)
+}
+
+func (m *MockTransactionRepository) CreateCheckout(user_id int, checkout model.Checkout) (model.Checkout, error) {
+	args := m.Called(user_id, checkout)
+	return args.Get(0).(model.Checkout), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetCheckoutByID(checkout_id int) (model.Checkout, error) {
+	args := m.Called(checkout_id)
+	return args.Get(0).(model.Checkout), args.Error(1)
+}
+
+func (m *MockTransactionRepository) TransitionCheckoutStatus(checkout_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error) {
+	args := m.Called(checkout_id, from, to)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTransactionRepository) SetCheckoutOrderID(checkout_id int, order_id string) error {
+	args := m.Called(checkout_id, order_id)
+	return args.Error(0)
+}
+
+func (m *MockTransactionRepository) CreateTopUp(topUp model.TopUp) (model.TopUp, error) {
+	args := m.Called(topUp)
+	return args.Get(0).(model.TopUp), args.Error(1)
+}
+
+func (m *MockTransactionRepository) GetTopUpByID(top_up_id int) (model.TopUp, error) {
+	args := m.Called(top_up_id)
+	return args.Get(0).(model.TopUp), args.Error(1)
+}
+
+func (m *MockTransactionRepository) TransitionTopUpStatus(top_up_id int, from model.TransactionStatus, to model.TransactionStatus) (bool, error) {
+	args := m.Called(top_up_id, from, to)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTransactionRepository) SetTopUpOrderID(top_up_id int, order_id string) error {
+	args := m.Called(top_up_id, order_id)
+	return args.Error(0)
+}
+
+type MockSagaRepository struct {
+	mock.Mock
+}
+
+func (m *MockSagaRepository) Create(saga model.Saga) (model.Saga, error) {
+	args := m.Called(saga)
+	return args.Get(0).(model.Saga), args.Error(1)
+}
+
+func (m *MockSagaRepository) GetByID(saga_id int) (model.Saga, error) {
+	args := m.Called(saga_id)
+	return args.Get(0).(model.Saga), args.Error(1)
+}
+
+func (m *MockSagaRepository) GetByTransaction(transaction_id int, kind model.SagaKind) (model.Saga, error) {
+	args := m.Called(transaction_id, kind)
+	return args.Get(0).(model.Saga), args.Error(1)
+}
+
+func (m *MockSagaRepository) Claim(saga_id int, now time.Time, until time.Time) (bool, error) {
+	args := m.Called(saga_id, now, until)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSagaRepository) Save(saga model.Saga) error {
+	args := m.Called(saga)
+	return args.Error(0)
+}
+
+func (m *MockSagaRepository) GetDue(now time.Time, limit int) ([]model.Saga, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]model.Saga), args.Error(1)
+}
+
+func (m *MockSagaRepository) GetStuck(before time.Time) ([]model.Saga, error) {
+	args := m.Called(before)
+	return args.Get(0).([]model.Saga), args.Error(1)
+}
+
+func movesTo(to model.TransactionStatus) interface{} {
+	return mock.MatchedBy(func(entry model.TransactionStatusHistory) bool {
+		return entry.To_Status == to
+	})
+}
+
+func setupTransactionService() (*MockTransactionRepository, *MockSagaRepository, TransactionService) {
+	repo := new(MockTransactionRepository)
+	sagaRepo := new(MockSagaRepository)
+	return repo, sagaRepo, NewTransactionService(repo, sagaRepo)
+}
+
+// TestPurchaseFlow_FakeGateway runs a purchase from creating the
+// transaction to it being paid, with the fake provider standing in for
+// Midtrans: the charge is created, the buyer pays, and the notification
+// the provider sends is verified and applied.
+func TestPurchaseFlow_FakeGateway(t *testing.T) {
+	repo, _, service := setupTransactionService()
+	gateway := NewFakeGateway()
+
+	order := model.Transaction{User_ID: 1, Seller_ID: 2, Book_ID: 3, Amount: 75000, Status: model.StatusPending}
+	created := order
+	created.Transaction_ID = 10
+	repo.On("CreateTransaction", 1, order).Return(created, nil)
+
+	trans, err := service.CreateTransaction(1, order)
+	assert.NoError(t, err)
+
+	orderID := "10-1700000000"
+	link, err := gateway.CreateCharge(PaymentCharge{Order_ID: orderID, Amount: trans.GatewayAmount()})
+	assert.NoError(t, err)
+	assert.Equal(t, "/payments/fake/"+orderID, link.Redirect_URL)
+
+	repo.On("SetOrderID", 10, orderID).Return(nil)
+	assert.NoError(t, service.SetOrderID(10, orderID))
+	trans.Order_ID = orderID
+
+	// Nothing happens while the buyer has not paid
+	payment, err := gateway.VerifyNotification([]byte(`{"order_id":"` + orderID + `"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusPending, payment.Status)
+	repo.On("GetTransactionWithDeleted", 10).Return(trans, nil)
+	assert.NoError(t, service.ApplyPayment(payment, model.ActorMidtrans))
+	repo.AssertNotCalled(t, "TransitionStatus", mock.Anything, mock.Anything)
+
+	_, err = gateway.Simulate(orderID, "settlement")
+	assert.NoError(t, err)
+
+	payment, err = gateway.VerifyNotification([]byte(`{"order_id":"` + orderID + `"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusPaid, payment.Status)
+	assert.Equal(t, "settlement", payment.Provider_Status)
+
+	repo.On("GetTransactionByID", 10).Return(trans, nil)
+	repo.On("TransitionStatus", trans, movesTo(model.StatusPaid)).Return(true, nil)
+
+	err = service.ApplyPayment(payment, model.ActorMidtrans)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestPurchaseFlow_FakeGateway_Denied(t *testing.T) {
+	repo, _, service := setupTransactionService()
+	gateway := NewFakeGateway()
+
+	orderID := "10-1700000000"
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPending, Order_ID: orderID}
+	_, err := gateway.CreateCharge(PaymentCharge{Order_ID: orderID, Amount: trans.GatewayAmount()})
+	assert.NoError(t, err)
+
+	payment, err := gateway.Simulate(orderID, "deny")
+	assert.NoError(t, err)
+	assert.Equal(t, model.StatusCancelled, payment.Status)
+
+	repo.On("GetTransactionWithDeleted", 10).Return(trans, nil)
+	repo.On("GetTransactionByID", 10).Return(trans, nil)
+	repo.On("TransitionStatus", trans, movesTo(model.StatusCancelled)).Return(true, nil)
+
+	err = service.ApplyPayment(payment, model.ActorMidtrans)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestPurchaseFlow_FakeGateway_SplitPayment(t *testing.T) {
+	repo, _, service := setupTransactionService()
+	gateway := NewFakeGateway()
+
+	orderID := "10-1700000000"
+	trans := model.Transaction{
+		Transaction_ID: 10,
+		Amount:         75000,
+		Wallet_Amount:  25000,
+		Payment_Method: model.PayBySplit,
+		Status:         model.StatusPending,
+		Order_ID:       orderID,
+	}
+	_, err := gateway.CreateCharge(PaymentCharge{Order_ID: orderID, Amount: trans.GatewayAmount()})
+	assert.NoError(t, err)
+
+	payment, err := gateway.Simulate(orderID, "settlement")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(50000), payment.Gross_Amount)
+
+	repo.On("GetTransactionWithDeleted", 10).Return(trans, nil)
+	repo.On("GetTransactionByID", 10).Return(trans, nil)
+	repo.On("TransitionStatus", trans, movesTo(model.StatusPaid)).Return(true, nil)
+
+	err = service.ApplyPayment(payment, model.ActorMidtrans)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestApplyPayment_AmountMismatch(t *testing.T) {
+	repo, _, service := setupTransactionService()
+
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPending, Order_ID: "10-1700000000"}
+	repo.On("GetTransactionWithDeleted", 10).Return(trans, nil)
+
+	err := service.ApplyPayment(PaymentStatus{
+		Order_ID:     "10-1700000000",
+		Status:       model.StatusPaid,
+		Gross_Amount: 1000,
+	}, model.ActorMidtrans)
+
+	assert.Equal(t, utils.ErrBadReq, err)
+	repo.AssertNotCalled(t, "TransitionStatus", mock.Anything, mock.Anything)
+}
+
+func TestApplyPayment_OtherOrderID(t *testing.T) {
+	repo, _, service := setupTransactionService()
+
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPending, Order_ID: "10-1700000000"}
+	repo.On("GetTransactionWithDeleted", 10).Return(trans, nil)
+
+	err := service.ApplyPayment(PaymentStatus{
+		Order_ID:     "10-1699999999",
+		Status:       model.StatusPaid,
+		Gross_Amount: 75000,
+	}, model.ActorMidtrans)
+
+	assert.Equal(t, utils.ErrOrderNotFound, err)
+}
+
+func TestApplyPayment_UnknownFakeOrder(t *testing.T) {
+	gateway := NewFakeGateway()
+
+	_, err := gateway.VerifyNotification([]byte(`{"order_id":"10-1700000000"}`))
+	assert.Equal(t, utils.ErrOrderNotFound, err)
+
+	_, err = gateway.Simulate("10-1700000000", "settlement")
+	assert.Equal(t, utils.ErrOrderNotFound, err)
+}
+
+func TestApplyPayment_PaidAfterCancel(t *testing.T) {
+	repo, sagaRepo, service := setupTransactionService()
+	gateway := NewFakeGateway()
+
+	orderID := "10-1700000000"
+	trans := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusCancelled, Order_ID: orderID}
+	_, err := gateway.CreateCharge(PaymentCharge{Order_ID: orderID, Amount: trans.GatewayAmount()})
+	assert.NoError(t, err)
+	payment, err := gateway.Simulate(orderID, "settlement")
+	assert.NoError(t, err)
+
+	repo.On("GetTransactionWithDeleted", 10).Return(trans, nil)
+	sagaRepo.On("Create", mock.MatchedBy(func(saga model.Saga) bool {
+		return saga.Transaction_ID == 10 && saga.Kind == model.SagaLatePayment
+	})).Return(model.Saga{ID: 5}, nil)
+
+	err = service.ApplyPayment(payment, model.ActorMidtrans)
+
+	assert.NoError(t, err)
+	sagaRepo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "TransitionStatus", mock.Anything, mock.Anything)
+}
+
+func TestApplyPayment_Checkout(t *testing.T) {
+	repo, _, service := setupTransactionService()
+	gateway := NewFakeGateway()
+
+	orderID := "checkout-4-1700000000"
+	first := model.Transaction{Transaction_ID: 10, Amount: 50000, Status: model.StatusPending}
+	second := model.Transaction{Transaction_ID: 11, Amount: 25000, Status: model.StatusPending}
+	checkout := model.Checkout{
+		Checkout_ID:  4,
+		Amount:       75000,
+		Status:       model.StatusPending,
+		Order_ID:     orderID,
+		Transactions: []model.Transaction{first, second},
+	}
+	_, err := gateway.CreateCharge(PaymentCharge{Order_ID: orderID, Amount: checkout.Amount})
+	assert.NoError(t, err)
+	payment, err := gateway.Simulate(orderID, "settlement")
+	assert.NoError(t, err)
+
+	var order []string
+	repo.On("GetCheckoutByID", 4).Return(checkout, nil)
+	repo.On("GetTransactionByID", 10).Return(first, nil)
+	repo.On("GetTransactionByID", 11).Return(second, nil)
+	repo.On("TransitionStatus", first, movesTo(model.StatusPaid)).Return(true, nil).
+		Run(func(mock.Arguments) { order = append(order, "transaction 10") })
+	repo.On("TransitionStatus", second, movesTo(model.StatusPaid)).Return(true, nil).
+		Run(func(mock.Arguments) { order = append(order, "transaction 11") })
+	repo.On("TransitionCheckoutStatus", 4, model.StatusPending, model.StatusPaid).Return(true, nil).
+		Run(func(mock.Arguments) { order = append(order, "checkout") })
+
+	err = service.ApplyPayment(payment, model.ActorMidtrans)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"transaction 10", "transaction 11", "checkout"}, order)
+	repo.AssertExpectations(t)
+}
+
+func TestApplyPayment_CheckoutLeftWhenTransactionFails(t *testing.T) {
+	repo, _, service := setupTransactionService()
+
+	first := model.Transaction{Transaction_ID: 10, Amount: 75000, Status: model.StatusPending}
+	checkout := model.Checkout{
+		Checkout_ID:  4,
+		Amount:       75000,
+		Status:       model.StatusPending,
+		Order_ID:     "checkout-4-1700000000",
+		Transactions: []model.Transaction{first},
+	}
+	repo.On("GetCheckoutByID", 4).Return(checkout, nil)
+	repo.On("GetTransactionByID", 10).Return(first, nil)
+	repo.On("TransitionStatus", first, movesTo(model.StatusPaid)).Return(false, errors.New("db down"))
+
+	err := service.ApplyPayment(PaymentStatus{
+		Order_ID:     "checkout-4-1700000000",
+		Status:       model.StatusPaid,
+		Gross_Amount: 75000,
+	}, model.ActorMidtrans)
+
+	assert.Error(t, err)
+	repo.AssertNotCalled(t, "TransitionCheckoutStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// balanceCredit is one call to credit a user's balance.
+type balanceCredit struct {
+	user_id int
+	amount  float64
+	key     string
+}
+
+// setupTopUps returns a transaction service whose balance credits are
+// recorded in credits instead of being sent to auth-service.
+func setupTopUps(credits *[]balanceCredit) (*MockTransactionRepository, TransactionService) {
+	repo := new(MockTransactionRepository)
+	service := &transactionService{repo: repo, sagaRepo: new(MockSagaRepository), credit: func(user_id int, amount float64, key string) error {
+		*credits = append(*credits, balanceCredit{user_id, amount, key})
+		return nil
+	}}
+	return repo, service
+}
+
+// TestTopUpFlow_FakeGateway tops up a wallet from creating the top-up to
+// the user's balance being credited once the provider reports it paid.
+func TestTopUpFlow_FakeGateway(t *testing.T) {
+	var credits []balanceCredit
+	repo, service := setupTopUps(&credits)
+	gateway := NewFakeGateway()
+
+	repo.On("CreateTopUp", model.TopUp{User_ID: 1, Amount: 50000}).
+		Return(model.TopUp{TopUp_ID: 7, User_ID: 1, Amount: 50000, Status: model.StatusPending}, nil)
+	topUp, err := service.CreateTopUp(1, 50000)
+	assert.NoError(t, err)
+
+	orderID := "topup-7-1700000000"
+	repo.On("SetTopUpOrderID", 7, orderID).Return(nil)
+	assert.NoError(t, service.SetTopUpOrderID(7, orderID))
+	topUp.Order_ID = orderID
+	_, err = gateway.CreateCharge(PaymentCharge{Order_ID: orderID, Amount: topUp.Amount})
+	assert.NoError(t, err)
+
+	payment, err := gateway.Simulate(orderID, "settlement")
+	assert.NoError(t, err)
+
+	repo.On("GetTopUpByID", 7).Return(topUp, nil)
+	repo.On("TransitionTopUpStatus", 7, model.StatusPending, model.StatusPaid).Return(true, nil)
+
+	err = service.ApplyPayment(payment, model.ActorMidtrans)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []balanceCredit{{1, 50000, "topup-7-credit"}}, credits)
+	repo.AssertExpectations(t)
+}
+
+func TestApplyPayment_TopUpRepeatNotCredited(t *testing.T) {
+	var credits []balanceCredit
+	repo, service := setupTopUps(&credits)
+
+	topUp := model.TopUp{TopUp_ID: 7, User_ID: 1, Amount: 50000, Status: model.StatusPaid, Order_ID: "topup-7-1700000000"}
+	repo.On("GetTopUpByID", 7).Return(topUp, nil)
+
+	err := service.ApplyPayment(PaymentStatus{
+		Order_ID:     "topup-7-1700000000",
+		Status:       model.StatusPaid,
+		Gross_Amount: 50000,
+	}, model.ActorMidtrans)
+
+	assert.NoError(t, err)
+	assert.Empty(t, credits)
+	repo.AssertNotCalled(t, "TransitionTopUpStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestApplyPayment_TopUpDenied(t *testing.T) {
+	var credits []balanceCredit
+	repo, service := setupTopUps(&credits)
+
+	topUp := model.TopUp{TopUp_ID: 7, User_ID: 1, Amount: 50000, Status: model.StatusPending, Order_ID: "topup-7-1700000000"}
+	repo.On("GetTopUpByID", 7).Return(topUp, nil)
+	repo.On("TransitionTopUpStatus", 7, model.StatusPending, model.StatusCancelled).Return(true, nil)
+
+	err := service.ApplyPayment(PaymentStatus{
+		Order_ID:     "topup-7-1700000000",
+		Status:       model.StatusCancelled,
+		Gross_Amount: 50000,
+	}, model.ActorMidtrans)
+
+	assert.NoError(t, err)
+	assert.Empty(t, credits)
+	repo.AssertExpectations(t)
+}
+
+func TestApplyPayment_TopUpLeftPendingWhenCreditFails(t *testing.T) {
+	repo := new(MockTransactionRepository)
+	service := &transactionService{repo: repo, sagaRepo: new(MockSagaRepository), credit: func(int, float64, string) error {
+		return utils.ErrBadReq
+	}}
+
+	topUp := model.TopUp{TopUp_ID: 7, User_ID: 1, Amount: 50000, Status: model.StatusPending, Order_ID: "topup-7-1700000000"}
+	repo.On("GetTopUpByID", 7).Return(topUp, nil)
+
+	err := service.ApplyPayment(PaymentStatus{
+		Order_ID:     "topup-7-1700000000",
+		Status:       model.StatusPaid,
+		Gross_Amount: 50000,
+	}, model.ActorMidtrans)
+
+	assert.Equal(t, utils.ErrBadReq, err)
+	repo.AssertNotCalled(t, "TransitionTopUpStatus", mock.Anything, mock.Anything, mock.Anything)
+}
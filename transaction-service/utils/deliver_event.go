@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"main/model"
+	"net/http"
+	"time"
+)
+
+// DeliverEvent posts an outbox event to the audience service's
+// /internal/events endpoint at baseURL. The event ID is sent as the
+// Idempotency-Key too: the same event is delivered again whenever a
+// delivery is not acknowledged with a 2xx, and consumers use it to handle
+// each event once.
+func DeliverEvent(baseURL string, audience string, event model.OutboxEvent) error {
+	token, err := ServiceTokenFor(audience)
+	if err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"id":   event.Event_ID,
+		"type": event.Type,
+		"data": json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", baseURL+"/internal/events", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return ErrBadReq
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", event.Event_ID)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s returned status %d: %s", audience, resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// DeliverToBookService delivers an outbox event to book-service.
+func DeliverToBookService(event model.OutboxEvent) error {
+	return DeliverEvent(bookServiceURL(), "book-service", event)
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"main/model"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -28,57 +29,124 @@ type GetUserByIDResponse struct {
 	User    User   `json:"user"`
 }
 
+// EmailTransaction sends the buyer a receipt listing the transaction's
+// items as they were bought. email-service sends one receipt per
+// transaction however often it is asked.
 func EmailTransaction(trans model.Transaction) error {
-	urlGetUser := fmt.Sprintf("http://auth-service:8080/users/%d", trans.User_ID)
+	user, err := getUser(trans.User_ID)
+	if err != nil {
+		return err
+	}
 
-	req, err := http.NewRequest("GET", urlGetUser, nil)
+	items, product := receiptItems(trans)
+	emailPayload := map[string]interface{}{
+		"email":          user.Email,
+		"transaction_id": fmt.Sprintf("%d", trans.Transaction_ID),
+		"product":        product,
+		"items":          items,
+		"amount":         trans.Amount,
+		"status":         trans.Status,
+		"timestamp":      time.Now().Format("2006-01-02 15:04:05"),
+		"invoice_url":    "", // blank as requested
+	}
+
+	return sendEmail("/send-transaction-success", emailPayload, fmt.Sprintf("transaction-%d-receipt", trans.Transaction_ID))
+}
+
+// EmailRefund tells the buyer their transaction has been refunded, and
+// where the money went. email-service sends it once per transaction.
+func EmailRefund(trans model.Transaction, refund model.Refund) error {
+	user, err := getUser(trans.User_ID)
 	if err != nil {
 		return err
 	}
 
+	items, product := receiptItems(trans)
+	emailPayload := map[string]interface{}{
+		"email":          user.Email,
+		"transaction_id": fmt.Sprintf("%d", trans.Transaction_ID),
+		"product":        product,
+		"items":          items,
+		"amount":         refund.Amount,
+		"method":         refund.Method,
+		"reason":         refund.Reason,
+		"timestamp":      time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	return sendEmail("/send-refund", emailPayload, fmt.Sprintf("transaction-%d-refund-receipt", trans.Transaction_ID))
+}
+
+func getUser(user_id int) (User, error) {
+	urlGetUser := fmt.Sprintf("http://auth-service:8080/users/%d", user_id)
+
+	req, err := http.NewRequest("GET", urlGetUser, nil)
+	if err != nil {
+		return User{}, err
+	}
+
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil || resp.StatusCode >= 400 {
-		return ErrBadReq
+		return User{}, ErrBadReq
 	}
 	defer resp.Body.Close()
 
 	var userResp GetUserByIDResponse
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return err
+		return User{}, err
 	}
 
 	err = json.Unmarshal(body, &userResp)
 	if err != nil {
-		return err
+		return User{}, err
 	}
-	user := userResp.User
+	return userResp.User, nil
+}
 
-	emailPayload := map[string]interface{}{
-		"email":          user.Email,
-		"transaction_id": fmt.Sprintf("%d", trans.Transaction_ID),
-		"product":        "preloved book",
-		"amount":         trans.Amount,
-		"status":         trans.Status,
-		"timestamp":      time.Now().Format("2006-01-02 15:04:05"),
-		"invoice_url":    "", // blank as requested
+// receiptItems lists the transaction's items for an email, along with
+// their titles joined as its product.
+func receiptItems(trans model.Transaction) ([]map[string]interface{}, string) {
+	product := "preloved book"
+	items := make([]map[string]interface{}, 0, len(trans.Items))
+	titles := make([]string, 0, len(trans.Items))
+	for _, item := range trans.Items {
+		items = append(items, map[string]interface{}{
+			"title":      item.Title,
+			"qty":        item.Qty,
+			"unit_price": item.Unit_Price,
+			"subtotal":   item.Subtotal,
+		})
+		titles = append(titles, item.Title)
 	}
+	if len(titles) > 0 {
+		product = strings.Join(titles, ", ")
+	}
+	return items, product
+}
 
-	jsonData, err := json.Marshal(emailPayload)
+// sendEmail posts the payload to email-service's path. The idempotency key
+// keeps a retried request from sending the email twice.
+func sendEmail(path string, payload map[string]interface{}, idempotency_key string) error {
+	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	emailURL := "http://email-service:8084/send-transaction-success"
-	req, err = http.NewRequest("POST", emailURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequest("POST", "http://email-service:8084"+path, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return ErrBadReq
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", idempotency_key)
 
-	resp, err = client.Do(req)
-	if err != nil || resp.StatusCode >= 400 {
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ErrBadReq
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 400 {
 		return ErrBadReq
 	}
 
@@ -7,4 +7,31 @@ var (
 	ErrUserForbidden = errors.New("user not eligible")
 	ErrBadReq        = errors.New("request not valid")
 	ErrUnauthorized  = errors.New("no credentials or wrong credentials")
+
+	ErrCartItemNotFound = errors.New("book not in cart")
+	ErrCartEmpty        = errors.New("cart is empty")
+	ErrCheckoutNotFound = errors.New("checkout not found")
+
+	ErrTopUpNotFound = errors.New("top-up not found")
+	// ErrTopUpNotRefundable is returned when the provider reports a paid
+	// top-up refunded: the money is already in the user's wallet, so
+	// taking it back is left to finance.
+	ErrTopUpNotRefundable = errors.New("a top-up can not be refunded at the provider")
+
+	ErrInvalidTransition = errors.New("transaction can not move to that status")
+	ErrRefundNotReady    = errors.New("transaction can not be refunded until its payment has been processed")
+	// ErrRefundNeedsApproval is returned when a buyer asks for a refund of
+	// a transaction that has shipped, which its seller or an admin has to
+	// make instead.
+	ErrRefundNeedsApproval = errors.New("a shipped transaction can only be refunded by its seller or an admin")
+
+	// ErrPaymentNotCancellable is returned when the payment provider will
+	// no longer cancel a payment, typically because it has been paid.
+	ErrPaymentNotCancellable = errors.New("payment can no longer be cancelled")
+
+	ErrSagaNotFound = errors.New("saga not found")
+	ErrSagaState    = errors.New("saga can not do that in its current status")
+
+	ErrReconciliationRunNotFound = errors.New("reconciliation run not found")
+	ErrReconciliationRunning     = errors.New("a reconciliation run is already going")
 )
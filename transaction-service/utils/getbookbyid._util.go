@@ -3,36 +3,77 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"main/dto"
-
+	"main/model"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
-func GetBookByID(bookID uint, token string) (dto.BookResponse, error) {
-	var result dto.GetBookByIDResponse
+var ErrBookNotFound = errors.New("book not found")
 
-	url := fmt.Sprintf("http://book-service:8081/books/%d", bookID)
-	req, err := http.NewRequest("GET", url, nil)
+// GetBooksByIDs looks up many books in one call to book-service's internal
+// batch endpoint, keyed by ID. IDs book-service does not know are returned
+// as missing rather than as an error.
+func GetBooksByIDs(bookIDs []uint) (map[uint]dto.BookResponse, []uint, error) {
+	token, err := ServiceToken()
 	if err != nil {
-		return result.Data, err
+		return nil, nil, err
 	}
 
-	req.Header.Set("Authorization", token)
+	ids := make([]string, len(bookIDs))
+	for i, id := range bookIDs {
+		ids[i] = strconv.FormatUint(uint64(id), 10)
+	}
+	url := fmt.Sprintf("%s/internal/books?ids=%s", bookServiceURL(), strings.Join(ids, ","))
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := callBookService("GET", url, nil, "Bearer "+token)
 	if err != nil {
-		return result.Data, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return result.Data, fmt.Errorf("book-service returned status: %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("book-service returned status: %d", resp.StatusCode)
 	}
 
-	body, _ := io.ReadAll(resp.Body)
-	json.Unmarshal(body, &result)
-	return result.Data, nil
+	var result dto.GetBooksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, err
+	}
+
+	books := make(map[uint]dto.BookResponse, len(result.Data.Books))
+	for _, book := range result.Data.Books {
+		books[book.ID] = book
+	}
+	return books, result.Data.MissingIDs, nil
+}
+
+func GetBookByID(bookID uint) (dto.BookResponse, error) {
+	books, _, err := GetBooksByIDs([]uint{bookID})
+	if err != nil {
+		return dto.BookResponse{}, err
+	}
+
+	book, ok := books[bookID]
+	if !ok {
+		return dto.BookResponse{}, ErrBookNotFound
+	}
+	return book, nil
+}
+
+// SellerOf returns who sold the transaction's books. It is recorded on the
+// transaction; only the oldest ones need it looked up from the book.
+func SellerOf(trans model.Transaction) (int, error) {
+	if trans.Seller_ID != 0 {
+		return trans.Seller_ID, nil
+	}
+
+	book, err := GetBookByID(uint(trans.Book_ID))
+	if err != nil {
+		return 0, err
+	}
+	return int(book.SellerID), nil
 }
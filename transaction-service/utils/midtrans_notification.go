@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"main/dto"
+	"main/model"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid notification signature")
+	ErrOrderNotFound    = errors.New("order not found")
+)
+
+// VerifyNotificationSignature checks a Midtrans notification's
+// signature_key, the SHA512 of order_id, status_code, gross_amount and the
+// server key. Without a server key nothing verifies.
+func VerifyNotificationSignature(n dto.MidtransNotification, serverKey string) bool {
+	if serverKey == "" || n.SignatureKey == "" {
+		return false
+	}
+
+	sum := sha512.Sum512([]byte(n.OrderID + n.StatusCode + n.GrossAmount + serverKey))
+	expected := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(n.SignatureKey))) == 1
+}
+
+// OrderKind is what an order charged at the payment provider pays for.
+type OrderKind string
+
+const (
+	OrderTransaction OrderKind = "transaction"
+	OrderCheckout    OrderKind = "checkout"
+	OrderTopUp       OrderKind = "topup"
+)
+
+// ParseOrderID reads back the order IDs sent to Midtrans:
+// "<transaction_id>-<unix>" for a single transaction,
+// "checkout-<checkout_id>-<unix>" for a cart checkout and
+// "topup-<top_up_id>-<unix>" for a wallet top-up.
+func ParseOrderID(order_id string) (kind OrderKind, id int, err error) {
+	kind = OrderTransaction
+	parts := strings.Split(order_id, "-")
+	if len(parts) == 3 && (parts[0] == string(OrderCheckout) || parts[0] == string(OrderTopUp)) {
+		kind = OrderKind(parts[0])
+		parts = parts[1:]
+	}
+	if len(parts) != 2 {
+		return "", 0, ErrOrderNotFound
+	}
+	if _, err := strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return "", 0, ErrOrderNotFound
+	}
+
+	id, err = strconv.Atoi(parts[0])
+	if err != nil || id <= 0 {
+		return "", 0, ErrOrderNotFound
+	}
+	return kind, id, nil
+}
+
+// NotificationStatus maps a Midtrans transaction_status and fraud_status
+// to our transaction status. Captures flagged by fraud detection stay
+// pending until Midtrans decides on them. Statuses we do not act on, such
+// as authorize or partial_refund, map to "".
+func NotificationStatus(transaction_status string, fraud_status string) model.TransactionStatus {
+	switch transaction_status {
+	case "capture":
+		switch fraud_status {
+		case "", "accept":
+			return model.StatusPaid
+		case "challenge":
+			return model.StatusPending
+		default:
+			return model.StatusCancelled
+		}
+	case "settlement":
+		return model.StatusPaid
+	case "pending":
+		return model.StatusPending
+	case "deny", "cancel":
+		return model.StatusCancelled
+	case "expire":
+		return model.StatusExpired
+	case "refund":
+		return model.StatusRefunded
+	}
+	return ""
+}
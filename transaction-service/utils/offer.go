@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"main/dto"
+	"net/http"
+)
+
+var ErrOfferUnavailable = errors.New("accepted offer is no longer available")
+
+// GetAcceptedOffer returns the buyer's accepted offer for the book from
+// book-service, or nil when they have none.
+func GetAcceptedOffer(bookID uint, token string) (*dto.OfferResponse, error) {
+	url := fmt.Sprintf("%s/books/%d/offers/accepted", bookServiceURL(), bookID)
+
+	resp, err := callBookService("GET", url, nil, token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var result dto.GetOfferResponse
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return nil, err
+		}
+		return &result.Data, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to get accepted offer: %s", string(bodyBytes))
+	}
+}
+
+// RedeemOffer marks the offer as used by the transaction so its price
+// cannot be applied to another purchase.
+func RedeemOffer(offerID uint, transactionID uint, token string) error {
+	url := fmt.Sprintf("%s/offers/%d/redeem", bookServiceURL(), offerID)
+
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"transaction_id": transactionID,
+	})
+
+	resp, err := callBookService("POST", url, jsonData, token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound, http.StatusConflict:
+		return ErrOfferUnavailable
+	default:
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to redeem offer: %s", string(bodyBytes))
+	}
+}
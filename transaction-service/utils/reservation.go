@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+var (
+	ErrInsufficientStock   = errors.New("quantity exceeds available stock")
+	ErrReservationNotFound = errors.New("reservation not found")
+)
+
+func bookServiceURL() string {
+	if url := os.Getenv("BOOK_SERVICE_URL"); url != "" {
+		return url
+	}
+	return "http://book-service:8081"
+}
+
+// ReserveStock holds qty copies of the book for the transaction in
+// book-service until ttl passes.
+func ReserveStock(bookID uint, transactionID uint, qty int, ttl time.Duration) error {
+	token, err := ServiceToken()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/books/%d/reservations", bookServiceURL(), bookID)
+
+	data := map[string]interface{}{
+		"transaction_id": transactionID,
+		"quantity":       qty,
+		"ttl_seconds":    int(ttl.Seconds()),
+	}
+	jsonData, _ := json.Marshal(data)
+
+	resp, err := callBookService("POST", url, jsonData, "Bearer "+token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return nil
+	case http.StatusConflict:
+		return ErrInsufficientStock
+	default:
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to reserve stock: %s", string(bodyBytes))
+	}
+}
+
+// ConfirmReservation turns the transaction's reservation into a stock
+// deduction. It is ErrInsufficientStock when the reservation expired and
+// its copies have since gone to other buyers.
+func ConfirmReservation(transactionID uint) error {
+	token, err := ServiceToken()
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/reservations/%d/confirm", bookServiceURL(), transactionID)
+
+	resp, err := callBookService("POST", url, nil, "Bearer "+token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrReservationNotFound
+	case http.StatusConflict:
+		return ErrInsufficientStock
+	default:
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to confirm reservation: %s", string(bodyBytes))
+	}
+}
+
+func callBookService(method string, url string, body []byte, token string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, ErrBadReq
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", token)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	return client.Do(req)
+}
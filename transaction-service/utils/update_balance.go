@@ -3,11 +3,21 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
-func UpdateBalance(user_id int, amount float64) error {
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+// UpdateBalance adds amount to the user's balance in auth-service. A
+// request repeated with the same idempotency key is only applied once.
+func UpdateBalance(user_id int, amount float64, idempotency_key string) error {
+	token, err := ServiceTokenFor("auth-service")
+	if err != nil {
+		return err
+	}
+
 	url := fmt.Sprintf("http://auth-service:8080/users/%d", user_id)
 
 	data := map[string]interface{}{
@@ -20,6 +30,8 @@ func UpdateBalance(user_id int, amount float64) error {
 		return ErrBadReq
 	}
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", idempotency_key)
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -30,3 +42,47 @@ func UpdateBalance(user_id int, amount float64) error {
 
 	return nil
 }
+
+// WalletPaymentKey is the idempotency key of the debit paying for a
+// transaction from the buyer's wallet.
+func WalletPaymentKey(transaction_id uint) string {
+	return fmt.Sprintf("transaction-%d-wallet", transaction_id)
+}
+
+// DebitBalance takes amount off the user's balance in auth-service. It
+// fails with ErrInsufficientBalance rather than overdraw it, and a request
+// repeated with the same idempotency key is only applied once.
+func DebitBalance(user_id int, amount float64, idempotency_key string) error {
+	token, err := ServiceTokenFor("auth-service")
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("http://auth-service:8080/users/%d/balance/debit", user_id)
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"amount": amount,
+	})
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return ErrBadReq
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Idempotency-Key", idempotency_key)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return ErrBadReq
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusConflict:
+		return ErrInsufficientBalance
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("auth-service returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
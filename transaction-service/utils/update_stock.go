@@ -1,35 +1,73 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"main/model"
 	"net/http"
 )
 
-func UpdateStock(trans model.Transaction, qty int, token string) error {
-	url := fmt.Sprintf("http://book-service:8081/books/%d/%d", trans.Book_ID, qty)
+// DeductStock takes the items' quantities off their books' stock through
+// book-service's internal batch endpoint. book-service applies each
+// idempotency key once, so a retried call never deducts twice.
+func DeductStock(idempotency_key string, items []model.TransactionItem) error {
+	return changeStock("deduct", idempotency_key, items)
+}
+
+// RestockStock puts the items' quantities back on their books' stock,
+// under the same once-per-key guarantee as DeductStock.
+func RestockStock(idempotency_key string, items []model.TransactionItem) error {
+	return changeStock("restock", idempotency_key, items)
+}
 
-	req, err := http.NewRequest("PATCH", url, nil)
+func changeStock(kind string, idempotency_key string, items []model.TransactionItem) error {
+	token, err := ServiceToken()
 	if err != nil {
-		return ErrBadReq
+		return err
 	}
 
-	// Set required headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token) // ✅ Add Bearer token here
+	stockItems := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		stockItems = append(stockItems, map[string]interface{}{
+			"book_id":  item.Book_ID,
+			"quantity": item.Qty,
+		})
+	}
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"idempotency_key": idempotency_key,
+		"items":           stockItems,
+	})
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	url := fmt.Sprintf("%s/internal/stock/%s", bookServiceURL(), kind)
+	resp, err := callBookService("POST", url, jsonData, "Bearer "+token)
 	if err != nil {
-		return ErrBadReq
+		return err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return ErrInsufficientStock
+	default:
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to update stock: %s", string(bodyBytes))
+		return fmt.Errorf("failed to %s stock: %s", kind, string(bodyBytes))
+	}
+}
+
+// ConfirmStock confirms the stock reserved for the transaction, which is
+// safe to repeat. Transactions without a reservation, such as ones created
+// before reservations existed, have their items deducted directly instead.
+func ConfirmStock(trans model.Transaction) error {
+	err := ConfirmReservation(trans.Transaction_ID)
+	if err != ErrReservationNotFound {
+		return err
 	}
 
-	return nil
+	if len(trans.Items) == 0 {
+		return nil
+	}
+	return DeductStock(fmt.Sprintf("transaction-%d-deduct", trans.Transaction_ID), trans.Items)
 }